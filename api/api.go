@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/CircleCI-Public/circleci-cli/api/graphql"
+	"github.com/CircleCI-Public/circleci-cli/api/header"
 	"github.com/CircleCI-Public/circleci-cli/pipeline"
 	"github.com/CircleCI-Public/circleci-cli/references"
 	"github.com/CircleCI-Public/circleci-cli/settings"
@@ -129,6 +130,16 @@ type OrbSetOrbListStatusResponse struct {
 	}
 }
 
+// OrbDeleteVersionResponse type matches the data shape of the GQL response for
+// deleting a dev version of an orb
+type OrbDeleteVersionResponse struct {
+	DeleteOrbVersion struct {
+		Deleted bool
+
+		Errors GQLErrorsCollection
+	}
+}
+
 // OrbLatestVersionResponse wraps the GQL result of fetching an Orb and latest version
 type OrbLatestVersionResponse struct {
 	Orb struct {
@@ -324,22 +335,42 @@ type OrbCategoriesForListing struct {
 	OrbCategories []OrbCategory `json:"orbCategories"`
 }
 
-// SortBy allows us to sort a collection of orbs by builds, projects, or orgs from the last 30 days of data.
-func (orbs *OrbsForListing) SortBy(sortBy string) {
+// OrbSortKeys are the keys a collection of orbs can be sorted by with SortBy.
+var OrbSortKeys = []string{"name", "created", "usage", "builds", "projects", "orgs"}
+
+// SortBy allows us to sort a collection of orbs by name, by when they were
+// created, newest first, or by their builds (usage), projects, or orgs from the
+// last 30 days of data, most first. The order is flipped when reverse is set.
+// Orbs that are equal keep their order.
+func (orbs *OrbsForListing) SortBy(sortBy string, reverse bool) {
+	var less func(a, b OrbWithData) bool
 	switch sortBy {
-	case "builds":
-		sort.Slice(orbs.Orbs, func(i, j int) bool {
-			return orbs.Orbs[i].Statistics.Last30DaysBuildCount > orbs.Orbs[j].Statistics.Last30DaysBuildCount
-		})
+	case "name":
+		less = func(a, b OrbWithData) bool { return a.Name < b.Name }
+	case "created":
+		less = func(a, b OrbWithData) bool { return a.CreatedAt > b.CreatedAt }
+	case "usage", "builds":
+		less = func(a, b OrbWithData) bool {
+			return a.Statistics.Last30DaysBuildCount > b.Statistics.Last30DaysBuildCount
+		}
 	case "projects":
-		sort.Slice(orbs.Orbs, func(i, j int) bool {
-			return orbs.Orbs[i].Statistics.Last30DaysProjectCount > orbs.Orbs[j].Statistics.Last30DaysProjectCount
-		})
+		less = func(a, b OrbWithData) bool {
+			return a.Statistics.Last30DaysProjectCount > b.Statistics.Last30DaysProjectCount
+		}
 	case "orgs":
-		sort.Slice(orbs.Orbs, func(i, j int) bool {
-			return orbs.Orbs[i].Statistics.Last30DaysOrganizationCount > orbs.Orbs[j].Statistics.Last30DaysOrganizationCount
-		})
+		less = func(a, b OrbWithData) bool {
+			return a.Statistics.Last30DaysOrganizationCount > b.Statistics.Last30DaysOrganizationCount
+		}
+	default:
+		return
 	}
+
+	sort.SliceStable(orbs.Orbs, func(i, j int) bool {
+		if reverse {
+			return less(orbs.Orbs[j], orbs.Orbs[i])
+		}
+		return less(orbs.Orbs[i], orbs.Orbs[j])
+	})
 }
 
 // OrbBase represents the minimum fields we wish to serialize for orbs.
@@ -365,6 +396,9 @@ type OrbStatistics struct {
 type OrbWithData struct {
 	OrbBase
 
+	// CreatedAt is only used for sorting, so it isn't serialized
+	CreatedAt string
+
 	Commands  map[string]OrbElement
 	Jobs      map[string]OrbElement
 	Executors map[string]OrbElement
@@ -480,8 +514,9 @@ type FollowedProject struct {
 	Message  string `json:"message"`
 }
 
+// LoadYaml reads the config at path, or from STDIN when path is `-`.
 // #nosec
-func loadYaml(path string) (string, error) {
+func LoadYaml(path string) (string, error) {
 	var err error
 	var config []byte
 	if path == "-" {
@@ -491,7 +526,11 @@ func loadYaml(path string) (string, error) {
 	}
 
 	if err != nil {
-		return "", errors.Wrapf(err, "Could not load config file at %s", path)
+		source := path
+		if path == "-" {
+			source = "<stdin>"
+		}
+		return "", errors.Wrapf(err, "Could not load config file at %s", source)
 	}
 
 	return string(config), nil
@@ -515,14 +554,20 @@ func WhoamiQuery(cl *graphql.Client) (*WhoamiResponse, error) {
 
 // ConfigQuery calls the GQL API to validate and process config
 func ConfigQuery(cl *graphql.Client, configPath string, orgSlug string, params pipeline.Parameters, values pipeline.Values) (*ConfigResponse, error) {
-	var response BuildConfigResponse
-	var query string
-
-	config, err := loadYaml(configPath)
+	config, err := LoadYaml(configPath)
 	if err != nil {
 		return nil, err
 	}
 
+	return ConfigSourceQuery(cl, config, orgSlug, params, values)
+}
+
+// ConfigSourceQuery is the same as ConfigQuery, but takes the config itself
+// rather than the path to it.
+func ConfigSourceQuery(cl *graphql.Client, config string, orgSlug string, params pipeline.Parameters, values pipeline.Values) (*ConfigResponse, error) {
+	var response BuildConfigResponse
+	var query string
+
 	// GraphQL isn't forwards-compatible, so we are unusually selective here about
 	// passing only non-empty fields on to the API, to minimize user impact if the
 	// backend is out of date.
@@ -561,7 +606,7 @@ func ConfigQuery(cl *graphql.Client, configPath string, orgSlug string, params p
 	}
 	request.SetToken(cl.Token)
 
-	err = cl.Run(request, &response)
+	err := cl.Run(request, &response)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "Unable to validate config")
@@ -576,13 +621,19 @@ func ConfigQuery(cl *graphql.Client, configPath string, orgSlug string, params p
 
 // OrbQuery validated and processes an orb.
 func OrbQuery(cl *graphql.Client, configPath string) (*ConfigResponse, error) {
-	var response OrbConfigResponse
-
-	config, err := loadYaml(configPath)
+	config, err := LoadYaml(configPath)
 	if err != nil {
 		return nil, err
 	}
 
+	return OrbSourceQuery(cl, config)
+}
+
+// OrbSourceQuery validates and processes the orb source given in config, for
+// orbs which aren't in a file, such as those packed from a directory.
+func OrbSourceQuery(cl *graphql.Client, config string) (*ConfigResponse, error) {
+	var response OrbConfigResponse
+
 	query := `
 		query ValidateOrb ($config: String!) {
 			orbConfig(orbYaml: $config) {
@@ -597,7 +648,7 @@ func OrbQuery(cl *graphql.Client, configPath string) (*ConfigResponse, error) {
 	request.Var("config", config)
 	request.SetToken(cl.Token)
 
-	err = cl.Run(request, &response)
+	err := cl.Run(request, &response)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "Unable to validate config")
@@ -651,13 +702,19 @@ func OrbImportVersion(cl *graphql.Client, orbSrc string, orbID string, orbVersio
 // OrbPublishByName publishes a new version of an orb using the provided orb's name and namespace, returning any
 // error encountered.
 func OrbPublishByName(cl *graphql.Client, configPath, orbName, namespaceName, orbVersion string) (*Orb, error) {
-	var response OrbPublishResponse
-
-	config, err := loadYaml(configPath)
+	config, err := LoadYaml(configPath)
 	if err != nil {
 		return nil, err
 	}
 
+	return OrbPublishSourceByName(cl, config, orbName, namespaceName, orbVersion)
+}
+
+// OrbPublishSourceByName publishes the orb source given in config as a new version of the orb, for orbs which aren't
+// in a file, such as those packed from a directory.
+func OrbPublishSourceByName(cl *graphql.Client, config, orbName, namespaceName, orbVersion string) (*Orb, error) {
+	var response OrbPublishResponse
+
 	query := `
 		mutation($config: String!, $orbName: String, $namespaceName: String, $version: String!) {
 			publishOrb(
@@ -682,7 +739,7 @@ func OrbPublishByName(cl *graphql.Client, configPath, orbName, namespaceName, or
 	request.Var("namespaceName", namespaceName)
 	request.Var("version", orbVersion)
 
-	err = cl.Run(request, &response)
+	err := cl.Run(request, &response)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "Unable to publish orb")
@@ -802,7 +859,8 @@ func CreateImportedNamespace(cl *graphql.Client, name string) (*ImportNamespaceR
 	return &response, nil
 }
 
-func createNamespaceWithOwnerID(cl *graphql.Client, name string, ownerID string) (*CreateNamespaceResponse, error) {
+// CreateNamespaceWithOwnerID creates (reserves) a namespace for the organization with the given ID
+func CreateNamespaceWithOwnerID(cl *graphql.Client, name string, ownerID string) (*CreateNamespaceResponse, error) {
 	var response CreateNamespaceResponse
 
 	query := `
@@ -867,6 +925,153 @@ func getOrganization(cl *graphql.Client, organizationName string, organizationVc
 	return &response, nil
 }
 
+// An Organization is a VCS organization, which CircleCI knows both by its ID and by its slug of VCS type and name.
+type Organization struct {
+	ID      string
+	Name    string
+	VCSType string
+}
+
+// Slug returns the organization's slug, such as github/example-org.
+func (o Organization) Slug() string {
+	return o.VCSType + "/" + o.Name
+}
+
+// OrganizationBySlug returns the organization with the given VCS type and name.
+func OrganizationBySlug(cl *graphql.Client, vcsType string, name string) (*Organization, error) {
+	response, err := getOrganization(cl, name, vcsType)
+	if err != nil {
+		return nil, errors.Wrap(organizationNotFound(name, vcsType), err.Error())
+	}
+	if response.Organization.ID == "" {
+		return nil, organizationNotFound(name, vcsType)
+	}
+
+	return &Organization{
+		ID:      response.Organization.ID,
+		Name:    name,
+		VCSType: strings.ToLower(vcsType),
+	}, nil
+}
+
+// OrganizationByID returns the organization with the given ID.
+func OrganizationByID(cl *graphql.Client, id string) (*Organization, error) {
+	var response struct {
+		Organization struct {
+			ID      string
+			Name    string
+			VCSType string
+		}
+	}
+
+	query := `query($id: ID!) {
+				organization(id: $id) {
+					id
+					name
+					vcsType
+				}
+			}`
+
+	request := graphql.NewRequest(query)
+	request.SetToken(cl.Token)
+	request.Var("id", id)
+
+	err := cl.Run(request, &response)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to find organization with id %s", id)
+	}
+	if response.Organization.ID == "" {
+		return nil, fmt.Errorf("the organization with id '%s' does not exist", id)
+	}
+
+	return &Organization{
+		ID:      response.Organization.ID,
+		Name:    response.Organization.Name,
+		VCSType: strings.ToLower(response.Organization.VCSType),
+	}, nil
+}
+
+// OrganizationNamespace is a namespace owned by an organization.
+type OrganizationNamespace struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// OrganizationNamespacesResponse matches the result from GQL for a page of
+// the namespaces of an organization.
+type OrganizationNamespacesResponse struct {
+	Organization struct {
+		ID                 string
+		RegistryNamespaces struct {
+			Edges []struct {
+				Cursor string
+				Node   OrganizationNamespace
+			}
+			PageInfo struct {
+				HasNextPage bool
+			}
+		}
+	}
+}
+
+// ListOrganizationNamespaces returns the namespaces of the organization with
+// the given ID that the token can see, following every page of them.
+func ListOrganizationNamespaces(cl *graphql.Client, id string) ([]OrganizationNamespace, error) {
+	query := `
+query organizationNamespaces ($id: ID!, $after: String!) {
+	organization(id: $id) {
+		id
+		registryNamespaces(first: 20, after: $after) {
+			edges {
+				cursor
+				node {
+					id
+					name
+					createdAt
+				}
+			}
+			pageInfo {
+				hasNextPage
+			}
+		}
+	}
+}
+`
+	namespaces := []OrganizationNamespace{}
+	currentCursor := ""
+
+	for {
+		var result OrganizationNamespacesResponse
+
+		request := graphql.NewRequest(query)
+		request.SetToken(cl.Token)
+		request.Var("after", currentCursor)
+		request.Var("id", id)
+
+		err := cl.Run(request, &result)
+		if err != nil {
+			return nil, errors.Wrap(err, "GraphQL query failed")
+		}
+
+		if result.Organization.ID == "" {
+			return nil, fmt.Errorf("the organization with id '%s' does not exist", id)
+		}
+
+		for i := range result.Organization.RegistryNamespaces.Edges {
+			edge := result.Organization.RegistryNamespaces.Edges[i]
+			currentCursor = edge.Cursor
+			namespaces = append(namespaces, edge.Node)
+		}
+
+		if !result.Organization.RegistryNamespaces.PageInfo.HasNextPage {
+			break
+		}
+	}
+
+	return namespaces, nil
+}
+
 func namespaceNotFound(name string) error {
 	return fmt.Errorf("the namespace '%s' does not exist. Did you misspell the namespace, or maybe you meant to create the namespace first?", name)
 }
@@ -959,7 +1164,7 @@ func CreateNamespace(cl *graphql.Client, name string, organizationName string, o
 		return nil, errors.Wrap(organizationNotFound(organizationName, organizationVcs), getOrgError.Error())
 	}
 
-	createNSResponse, createNSError := createNamespaceWithOwnerID(cl, name, getOrgResponse.Organization.ID)
+	createNSResponse, createNSError := CreateNamespaceWithOwnerID(cl, name, getOrgResponse.Organization.ID)
 
 	if createNSError != nil {
 		return nil, createNSError
@@ -1181,29 +1386,35 @@ func incrementVersion(version string, segment string) (string, error) {
 	return v2.String(), nil
 }
 
-// OrbIncrementVersion accepts an orb and segment to increment the orb.
-func OrbIncrementVersion(cl *graphql.Client, configPath string, namespace string, orb string, segment string) (*Orb, error) {
-	v, err := OrbLatestVersion(cl, namespace, orb)
-	if err != nil {
-		return nil, err
-	}
-
-	v2, err := incrementVersion(v, segment)
+// OrbNextVersion returns the version that incrementing segment of the orb's
+// latest published version gives. It returns an error if the orb hasn't
+// published a version yet, as there is nothing to increment.
+func OrbNextVersion(cl *graphql.Client, namespace string, orb string, segment string) (string, error) {
+	v, err := orbLatestVersion(cl, namespace, orb)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	response, err := OrbPublishByName(cl, configPath, orb, namespace, v2)
-	if err != nil {
-		return nil, err
+	if v == "" {
+		return "", fmt.Errorf("Orb %s/%s has no published versions to increment, publish its first version with `circleci orb publish <path> %s/%s@<version>`", namespace, orb, namespace, orb)
 	}
 
-	return response, nil
+	return incrementVersion(v, segment)
 }
 
 // OrbLatestVersion finds the latest published version of an orb and returns it.
 // If it doesn't find a version, it will return 0.0.0 for the orb's version
 func OrbLatestVersion(cl *graphql.Client, namespace string, orb string) (string, error) {
+	v, err := orbLatestVersion(cl, namespace, orb)
+	if err != nil || v != "" {
+		return v, err
+	}
+	return "0.0.0", nil
+}
+
+// orbLatestVersion is OrbLatestVersion, but returns an empty version when the
+// orb hasn't published one.
+func orbLatestVersion(cl *graphql.Client, namespace string, orb string) (string, error) {
 	name := namespace + "/" + orb
 
 	var response OrbLatestVersionResponse
@@ -1228,7 +1439,7 @@ func OrbLatestVersion(cl *graphql.Client, namespace string, orb string) (string,
 	}
 
 	if len(response.Orb.Versions) != 1 {
-		return "0.0.0", nil
+		return "", nil
 	}
 
 	return response.Orb.Versions[0].Version, nil
@@ -1329,6 +1540,55 @@ func OrbSetOrbListStatus(cl *graphql.Client, namespace string, orb string, list
 	return &response.SetOrbListStatus.Listed, nil
 }
 
+// OrbDeleteDevVersion deletes the dev version of an orb, such as dev:alpha.
+// The registry only allows dev versions to be deleted, as published versions
+// are immutable.
+func OrbDeleteDevVersion(cl *graphql.Client, namespace string, orb string, version string) error {
+	id, err := OrbID(cl, namespace, orb)
+	if err != nil {
+		return err
+	}
+
+	var response OrbDeleteVersionResponse
+
+	query := `
+		mutation($orbId: UUID!, $version: String!) {
+			deleteOrbVersion(
+				orbId: $orbId,
+				version: $version
+			) {
+				deleted
+				errors {
+					message
+					type
+				}
+			}
+		}
+	`
+
+	request := graphql.NewRequest(query)
+	request.SetToken(cl.Token)
+
+	request.Var("orbId", id.Orb.ID)
+	request.Var("version", version)
+
+	err = cl.Run(request, &response)
+
+	if len(response.DeleteOrbVersion.Errors) > 0 {
+		return response.DeleteOrbVersion.Errors
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "Unable to delete orb version")
+	}
+
+	if !response.DeleteOrbVersion.Deleted {
+		return errors.New("Orb version deletion failed for unknown reasons.")
+	}
+
+	return nil
+}
+
 // orbVersionRef is designed to ensure an orb reference fits the orbVersion query where orbVersionRef argument requires a version
 func orbVersionRef(orb string) string {
 	split := strings.Split(orb, "@")
@@ -1342,6 +1602,45 @@ func orbVersionRef(orb string) string {
 	return fmt.Sprintf("%s@%s", split[0], "volatile")
 }
 
+// OrbResolvedVersion returns the concrete version that orbRef resolves to when
+// it's given with a partial version, like `circleci/node@5`, or a label like
+// `@volatile`.
+func OrbResolvedVersion(cl *graphql.Client, orbRef string) (string, error) {
+	ref := orbVersionRef(orbRef)
+
+	var response struct {
+		OrbVersion struct {
+			ID      string
+			Version string
+		}
+	}
+
+	query := `query($orbVersionRef: String!) {
+			    orbVersion(orbVersionRef: $orbVersionRef) {
+			        id
+			        version
+			    }
+		      }`
+
+	request := graphql.NewRequest(query)
+	request.SetToken(cl.Token)
+	request.Var("orbVersionRef", ref)
+	request.Cacheable = true
+
+	err := cl.Run(request, &response)
+	if err != nil {
+		return "", err
+	}
+
+	if response.OrbVersion.ID == "" {
+		return "", &ErrOrbVersionNotExists{
+			OrbRef: ref,
+		}
+	}
+
+	return response.OrbVersion.Version, nil
+}
+
 // OrbSource gets the source of an orb
 func OrbSource(cl *graphql.Client, orbRef string) (string, error) {
 	if err := references.IsOrbRefWithOptionalVersion(orbRef); err != nil {
@@ -1365,6 +1664,7 @@ func OrbSource(cl *graphql.Client, orbRef string) (string, error) {
 
 	request := graphql.NewRequest(query)
 	request.Var("orbVersionRef", ref)
+	request.Cacheable = true
 
 	err := cl.Run(request, &response)
 	if err != nil {
@@ -1434,6 +1734,7 @@ func OrbInfo(cl *graphql.Client, orbRef string) (*OrbVersion, error) {
 	request := graphql.NewRequest(query)
 	request.SetToken(cl.Token)
 	request.Var("orbVersionRef", ref)
+	request.Cacheable = true
 
 	err := cl.Run(request, &response)
 	if err != nil {
@@ -1466,6 +1767,21 @@ func OrbInfo(cl *graphql.Client, orbRef string) (*OrbVersion, error) {
 // ListOrbs queries the API to find all orbs.
 // Returns a collection of Orb objects containing their relevant data.
 func ListOrbs(cl *graphql.Client, uncertified bool) (*OrbsForListing, error) {
+	var orbs OrbsForListing
+	err := EachOrb(cl, uncertified, func(orb OrbWithData) error {
+		orbs.Orbs = append(orbs.Orbs, orb)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &orbs, nil
+}
+
+// EachOrb queries the API to find all orbs, as ListOrbs does, but calls fn
+// with each orb as its page of results arrives rather than collecting them.
+// An error from fn stops the listing and is returned.
+func EachOrb(cl *graphql.Client, uncertified bool, fn func(OrbWithData) error) error {
 	l := log.New(os.Stderr, "", 0)
 
 	query := `
@@ -1476,6 +1792,7 @@ query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
 		cursor
 	  node {
 	    name
+	    createdAt
 	    statistics {
 		last30DaysBuildCount,
 		last30DaysProjectCount,
@@ -1494,8 +1811,6 @@ query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
 }
 `
 
-	var orbs OrbsForListing
-
 	var result OrbListResponse
 	currentCursor := ""
 
@@ -1507,7 +1822,7 @@ query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
 
 		err := cl.Run(request, &result)
 		if err != nil {
-			return nil, errors.Wrap(err, "GraphQL query failed")
+			return errors.Wrap(err, "GraphQL query failed")
 		}
 
 	Orbs:
@@ -1528,7 +1843,9 @@ query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
 					continue Orbs
 				}
 
-				orbs.Orbs = append(orbs.Orbs, edge.Node)
+				if err := fn(edge.Node); err != nil {
+					return err
+				}
 			}
 		}
 
@@ -1536,7 +1853,7 @@ query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
 			break
 		}
 	}
-	return &orbs, nil
+	return nil
 }
 
 // ListNamespaceOrbVersions queries the API to retrieve the orbs belonging to the given namespace.
@@ -1615,6 +1932,22 @@ query namespaceOrbs ($namespace: String, $after: String!) {
 // namespace.
 // Returns a collection of Orb objects containing their relevant data.
 func ListNamespaceOrbs(cl *graphql.Client, namespace string, isPrivate bool) (*OrbsForListing, error) {
+	orbs := OrbsForListing{Namespace: namespace}
+	err := EachNamespaceOrb(cl, namespace, isPrivate, func(orb OrbWithData) error {
+		orbs.Orbs = append(orbs.Orbs, orb)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &orbs, nil
+}
+
+// EachNamespaceOrb queries the API to find all orbs belonging to the given
+// namespace, as ListNamespaceOrbs does, but calls fn with each orb as its
+// page of results arrives rather than collecting them. An error from fn
+// stops the listing and is returned.
+func EachNamespaceOrb(cl *graphql.Client, namespace string, isPrivate bool, fn func(OrbWithData) error) error {
 	l := log.New(os.Stderr, "", 0)
 
 	query := `
@@ -1631,6 +1964,7 @@ query namespaceOrbs ($namespace: String, $after: String!, $view: OrbListViewType
 						version
 					}
 					name
+					createdAt
 	                                statistics {
 		                           last30DaysBuildCount,
 		                           last30DaysProjectCount,
@@ -1646,7 +1980,6 @@ query namespaceOrbs ($namespace: String, $after: String!, $view: OrbListViewType
 	}
 }
 `
-	var orbs OrbsForListing
 	var result NamespaceOrbResponse
 	currentCursor := ""
 
@@ -1662,15 +1995,13 @@ query namespaceOrbs ($namespace: String, $after: String!, $view: OrbListViewType
 		request.Var("namespace", namespace)
 		request.Var("view", view)
 
-		orbs.Namespace = namespace
-
 		err := cl.Run(request, &result)
 		if err != nil {
-			return nil, errors.Wrap(err, "GraphQL query failed")
+			return errors.Wrap(err, "GraphQL query failed")
 		}
 
 		if result.RegistryNamespace.ID == "" {
-			return nil, errors.New("No namespace found")
+			return errors.New("No namespace found")
 		}
 
 	NamespaceOrbs:
@@ -1692,7 +2023,9 @@ query namespaceOrbs ($namespace: String, $after: String!, $view: OrbListViewType
 				edge.Node.HighestVersion = "Not published"
 			}
 
-			orbs.Orbs = append(orbs.Orbs, edge.Node)
+			if err := fn(edge.Node); err != nil {
+				return err
+			}
 		}
 
 		if !result.RegistryNamespace.Orbs.PageInfo.HasNextPage {
@@ -1700,7 +2033,7 @@ query namespaceOrbs ($namespace: String, $after: String!, $view: OrbListViewType
 		}
 	}
 
-	return &orbs, nil
+	return nil
 }
 
 // IntrospectionQuery makes a query on the API asking for bits of the schema
@@ -1893,7 +2226,7 @@ func FollowProject(config settings.Config, vcs string, owner string, projectName
 		return FollowedProject{}, err
 	}
 	if response.StatusCode >= 400 {
-		return FollowedProject{}, errors.New("Could not follow project")
+		return FollowedProject{}, header.WithRequestID(errors.New("Could not follow project"), response)
 	}
 
 	var fr FollowedProject
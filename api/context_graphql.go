@@ -35,7 +35,7 @@ type contextsQueryResponse struct {
 }
 
 func improveVcsTypeError(err error) error {
-	if responseErrors, ok := err.(graphql.ResponseErrorsCollection); ok {
+	if responseErrors, ok := errors.Cause(err).(graphql.ResponseErrorsCollection); ok {
 		if len(responseErrors) > 0 {
 			details := responseErrors[0].Extensions
 			if details.EnumType == "VCSType" {
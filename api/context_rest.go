@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/CircleCI-Public/circleci-cli/api/header"
 	"github.com/CircleCI-Public/circleci-cli/settings"
@@ -25,12 +26,20 @@ type ContextRestClient struct {
 }
 
 type listEnvironmentVariablesResponse struct {
-	Items         []EnvironmentVariable
+	Items         []restEnvironmentVariable
 	NextPageToken *string `json:"next_page_token"`
 	client        *ContextRestClient
 	params        *listEnvironmentVariablesParams
 }
 
+// restEnvironmentVariable is an EnvironmentVariable as the REST API returns
+// it, with snake_case fields.
+type restEnvironmentVariable struct {
+	Variable  string    `json:"variable"`
+	ContextID string    `json:"context_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type listContextsResponse struct {
 	Items         []Context
 	NextPageToken *string `json:"next_page_token"`
@@ -83,7 +92,7 @@ func (c *ContextRestClient) DeleteEnvironmentVariable(contextID, variable string
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return err
 		}
-		return errors.New(*dest.Message)
+		return header.WithRequestID(errors.New(*dest.Message), resp)
 	}
 	return nil
 }
@@ -111,7 +120,7 @@ func (c *ContextRestClient) CreateContext(vcs, org, name string) error {
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return err
 		}
-		return errors.New(*dest.Message)
+		return header.WithRequestID(errors.New(*dest.Message), resp)
 	}
 	var dest Context
 	if err := json.Unmarshal(bodyBytes, &dest); err != nil {
@@ -142,7 +151,7 @@ func (c *ContextRestClient) CreateEnvironmentVariable(contextID, variable, value
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return err
 		}
-		return errors.New(*dest.Message)
+		return header.WithRequestID(errors.New(*dest.Message), resp)
 	}
 	return nil
 }
@@ -170,7 +179,7 @@ func (c *ContextRestClient) DeleteContext(contextID string) error {
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return err
 		}
-		return errors.New(*dest.Message)
+		return header.WithRequestID(errors.New(*dest.Message), resp)
 	}
 	return nil
 }
@@ -229,7 +238,9 @@ func (c *ContextRestClient) listAllEnvironmentVariables(params *listEnvironmentV
 			return nil, err
 		}
 
-		envVars = append(envVars, resp.Items...)
+		for _, item := range resp.Items {
+			envVars = append(envVars, EnvironmentVariable(item))
+		}
 
 		if resp.NextPageToken == nil {
 			break
@@ -280,7 +291,7 @@ func (c *ContextRestClient) listEnvironmentVariables(params *listEnvironmentVari
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
-		return nil, errors.New(*dest.Message)
+		return nil, header.WithRequestID(errors.New(*dest.Message), resp)
 
 	}
 	dest := listEnvironmentVariablesResponse{
@@ -315,7 +326,7 @@ func (c *ContextRestClient) listContexts(params *listContextsParams) (*listConte
 			return nil, err
 
 		}
-		return nil, errors.New(*dest.Message)
+		return nil, header.WithRequestID(errors.New(*dest.Message), resp)
 
 	}
 
@@ -506,7 +517,7 @@ func (c *ContextRestClient) EnsureExists() error {
 		return err
 	}
 	if resp.StatusCode != 200 {
-		return errors.New("API v2 test request failed.")
+		return header.WithRequestID(errors.New("API v2 test request failed."), resp)
 	}
 
 	bodyBytes, err := ioutil.ReadAll(resp.Body)
@@ -0,0 +1,173 @@
+package debug
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Redacted replaces the values that must never be written to a debug log.
+const Redacted = "[REDACTED]"
+
+// redactedHeaders are the headers that carry credentials.
+var redactedHeaders = map[string]bool{
+	"Authorization": true,
+	"Circle-Token":  true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+// secretField matches the names of JSON fields whose values are redacted,
+// such as `token`, `circle_token` or `clientSecret`.
+var secretField = regexp.MustCompile(`(?i)(token|secret|password)`)
+
+// Transport is an http.RoundTripper that logs every request and response,
+// with their bodies, to Out. Credentials are redacted before they are written.
+type Transport struct {
+	Base http.RoundTripper
+	Out  io.Writer
+}
+
+// NewTransport wraps base, or http.DefaultTransport when it is nil, so that
+// the requests it makes are logged to out.
+func NewTransport(base http.RoundTripper, out io.Writer) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base, Out: out}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "> %s %s\n", req.Method, req.URL)
+	writeHeaders(&b, "> ", req.Header)
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if req.Header.Get("Content-Encoding") == "gzip" {
+			body = gunzip(body)
+		}
+		writeBody(&b, "> ", body)
+	}
+	t.flush(&b)
+
+	start := time.Now()
+	res, err := t.Base.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(&b, "< %s %s failed: %s\n", req.Method, req.URL, err)
+		t.flush(&b)
+		return res, err
+	}
+
+	fmt.Fprintf(&b, "< %s (%s)\n", res.Status, time.Since(start).Round(time.Millisecond))
+	writeHeaders(&b, "< ", res.Header)
+
+	if res.Body != nil {
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			fmt.Fprintf(&b, "< reading body failed: %s\n", err)
+		} else {
+			writeBody(&b, "< ", body)
+		}
+	}
+	t.flush(&b)
+
+	return res, nil
+}
+
+// gunzip returns the decompressed body, so that compressed requests are
+// logged readably, or body itself when it can't be decompressed.
+func gunzip(body []byte) []byte {
+	r, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return body
+	}
+	decompressed, err := ioutil.ReadAll(r)
+	if err != nil {
+		return body
+	}
+	return decompressed
+}
+
+func (t *Transport) flush(b *strings.Builder) {
+	_, _ = io.WriteString(t.Out, b.String())
+	b.Reset()
+}
+
+func writeHeaders(b *strings.Builder, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			value = Redacted
+		}
+		fmt.Fprintf(b, "%s%s: %s\n", prefix, name, value)
+	}
+}
+
+func writeBody(b *strings.Builder, prefix string, body []byte) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(RedactBody(body)), "\n"), "\n") {
+		fmt.Fprintf(b, "%s%s\n", prefix, line)
+	}
+}
+
+// RedactBody returns body with the values of any JSON fields named like a
+// token, secret or password replaced. Bodies that aren't JSON are returned
+// unchanged.
+func RedactBody(body []byte) []byte {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+
+	var redacted bytes.Buffer
+	encoder := json.NewEncoder(&redacted)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redactValue(value)); err != nil {
+		return body
+	}
+	return redacted.Bytes()
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if secretField.MatchString(key) && field != nil {
+				v[key] = Redacted
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
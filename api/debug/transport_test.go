@@ -0,0 +1,119 @@
+package debug
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=abc123")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": "1234", "token": "response-secret", "echo": ` + string(body) + `}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client := &http.Client{Transport: NewTransport(nil, &out)}
+
+	req, err := http.NewRequest("POST", server.URL+"/api/v2/runner/token", strings.NewReader(`{"nickname": "n", "client_secret": "request-secret"}`))
+	assert.NilError(t, err)
+	req.Header.Set("Authorization", "Bearer my-token")
+	req.Header.Set("Circle-Token", "my-token")
+
+	res, err := client.Do(req)
+	assert.NilError(t, err)
+	defer res.Body.Close()
+
+	t.Run("the bodies are still readable", func(t *testing.T) {
+		body, err := ioutil.ReadAll(res.Body)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(string(body), "response-secret"))
+		assert.Check(t, cmp.Contains(string(body), "request-secret"))
+	})
+
+	t.Run("the request and response are logged", func(t *testing.T) {
+		log := out.String()
+		assert.Check(t, cmp.Contains(log, "> POST "+server.URL+"/api/v2/runner/token\n"))
+		assert.Check(t, cmp.Contains(log, "< 201 Created"))
+		assert.Check(t, cmp.Contains(log, `"nickname":"n"`))
+		assert.Check(t, cmp.Contains(log, `"id":"1234"`))
+	})
+
+	t.Run("secrets are redacted", func(t *testing.T) {
+		log := out.String()
+		assert.Check(t, cmp.Contains(log, "> Authorization: [REDACTED]\n"))
+		assert.Check(t, cmp.Contains(log, "> Circle-Token: [REDACTED]\n"))
+		assert.Check(t, cmp.Contains(log, "< Set-Cookie: [REDACTED]\n"))
+		for _, secret := range []string{"my-token", "request-secret", "response-secret", "abc123"} {
+			assert.Check(t, !strings.Contains(log, secret), "%s was logged", secret)
+		}
+	})
+}
+
+func TestTransportCompressedRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client := &http.Client{Transport: NewTransport(nil, &out)}
+
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	_, err := gz.Write([]byte(`{"query": "query {}", "token": "request-secret"}`))
+	assert.NilError(t, err)
+	assert.NilError(t, gz.Close())
+
+	req, err := http.NewRequest("POST", server.URL, &body)
+	assert.NilError(t, err)
+	req.Header.Set("Content-Encoding", "gzip")
+
+	res, err := client.Do(req)
+	assert.NilError(t, err)
+	defer res.Body.Close()
+
+	log := out.String()
+	assert.Check(t, cmp.Contains(log, `"query":"query {}"`))
+	assert.Check(t, !strings.Contains(log, "request-secret"), "request-secret was logged")
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "nested fields",
+			body: `{"variables": {"orgId": "1", "apiToken": "x"}, "items": [{"secret": "y", "value": 12345678901234567890}]}`,
+			want: `{"items":[{"secret":"[REDACTED]","value":12345678901234567890}],"variables":{"apiToken":"[REDACTED]","orgId":"1"}}` + "\n",
+		},
+		{
+			name: "null values are kept",
+			body: `{"next_page_token": null}`,
+			want: `{"next_page_token":null}` + "\n",
+		},
+		{
+			name: "not json",
+			body: `token=abc`,
+			want: `token=abc`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, string(RedactBody([]byte(tt.body))), tt.want)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultCache is the Cache of new clients. The CLI sets it when the
+// cache_ttl setting is given, unless --no-cache is.
+var DefaultCache *Cache
+
+// A Cache keeps the responses to queries on disk, so that asking for the same
+// thing again within TTL doesn't need the API. Only requests that are marked
+// Cacheable use it, and mutations never do.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+}
+
+// key identifies a request to address on host made with token. The token
+// stands in for the organizations the user can see, so that private orbs
+// aren't shared between users, and the variables carry any namespace or
+// organization asked for.
+func (c *Cache) key(host, address, token string, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{host, address, token} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+// get returns the response saved for key, unless it's older than TTL.
+func (c *Cache) get(key string) ([]byte, bool) {
+	info, err := os.Stat(c.path(key))
+	if err != nil || time.Since(info.ModTime()) > c.TTL {
+		return nil, false
+	}
+	body, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+func (c *Cache) put(key string, body []byte) error {
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path(key), body, 0600)
+}
+
+// Clear removes every response in the cache.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
@@ -2,38 +2,96 @@ package graphql
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/CircleCI-Public/circleci-cli/api/header"
 	"github.com/CircleCI-Public/circleci-cli/version"
 	"github.com/pkg/errors"
 )
 
+// DefaultMaxRetries is the MaxRetries of new clients. The CLI sets it from
+// the --max-retries flag.
+var DefaultMaxRetries = 0
+
+// DefaultTimeout is the Timeout of new clients. The CLI sets it from the
+// --timeout flag.
+var DefaultTimeout time.Duration
+
+// DefaultCompression is the Compression of new clients. The CLI turns it off
+// with the --no-compression flag.
+var DefaultCompression = true
+
+// compressThreshold is the size, in bytes, above which request bodies are
+// gzipped. Smaller ones aren't worth compressing.
+var compressThreshold = 32 * 1024
+
+// The delay before the first retry, which doubles with each attempt up to
+// maxRetryDelay unless the server asks for a different one with Retry-After.
+var (
+	retryBaseDelay = time.Second
+	maxRetryDelay  = 30 * time.Second
+	after          = time.After
+)
+
 // A Client is an HTTP client for our GraphQL endpoint.
 type Client struct {
-	Debug      bool
-	Endpoint   string
-	Host       string
-	Token      string
+	Debug    bool
+	Endpoint string
+	Host     string
+	Token    string
+
+	// MaxRetries is how many times a request is retried after a transient
+	// failure. Queries are retried on network errors and 5xx responses, but
+	// mutations only when the connection to the server could not be made, so
+	// that they are never applied twice.
+	MaxRetries int
+
+	// Timeout bounds each call to Run, including any retries. Zero means
+	// there is no timeout.
+	Timeout time.Duration
+
+	// Compression gzips request bodies larger than compressThreshold and
+	// accepts gzipped responses, which net/http decompresses transparently.
+	// Without it, bodies are sent and asked for uncompressed, for proxies
+	// that mangle compressed ones.
+	Compression bool
+
+	// Cache, when set, keeps the responses to Cacheable queries.
+	Cache *Cache
+
 	httpClient *http.Client
 }
 
 // NewClient returns a reference to a Client.
 func NewClient(httpClient *http.Client, host, endpoint, token string, debug bool) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
 	return &Client{
-		httpClient: http.DefaultClient,
+		httpClient: httpClient,
 		Endpoint:   endpoint,
 		Host:       host,
 		Token:      token,
 		Debug:      debug,
+		MaxRetries: DefaultMaxRetries,
+		Timeout:    DefaultTimeout,
+
+		Compression: DefaultCompression,
+		Cache:       DefaultCache,
 	}
 }
 
@@ -69,6 +127,11 @@ type Request struct {
 	// Header represent any request headers that will be set
 	// when the request is made.
 	Header http.Header `json:"-"`
+
+	// Cacheable marks a query whose response may be kept in the client's
+	// Cache, as it only reads data that rarely changes, such as an orb
+	// version. It is ignored for mutations.
+	Cacheable bool `json:"-"`
 }
 
 // SetToken sets the Authorization header for the request with the given token.
@@ -138,6 +201,7 @@ type ResponseError struct {
 		Value         string
 		AllowedValues []string `json:"allowed-values"`
 		EnumType      string   `json:"enum-type"`
+		Type          string
 	}
 }
 
@@ -180,17 +244,26 @@ func getServerAddress(host, endpoint string) (string, error) {
 	return h.ResolveReference(e).String(), err
 }
 
-func prepareRequest(ctx context.Context, address string, request *Request) (*http.Request, error) {
+func prepareRequest(ctx context.Context, address string, request *Request, compress bool) (*http.Request, error) {
 	requestBody, err := request.Encode()
 	if err != nil {
 		return nil, err
 	}
+	compressed := compress && requestBody.Len() > compressThreshold
+	if compressed {
+		if requestBody, err = gzipBody(requestBody); err != nil {
+			return nil, err
+		}
+	}
 	r, err := http.NewRequest(http.MethodPost, address, &requestBody)
 	if err != nil {
 		return nil, err
 	}
 	r.Header.Set("Content-Type", "application/json; charset=utf-8")
 	r.Header.Set("Accept", "application/json; charset=utf-8")
+	if compressed {
+		r.Header.Set("Content-Encoding", "gzip")
+	}
 	for key, values := range request.Header {
 		for _, value := range values {
 			r.Header.Add(key, value)
@@ -201,12 +274,144 @@ func prepareRequest(ctx context.Context, address string, request *Request) (*htt
 	return r, nil
 }
 
+func gzipBody(body bytes.Buffer) (bytes.Buffer, error) {
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	if _, err := body.WriteTo(w); err != nil {
+		return compressed, err
+	}
+	err := w.Close()
+	return compressed, err
+}
+
+// do sends request to address, retrying transient failures as allowed by
+// cl.MaxRetries.
+func (cl *Client) do(ctx context.Context, l *log.Logger, address string, request *Request) (*http.Response, error) {
+	mutation := request.isMutation()
+	compress := cl.Compression
+
+	for attempt := 0; ; attempt++ {
+		req, err := prepareRequest(ctx, address, request, compress)
+		if err != nil {
+			return nil, err
+		}
+		if !cl.Compression {
+			// Otherwise net/http asks for gzip itself
+			req.Header.Set("Accept-Encoding", "identity")
+		}
+
+		res, err := cl.httpClient.Do(req)
+		if err == nil && res.StatusCode == http.StatusUnsupportedMediaType && req.Header.Get("Content-Encoding") == "gzip" {
+			// The server, or a proxy in front of it, doesn't accept compressed
+			// requests, so send this one again uncompressed
+			if cl.Debug {
+				l.Printf("<< compressed request rejected, sending it uncompressed")
+			}
+			_, _ = io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+			compress = false
+			attempt--
+			continue
+		}
+		retryable := false
+		var delay time.Duration
+		switch {
+		case err != nil:
+			retryable = !mutation || isConnectionFailure(err)
+		case res.StatusCode >= 500 && !mutation:
+			retryable = true
+			delay = retryAfter(res)
+		}
+
+		if !retryable || attempt >= cl.MaxRetries || ctx.Err() != nil {
+			return res, err
+		}
+
+		if delay == 0 {
+			delay = retryBaseDelay << uint(attempt)
+			if delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+		}
+
+		if err != nil {
+			if cl.Debug {
+				l.Printf("<< request failed: %s, retrying in %s", err, delay)
+			}
+		} else {
+			if cl.Debug {
+				l.Printf("<< result status: %s, retrying in %s", res.Status, delay)
+			}
+			_, _ = io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		select {
+		case <-after(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// isMutation reports whether the request is a GraphQL mutation rather than a
+// query, which is not safe to send again once the server has received it.
+func (request *Request) isMutation() bool {
+	return strings.HasPrefix(strings.TrimSpace(request.Query), "mutation")
+}
+
+// isConnectionFailure reports whether err happened before the request could
+// be sent, because the connection to the server could not be made.
+func isConnectionFailure(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
+
+// retryAfter returns the delay asked for by the Retry-After header of res,
+// or zero when there isn't one.
+func retryAfter(res *http.Response) time.Duration {
+	value := res.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = time.Until(date)
+	}
+	if delay < 0 {
+		return 0
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// timeoutError replaces err with a clearer one when it was caused by the
+// request running out of time.
+func (cl *Client) timeoutError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s waiting for the CircleCI API", cl.Timeout)
+	}
+	return err
+}
+
 // Run sends an HTTP request to the GraphQL server and deserializes the response or returns an error.
 // TODO(zzak): This function is fairly complex, we should refactor it
 // nolint: gocyclo
 func (cl *Client) Run(request *Request, resp interface{}) error {
 	l := log.New(os.Stderr, "", 0)
 	ctx := context.Background()
+	if cl.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cl.Timeout)
+		defer cancel()
+	}
 
 	select {
 	case <-ctx.Done():
@@ -219,20 +424,30 @@ func (cl *Client) Run(request *Request, resp interface{}) error {
 		return err
 	}
 
-	req, err := prepareRequest(ctx, address, request)
-	if err != nil {
-		return err
-	}
-
+	// The variables and the response body are logged, with their secrets
+	// redacted, by the debug transport of the HTTP client
 	if cl.Debug {
-		l.Printf(">> variables: %v", request.Variables)
 		l.Printf(">> query: %s", request.Query)
 	}
 
-	res, err := cl.httpClient.Do(req)
+	var cacheKey string
+	if cl.Cache != nil && request.Cacheable && !request.isMutation() {
+		body, err := request.Encode()
+		if err != nil {
+			return err
+		}
+		cacheKey = cl.Cache.key(cl.Host, address, cl.Token, body.Bytes())
+		if cached, ok := cl.Cache.get(cacheKey); ok {
+			if cl.Debug {
+				l.Printf("<< cached response")
+			}
+			return decodeResponse(cached, resp)
+		}
+	}
 
+	res, err := cl.do(ctx, l, address, request)
 	if err != nil {
-		return err
+		return cl.timeoutError(ctx, err)
 	}
 	defer func() {
 		responseBodyCloseErr := res.Body.Close()
@@ -242,35 +457,40 @@ func (cl *Client) Run(request *Request, resp interface{}) error {
 	}()
 
 	if cl.Debug {
-		l.Printf("<< request id: %s", res.Header.Get("X-Request-Id"))
+		l.Printf("<< request id: %s", header.RequestID(res.Header))
 		l.Printf("<< result status: %s", res.Status)
 	}
 
 	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("failure calling GraphQL API: %s", res.Status)
+		return header.WithRequestID(fmt.Errorf("failure calling GraphQL API: %s", res.Status), res)
 	}
 
-	// Request.Body is an io.ReadCloser it can only be read once
-	if cl.Debug {
-		var bodyBytes []byte
-		if res.Body != nil {
-			bodyBytes, err = ioutil.ReadAll(res.Body)
-			if err != nil {
-				return errors.Wrap(err, "reading response")
-			}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return cl.timeoutError(ctx, errors.Wrap(err, "decoding response"))
+	}
 
-			l.Printf("<< %s", string(bodyBytes))
+	if err := decodeResponse(body, resp); err != nil {
+		return header.WithRequestID(err, res)
+	}
 
-			// Restore the io.ReadCloser to its original state
-			res.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+	if cacheKey != "" {
+		if err := cl.Cache.put(cacheKey, body); err != nil && cl.Debug {
+			l.Printf("<< unable to cache the response: %s", err)
 		}
 	}
 
+	return nil
+}
+
+// decodeResponse deserializes the data of body into resp, or returns the
+// errors that the server responded with.
+func decodeResponse(body []byte, resp interface{}) error {
 	wrappedResponse := &Response{
 		Data: resp,
 	}
 
-	if err := json.NewDecoder(res.Body).Decode(&wrappedResponse); err != nil {
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&wrappedResponse); err != nil {
 		return errors.Wrap(err, "decoding response")
 	}
 
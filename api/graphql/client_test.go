@@ -1,12 +1,17 @@
 package graphql
 
 import (
+	"compress/gzip"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 func TestServerAddress(t *testing.T) {
@@ -117,7 +122,7 @@ func TestQueryJSON(t *testing.T) {
 
 	// check variables
 	if req == nil {
-		t.Errorf("expected %s", req)
+		t.Errorf("expected %v", req)
 	}
 
 	if req.Variables["username"] != "matryer" {
@@ -317,3 +322,382 @@ func TestStatusCode413(t *testing.T) {
 		t.Errorf("expected %d", calls)
 	}
 }
+
+func TestRetries(t *testing.T) {
+	var delays []time.Duration
+	after = func(d time.Duration) <-chan time.Time {
+		delays = append(delays, d)
+		c := make(chan time.Time, 1)
+		c <- time.Now()
+		return c
+	}
+	defer func() { after = time.After }()
+
+	newServer := func(calls *int, failures int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			if *calls <= failures {
+				w.Header().Set("Retry-After", "2")
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_, err := io.WriteString(w, `{"data":{"value":"some data"}}`)
+			if err != nil {
+				t.Errorf(err.Error())
+			}
+		}))
+	}
+
+	t.Run("queries are retried on server errors", func(t *testing.T) {
+		delays = nil
+		var calls int
+		srv := newServer(&calls, 2)
+		defer srv.Close()
+
+		client := NewClient(http.DefaultClient, srv.URL, "/", "token", false)
+		client.MaxRetries = 3
+
+		var resp struct {
+			Value string
+		}
+		err := client.Run(NewRequest("query {}"), &resp)
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+		if len(delays) != 2 || delays[0] != 2*time.Second {
+			t.Errorf("expected to wait for Retry-After, got %v", delays)
+		}
+		if resp.Value != "some data" {
+			t.Errorf("expected %+v", resp)
+		}
+	})
+
+	t.Run("queries give up after MaxRetries", func(t *testing.T) {
+		var calls int
+		srv := newServer(&calls, 10)
+		defer srv.Close()
+
+		client := NewClient(http.DefaultClient, srv.URL, "/", "token", false)
+		client.MaxRetries = 2
+
+		var resp interface{}
+		err := client.Run(NewRequest("query {}"), &resp)
+		if err == nil || err.Error() != "failure calling GraphQL API: 502 Bad Gateway" {
+			t.Errorf("expected a bad gateway error, got %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("mutations are not retried once sent", func(t *testing.T) {
+		var calls int
+		srv := newServer(&calls, 1)
+		defer srv.Close()
+
+		client := NewClient(http.DefaultClient, srv.URL, "/", "token", false)
+		client.MaxRetries = 3
+
+		var resp interface{}
+		err := client.Run(NewRequest("mutation { publish }"), &resp)
+		if err == nil {
+			t.Error("expected error")
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("mutations are retried when the connection fails", func(t *testing.T) {
+		delays = nil
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		client := NewClient(http.DefaultClient, srv.URL, "/", "token", false)
+		client.MaxRetries = 2
+
+		var resp interface{}
+		err := client.Run(NewRequest("mutation { publish }"), &resp)
+		if err == nil {
+			t.Error("expected error")
+		}
+		if len(delays) != 2 || delays[0] != retryBaseDelay || delays[1] != 2*retryBaseDelay {
+			t.Errorf("expected exponential backoff, got %v", delays)
+		}
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		time.Sleep(200 * time.Millisecond)
+		_, err := io.WriteString(w, `{"data":{"value":"some data"}}`)
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(http.DefaultClient, srv.URL, "/", "token", false)
+	client.Timeout = 50 * time.Millisecond
+	client.MaxRetries = 3
+
+	var resp interface{}
+	err := client.Run(NewRequest("query {}"), &resp)
+	if err == nil || err.Error() != "timed out after 50ms waiting for the CircleCI API" {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected no retries after timing out, got %d calls", calls)
+	}
+}
+
+func TestTimeoutDuringRetryDelay(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	client := NewClient(http.DefaultClient, srv.URL, "/", "token", false)
+	client.Timeout = 50 * time.Millisecond
+	client.MaxRetries = 3
+
+	start := time.Now()
+	var resp interface{}
+	err := client.Run(NewRequest("query {}"), &resp)
+	if err == nil || err.Error() != "timed out after 50ms waiting for the CircleCI API" {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= retryBaseDelay {
+		t.Errorf("expected to stop waiting to retry when the timeout expired, took %s", elapsed)
+	}
+	if calls != 1 {
+		t.Errorf("expected no retries after timing out, got %d calls", calls)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	largeQuery := "query { " + strings.Repeat("a", compressThreshold) + " }"
+
+	newServer := func(encodings *[]string, acceptsGzip bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := r.Header.Get("Content-Encoding")
+			*encodings = append(*encodings, encoding)
+
+			body := r.Body
+			if encoding == "gzip" {
+				if !acceptsGzip {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+				gz, err := gzip.NewReader(r.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = gz
+			}
+			b, err := ioutil.ReadAll(body)
+			if err != nil {
+				t.Errorf(err.Error())
+			}
+			if !strings.Contains(string(b), `"query":"query {`) {
+				t.Errorf("unexpected body %s", string(b))
+			}
+
+			if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				w.Header().Set("Content-Encoding", "gzip")
+				gz := gzip.NewWriter(w)
+				defer gz.Close()
+				_, err = io.WriteString(gz, `{"data":{"value":"compressed"}}`)
+			} else {
+				_, err = io.WriteString(w, `{"data":{"value":"uncompressed"}}`)
+			}
+			if err != nil {
+				t.Errorf(err.Error())
+			}
+		}))
+	}
+
+	run := func(client *Client, query string) string {
+		var resp struct {
+			Value string
+		}
+		if err := client.Run(NewRequest(query), &resp); err != nil {
+			t.Errorf(err.Error())
+		}
+		return resp.Value
+	}
+
+	t.Run("large requests are compressed", func(t *testing.T) {
+		var encodings []string
+		srv := newServer(&encodings, true)
+		defer srv.Close()
+
+		client := NewClient(http.DefaultClient, srv.URL, "/", "token", false)
+		if value := run(client, largeQuery); value != "compressed" {
+			t.Errorf("expected a decompressed response, got %s", value)
+		}
+		if value := run(client, "query {}"); value != "compressed" {
+			t.Errorf("expected a decompressed response, got %s", value)
+		}
+		if len(encodings) != 2 || encodings[0] != "gzip" || encodings[1] != "" {
+			t.Errorf("expected only the large request to be compressed, got %v", encodings)
+		}
+	})
+
+	t.Run("rejected compressed requests are sent again uncompressed", func(t *testing.T) {
+		var encodings []string
+		srv := newServer(&encodings, false)
+		defer srv.Close()
+
+		client := NewClient(http.DefaultClient, srv.URL, "/", "token", false)
+		client.MaxRetries = 0
+		if value := run(client, largeQuery); value != "compressed" {
+			t.Errorf("expected a decompressed response, got %s", value)
+		}
+		if len(encodings) != 2 || encodings[0] != "gzip" || encodings[1] != "" {
+			t.Errorf("expected the request to be sent again uncompressed, got %v", encodings)
+		}
+	})
+
+	t.Run("compression can be turned off", func(t *testing.T) {
+		var encodings []string
+		srv := newServer(&encodings, true)
+		defer srv.Close()
+
+		client := NewClient(http.DefaultClient, srv.URL, "/", "token", false)
+		client.Compression = false
+		if value := run(client, largeQuery); value != "uncompressed" {
+			t.Errorf("expected an uncompressed response, got %s", value)
+		}
+		if len(encodings) != 1 || encodings[0] != "" {
+			t.Errorf("expected an uncompressed request, got %v", encodings)
+		}
+	})
+}
+
+func TestCache(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, err := io.WriteString(w, `{"data":{"value":"some data"}}`)
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+	}))
+	defer srv.Close()
+
+	run := func(client *Client, query string, cacheable bool) string {
+		var resp struct {
+			Value string
+		}
+		request := NewRequest(query)
+		request.Var("name", "circleci/node")
+		request.Cacheable = cacheable
+		if err := client.Run(request, &resp); err != nil {
+			t.Errorf(err.Error())
+		}
+		return resp.Value
+	}
+
+	newClient := func(cache *Cache, token string) *Client {
+		client := NewClient(http.DefaultClient, srv.URL, "/", token, false)
+		client.Cache = cache
+		return client
+	}
+
+	t.Run("cacheable queries are answered from the cache", func(t *testing.T) {
+		calls = 0
+		client := newClient(&Cache{Dir: t.TempDir(), TTL: time.Hour}, "token")
+		for i := 0; i < 2; i++ {
+			if value := run(client, "query {}", true); value != "some data" {
+				t.Errorf("expected the response to be decoded, got %s", value)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("expected the second query to be cached, got %d calls", calls)
+		}
+	})
+
+	t.Run("other requests are never cached", func(t *testing.T) {
+		calls = 0
+		client := newClient(&Cache{Dir: t.TempDir(), TTL: time.Hour}, "token")
+		run(client, "query {}", false)
+		run(client, "query {}", false)
+		run(client, "mutation {}", true)
+		run(client, "mutation {}", true)
+		if calls != 4 {
+			t.Errorf("expected every request to be sent, got %d calls", calls)
+		}
+	})
+
+	t.Run("responses aren't shared between tokens", func(t *testing.T) {
+		calls = 0
+		cache := &Cache{Dir: t.TempDir(), TTL: time.Hour}
+		run(newClient(cache, "token"), "query {}", true)
+		run(newClient(cache, "other-token"), "query {}", true)
+		if calls != 2 {
+			t.Errorf("expected each token to have its own responses, got %d calls", calls)
+		}
+	})
+
+	t.Run("responses expire after the TTL and can be cleared", func(t *testing.T) {
+		calls = 0
+		cache := &Cache{Dir: t.TempDir(), TTL: time.Hour}
+		client := newClient(cache, "token")
+		run(client, "query {}", true)
+
+		cache.TTL = 0
+		run(client, "query {}", true)
+		if calls != 2 {
+			t.Errorf("expected an expired response to be asked for again, got %d calls", calls)
+		}
+
+		cache.TTL = time.Hour
+		if err := cache.Clear(); err != nil {
+			t.Fatal(err)
+		}
+		run(client, "query {}", true)
+		if calls != 3 {
+			t.Errorf("expected a cleared response to be asked for again, got %d calls", calls)
+		}
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "d5e0ab1c-7a41-4a4e-9c5e-2f1e0c6a9b11")
+		if strings.Contains(r.URL.Path, "broken") {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, err := io.WriteString(w, `{"errors": [{"message": "Something went wrong"}]}`)
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+	}))
+	defer srv.Close()
+
+	var resp map[string]interface{}
+
+	client := NewClient(http.DefaultClient, srv.URL, "/", "token", false)
+	err := client.Run(NewRequest("query {}"), &resp)
+	if err == nil || err.Error() != "Something went wrong (request ID: d5e0ab1c-7a41-4a4e-9c5e-2f1e0c6a9b11)" {
+		t.Errorf("expected the request ID in the error, got %v", err)
+	}
+	if _, ok := errors.Cause(err).(ResponseErrorsCollection); !ok {
+		t.Errorf("expected the errors of the response to be the cause, got %T", errors.Cause(err))
+	}
+
+	client = NewClient(http.DefaultClient, srv.URL, "/broken", "token", false)
+	err = client.Run(NewRequest("query {}"), &resp)
+	if err == nil || err.Error() != "failure calling GraphQL API: 502 Bad Gateway (request ID: d5e0ab1c-7a41-4a4e-9c5e-2f1e0c6a9b11)" {
+		t.Errorf("expected the request ID in the error, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package header
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// requestIDHeaders are the headers that the API puts the ID of a request in,
+// in order of preference. Support can find a request in their logs by it.
+var requestIDHeaders = []string{"X-Request-Id", "X-Trace-Id"}
+
+// RequestID returns the ID that the server gave the request of a response,
+// or "" when it didn't give one.
+func RequestID(h http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := h.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// RequestIDError is an error from a failed API request, along with the ID
+// that the server gave the request.
+type RequestIDError struct {
+	Err       error
+	RequestID string
+}
+
+func (e *RequestIDError) Error() string {
+	return fmt.Sprintf("%s (request ID: %s)", e.Err, e.RequestID)
+}
+
+// Cause returns the error without the request ID, for errors.Cause.
+func (e *RequestIDError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the error without the request ID, for errors.As.
+func (e *RequestIDError) Unwrap() error {
+	return e.Err
+}
+
+// WithRequestID adds the request ID of res to err, so that it can be given
+// to support. err is returned unchanged when it's nil or res has no ID.
+func WithRequestID(err error, res *http.Response) error {
+	if err == nil || res == nil {
+		return err
+	}
+	id := RequestID(res.Header)
+	if id == "" {
+		return err
+	}
+	return &RequestIDError{Err: err, RequestID: id}
+}
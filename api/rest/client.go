@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/CircleCI-Public/circleci-cli/api/header"
+	"github.com/CircleCI-Public/circleci-cli/settings"
 	"github.com/CircleCI-Public/circleci-cli/version"
 )
 
@@ -37,6 +38,17 @@ func New(host, endpoint, circleToken string) *Client {
 	}
 }
 
+// NewFromConfig returns a client for the REST API of the host in config,
+// which sends its requests through the HTTP client of config, so that they
+// share its TLS settings and --debug logging.
+func NewFromConfig(config *settings.Config) *Client {
+	c := New(config.Host, config.RestEndpoint, config.Token)
+	if config.HTTPClient != nil {
+		c.client.Transport = config.HTTPClient.Transport
+	}
+	return c
+}
+
 func (c *Client) NewRequest(method string, u *url.URL, payload interface{}) (req *http.Request, err error) {
 	var r io.Reader
 	if payload != nil {
@@ -80,9 +92,9 @@ func (c *Client) DoRequest(req *http.Request, resp interface{}) (statusCode int,
 		}{}
 		err = json.NewDecoder(httpResp.Body).Decode(&httpError)
 		if err != nil {
-			return httpResp.StatusCode, err
+			return httpResp.StatusCode, header.WithRequestID(err, httpResp)
 		}
-		return httpResp.StatusCode, &HTTPError{Code: httpResp.StatusCode, Message: httpError.Message}
+		return httpResp.StatusCode, header.WithRequestID(&HTTPError{Code: httpResp.StatusCode, Message: httpError.Message}, httpResp)
 	}
 
 	if resp != nil {
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -84,6 +85,28 @@ func TestClient_DoRequest(t *testing.T) {
 		})
 	})
 
+	t.Run("error status with a request ID", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Request-Id", "d5e0ab1c-7a41-4a4e-9c5e-2f1e0c6a9b11")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, `{"message": "Permission denied"}`)
+		}))
+		defer server.Close()
+
+		c := New(server.URL, "api/v2", "fake-token")
+		r, err := c.NewRequest("GET", &url.URL{Path: "my/error/endpoint"}, nil)
+		assert.NilError(t, err)
+
+		statusCode, err := c.DoRequest(r, nil)
+		assert.Error(t, err, "Permission denied (request ID: d5e0ab1c-7a41-4a4e-9c5e-2f1e0c6a9b11)")
+		assert.Equal(t, statusCode, http.StatusForbidden)
+
+		var httpErr *HTTPError
+		assert.Assert(t, errors.As(err, &httpErr))
+		assert.Equal(t, httpErr.Code, http.StatusForbidden)
+	})
+
 	t.Run("GET with resp only", func(t *testing.T) {
 		fix := &fixture{}
 		c, cleanup := fix.Run(http.StatusCreated, `{"a": "abc", "b": true}`)
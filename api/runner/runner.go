@@ -70,16 +70,28 @@ func (r *Runner) GetNamespaceByResourceClass(resourceClass string) (ns string, e
 func (r *Runner) GetResourceClassesByNamespace(namespace string) ([]ResourceClass, error) {
 	query := url.Values{}
 	query.Set("namespace", namespace)
-	req, err := r.rc.NewRequest("GET", &url.URL{Path: "runner/resource", RawQuery: query.Encode()}, nil)
-	if err != nil {
-		return nil, err
-	}
 
-	resp := struct {
-		Items []ResourceClass `json:"items"`
-	}{}
-	_, err = r.rc.DoRequest(req, &resp)
-	return resp.Items, err
+	rcs := []ResourceClass{}
+	for {
+		req, err := r.rc.NewRequest("GET", &url.URL{Path: "runner/resource", RawQuery: query.Encode()}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := struct {
+			Items         []ResourceClass `json:"items"`
+			NextPageToken string          `json:"next_page_token"`
+		}{}
+		if _, err = r.rc.DoRequest(req, &resp); err != nil {
+			return nil, err
+		}
+		rcs = append(rcs, resp.Items...)
+
+		if resp.NextPageToken == "" {
+			return rcs, nil
+		}
+		query.Set("page-token", resp.NextPageToken)
+	}
 }
 
 func (r *Runner) DeleteResourceClass(id string) error {
@@ -144,6 +144,41 @@ func TestRunner_GetResourceClassesByNamespace(t *testing.T) {
 	})
 }
 
+func TestRunner_GetResourceClassesByNamespace_Pages(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page-token") == "" {
+			_, _ = io.WriteString(w, `{"items": [{"id": "1", "resource_class": "the-namespace/the-resource-class-1"}], "next_page_token": "the-next-page"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"items": [{"id": "2", "resource_class": "the-namespace/the-resource-class-2"}]}`)
+	}))
+	defer server.Close()
+	runner := New(rest.New(server.URL, "api/v2", "fake-token"))
+
+	rcs, err := runner.GetResourceClassesByNamespace("the-namespace")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(rcs, []ResourceClass{
+		{ID: "1", ResourceClass: "the-namespace/the-resource-class-1"},
+		{ID: "2", ResourceClass: "the-namespace/the-resource-class-2"},
+	}))
+	assert.Check(t, cmp.DeepEqual(queries, []string{
+		"namespace=the-namespace",
+		"namespace=the-namespace&page-token=the-next-page",
+	}))
+}
+
+func TestRunner_GetResourceClassesByNamespace_Forbidden(t *testing.T) {
+	fix := fixture{}
+	runner, cleanup := fix.Run(http.StatusForbidden, `{"message": "Permission denied"}`)
+	defer cleanup()
+
+	_, err := runner.GetResourceClassesByNamespace("the-namespace")
+	assert.Check(t, cmp.DeepEqual(err, &rest.HTTPError{Code: http.StatusForbidden, Message: "Permission denied"}))
+}
+
 func TestRunner_DeleteResourceClass(t *testing.T) {
 	fix := fixture{}
 	runner, cleanup := fix.Run(http.StatusOK, ``)
@@ -93,6 +93,7 @@ type MockRequestResponse struct {
 // When authToken is an empty string no token validation is performed.
 func (tempSettings *TempSettings) AppendPostHandler(authToken string, combineHandlers ...MockRequestResponse) {
 	for _, handler := range combineHandlers {
+		handler := handler // the handlers below close over it
 		responseBody := `{ "data": ` + handler.Response + `}`
 		if handler.ErrorResponse != "" {
 			responseBody = fmt.Sprintf("{ \"data\": %s, \"errors\": %s}", handler.Response, handler.ErrorResponse)
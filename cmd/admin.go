@@ -119,8 +119,9 @@ Example:
 
 			// PersistentPreRunE overwrites the inherited persistent hook from rootCmd
 			// So we explicitly call it here to retain that behavior.
-			// As of writing this comment, that is only for daily update checks.
-			return rootCmdPreRun(rootOptions)
+			// As of writing this comment, that is for checking --host and daily
+			// update checks.
+			return rootCmdPreRun(cmd, rootOptions)
 		},
 		Hidden: true,
 	}
@@ -16,6 +16,9 @@ func newLocalExecuteCommand(config *settings.Config) *cobra.Command {
 	}
 
 	local.AddFlagsForDocumentation(buildCommand.Flags())
+	buildCommand.Flags().String("env-file", "", "Read environment variables for the job from a file of KEY=VALUE lines. Variables set with -e take precedence.")
+	buildCommand.Flags().String("pull", local.PullMissing, "When to pull the job's images before running it: always, to pull them even when they're cached, missing, to let the build agent pull those that aren't cached, or never, to fail if any aren't cached.")
+	buildCommand.Flags().String("workdir", "", "The working directory of the job in its container, in place of the working_directory of the job in the config, which is reported when they differ.")
 	buildCommand.Flags().StringP("org-slug", "o", "", "organization slug (for example: github/example-org), used when a config depends on private orbs belonging to that org")
 
 	return buildCommand
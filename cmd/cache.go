@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/CircleCI-Public/circleci-cli/api/graphql"
+	"github.com/CircleCI-Public/circleci-cli/settings"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newCacheCommand(config *settings.Config) *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of API responses",
+		Long: `Manage the cache of API responses.
+
+Orb versions and their metadata, as looked up by commands such as
+` + "`orb info`" + ` and ` + "`orb source`" + `, are cached on disk when the cache_ttl setting is
+given, for example with ` + "`circleci settings set cache_ttl 1h`" + `. Use --no-cache to
+skip the cache for one command.`,
+	}
+
+	clearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove every cached API response",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return clearCache()
+		},
+		Args: cobra.NoArgs,
+	}
+
+	cacheCmd.AddCommand(clearCmd)
+
+	return cacheCmd
+}
+
+func clearCache() error {
+	cache := &graphql.Cache{Dir: settings.CachePath()}
+	if err := cache.Clear(); err != nil {
+		return errors.Wrapf(err, "Unable to clear the cache at %s", cache.Dir)
+	}
+	infof("Cleared the cache at %s.\n", cache.Dir)
+	return nil
+}
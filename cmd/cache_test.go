@@ -0,0 +1,107 @@
+package cmd_test
+
+import (
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/CircleCI-Public/circleci-cli/api/graphql"
+	"github.com/CircleCI-Public/circleci-cli/clitest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		tempSettings *clitest.TempSettings
+		cacheDir     string
+		request      string
+	)
+
+	BeforeEach(func() {
+		tempSettings = clitest.WithTempSettings()
+		tempSettings.Config.Write([]byte("cache_ttl: 1h\n"))
+		cacheDir = filepath.Join(tempSettings.Home, ".circleci", "cache")
+
+		query := `query($orbVersionRef: String!) {
+			    orbVersion(orbVersionRef: $orbVersionRef) {
+			        id
+                                version
+                                orb { id }
+                                source
+			    }
+		      }`
+		gqlRequest := graphql.NewRequest(query)
+		gqlRequest.Variables["orbVersionRef"] = "my/orb@1.2.3"
+		encoded, err := gqlRequest.Encode()
+		Expect(err).ShouldNot(HaveOccurred())
+		request = encoded.String()
+	})
+
+	AfterEach(func() {
+		tempSettings.Close()
+	})
+
+	appendSourceHandler := func() {
+		tempSettings.AppendPostHandler("", clitest.MockRequestResponse{
+			Status:  http.StatusOK,
+			Request: request,
+			Response: `{"orbVersion": {
+				"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87",
+				"version": "1.2.3",
+				"orb": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87"},
+				"source": "cached orb"
+			}}`})
+	}
+
+	orbSource := func(args ...string) *exec.Cmd {
+		return commandWithHome(pathCLI, tempSettings.Home, append([]string{
+			"orb", "source", "my/orb@1.2.3",
+			"--skip-update-check",
+			"--host", tempSettings.TestServer.URL(),
+		}, args...)...)
+	}
+
+	run := func(command *exec.Cmd) *gexec.Session {
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(0))
+		return session
+	}
+
+	It("answers orb lookups from the cache when cache_ttl is set", func() {
+		appendSourceHandler()
+
+		for i := 0; i < 2; i++ {
+			session := run(orbSource())
+			Expect(string(session.Out.Contents())).To(Equal("cached orb\n"))
+		}
+		Expect(tempSettings.TestServer.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("asks the API with --no-cache", func() {
+		appendSourceHandler()
+		appendSourceHandler()
+
+		run(orbSource())
+		session := run(orbSource("--no-cache"))
+		Expect(string(session.Out.Contents())).To(Equal("cached orb\n"))
+		Expect(tempSettings.TestServer.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("removes the cached responses with cache clear", func() {
+		appendSourceHandler()
+		run(orbSource())
+		Expect(cacheDir).To(BeADirectory())
+
+		session := run(commandWithHome(pathCLI, tempSettings.Home,
+			"cache", "clear",
+			"--skip-update-check",
+		))
+		Expect(string(session.Out.Contents())).To(Equal("Cleared the cache at " + cacheDir + ".\n"))
+		_, err := os.Stat(cacheDir)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
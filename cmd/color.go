@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+)
+
+// noColor is set with --no-color, to print output without ANSI styling.
+var noColor bool
+
+// quiet is set with --quiet, to print only errors and the data that was
+// asked for.
+var quiet bool
+
+// The ANSI styles used in output.
+const (
+	styleBoldBlue = "1;34"
+)
+
+// stdoutIsTerminal reports whether stdout is a terminal, rather than a file
+// or a pipe. It is a variable so that tests can pretend that it is.
+var stdoutIsTerminal = func() bool {
+	stat, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) != 0
+}
+
+// colorEnabled reports whether output can be styled. It can't with
+// --no-color, when NO_COLOR is set (https://no-color.org), or when stdout
+// isn't a terminal.
+func colorEnabled() bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return stdoutIsTerminal()
+}
+
+// styled returns text in the given ANSI style, or unchanged when color isn't
+// enabled. All styled output should go through it, so that --no-color
+// applies everywhere.
+func styled(style, text string) string {
+	if !colorEnabled() {
+		return text
+	}
+	return "\033[" + style + "m" + text + "\033[0m"
+}
+
+// infof prints informational output, such as a success message, unless
+// --quiet was given. Output that is the result of a command, such as --json,
+// should be printed directly instead, so that --output-file writes it to a
+// file.
+func infof(format string, a ...interface{}) {
+	if quiet {
+		return
+	}
+	fmt.Fprintf(statusOutput(), format, a...)
+}
+
+// infoln is infof for a single line.
+func infoln(a ...interface{}) {
+	if quiet {
+		return
+	}
+	fmt.Fprintln(statusOutput(), a...)
+}
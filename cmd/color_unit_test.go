@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Styled output", func() {
+	var isTerminal func() bool
+
+	BeforeEach(func() {
+		isTerminal = stdoutIsTerminal
+		stdoutIsTerminal = func() bool { return true }
+		Expect(os.Unsetenv("NO_COLOR")).To(Succeed())
+	})
+
+	AfterEach(func() {
+		stdoutIsTerminal = isTerminal
+		noColor = false
+		Expect(os.Unsetenv("NO_COLOR")).To(Succeed())
+	})
+
+	It("is styled on a terminal", func() {
+		Expect(styled(styleBoldBlue, "git push")).To(Equal("\033[1;34mgit push\033[0m"))
+	})
+
+	It("isn't styled with --no-color", func() {
+		noColor = true
+		Expect(styled(styleBoldBlue, "git push")).To(Equal("git push"))
+	})
+
+	It("isn't styled when NO_COLOR is set", func() {
+		Expect(os.Setenv("NO_COLOR", "1")).To(Succeed())
+		Expect(styled(styleBoldBlue, "git push")).To(Equal("git push"))
+	})
+
+	It("isn't styled when stdout isn't a terminal", func() {
+		stdoutIsTerminal = func() bool { return false }
+		Expect(styled(styleBoldBlue, "git push")).To(Equal("git push"))
+	})
+})
@@ -2,14 +2,26 @@ package cmd
 
 import (
 	"os"
+	"strings"
 
+	"github.com/CircleCI-Public/circleci-cli/api"
+	"github.com/CircleCI-Public/circleci-cli/api/graphql"
+	"github.com/CircleCI-Public/circleci-cli/git"
+	"github.com/CircleCI-Public/circleci-cli/settings"
 	"github.com/spf13/cobra"
 )
 
 func newCompletionCommand() *cobra.Command {
+	var noDescriptions bool
+
 	completionCmd := &cobra.Command{
 		Use:   "completion",
 		Short: "Generate shell completion scripts",
+		Long: `Generate shell completion scripts.
+
+The completions of zsh, fish and powershell describe each command and flag,
+which --no-descriptions leaves out for leaner scripts. Those of bash never
+have descriptions.`,
 		Run: func(cmd *cobra.Command, _ []string) {
 			err := cmd.Help()
 			if err != nil {
@@ -33,7 +45,49 @@ func newCompletionCommand() *cobra.Command {
 		Use:   "zsh",
 		Short: "Generate zsh completion scripts",
 		Run: func(cmd *cobra.Command, _ []string) {
-			err := cmd.Root().GenZshCompletion(os.Stdout)
+			generate := cmd.Root().GenZshCompletion
+			if noDescriptions {
+				generate = cmd.Root().GenZshCompletionNoDesc
+			}
+			err := generate(os.Stdout)
+			if err != nil {
+				panic(err)
+			}
+		},
+	}
+
+	fishCommand := &cobra.Command{
+		Use:   "fish",
+		Short: "Generate fish completion scripts",
+		Long: `Generate fish completion scripts.
+
+To load completions for every new session, run:
+
+  circleci completion fish > ~/.config/fish/completions/circleci.fish`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			err := cmd.Root().GenFishCompletion(os.Stdout, !noDescriptions)
+			if err != nil {
+				panic(err)
+			}
+		},
+	}
+
+	powershellCommand := &cobra.Command{
+		Use:   "powershell",
+		Short: "Generate powershell completion scripts",
+		Long: `Generate powershell completion scripts.
+
+To load completions for every new session, run:
+
+  circleci completion powershell | Out-String | Invoke-Expression
+
+and add the same line to your PowerShell profile ($PROFILE).`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			generate := cmd.Root().GenPowerShellCompletionWithDesc
+			if noDescriptions {
+				generate = cmd.Root().GenPowerShellCompletion
+			}
+			err := generate(os.Stdout)
 			if err != nil {
 				panic(err)
 			}
@@ -42,6 +96,119 @@ func newCompletionCommand() *cobra.Command {
 
 	completionCmd.AddCommand(bashCommand)
 	completionCmd.AddCommand(zshCommand)
+	completionCmd.AddCommand(fishCommand)
+	completionCmd.AddCommand(powershellCommand)
+
+	completionCmd.PersistentFlags().BoolVar(&noDescriptions, "no-descriptions", false, "leave the descriptions of commands and flags out of the completions. It has no effect for bash, whose completions never have them")
 
 	return completionCmd
 }
+
+// completionCache holds the suggestions fetched from the API while
+// completing, so that a single completion request doesn't make the same
+// round-trip twice.
+var completionCache = map[string][]string{}
+
+// cachedCompletions returns the suggestions stored under key, calling fetch
+// to get them the first time. Completion must never fail loudly, so an error
+// from fetch just means that there are no suggestions.
+func cachedCompletions(key string, fetch func() ([]string, error)) []string {
+	if suggestions, ok := completionCache[key]; ok {
+		return suggestions
+	}
+	suggestions, err := fetch()
+	if err != nil {
+		suggestions = nil
+	}
+	completionCache[key] = suggestions
+	return suggestions
+}
+
+// completeContextArgs completes the `<vcs-type> <org-name> <context-name>`
+// arguments shared by the context commands. The context names of the given
+// org are suggested for each of the argument positions in contextArgs.
+func completeContextArgs(client func(*cobra.Command, []string) (api.ContextInterface, error), contextArgs ...int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		// With --org-slug the organization isn't given in the arguments
+		if cmd != nil {
+			slug, _ := cmd.Flags().GetString("org-slug")
+			if parts := strings.Split(slug, "/"); len(parts) == 2 {
+				args = append(parts, args...)
+			}
+		}
+
+		switch len(args) {
+		case 0:
+			return []string{"github", "bitbucket"}, cobra.ShellCompDirectiveNoFileComp
+		case 1:
+			// Suggest the org of the current project, if there is one
+			remote, err := git.InferProjectFromGitRemotes()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return []string{remote.Organization}, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		for _, position := range contextArgs {
+			if len(args) != position {
+				continue
+			}
+			vcsType, orgName := args[0], args[1]
+			names := cachedCompletions("contexts/"+vcsType+"/"+orgName, func() ([]string, error) {
+				contextClient, err := client(cmd, args)
+				if err != nil {
+					return nil, err
+				}
+				contexts, err := contextClient.Contexts(vcsType, orgName)
+				if err != nil {
+					return nil, err
+				}
+				names := []string{}
+				for _, context := range *contexts {
+					names = append(names, context.Name)
+				}
+				return names, nil
+			})
+			return names, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeOrbNames completes an orb name. Once a namespace has been typed the
+// orbs in that namespace are suggested, otherwise the certified orbs are.
+func completeOrbNames(config *settings.Config) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 || strings.Contains(toComplete, "@") {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		cl := graphql.NewClient(config.HTTPClient, config.Host, config.Endpoint, config.Token, false)
+		// Retrying would only keep the shell waiting
+		cl.MaxRetries = 0
+
+		key := "orbs"
+		fetch := func() (*api.OrbsForListing, error) { return api.ListOrbs(cl, false) }
+		if i := strings.Index(toComplete, "/"); i > 0 {
+			namespace := toComplete[:i]
+			key = "orbs/" + namespace
+			fetch = func() (*api.OrbsForListing, error) { return api.ListNamespaceOrbs(cl, namespace, false) }
+		}
+
+		names := cachedCompletions(key, func() ([]string, error) {
+			orbs, err := fetch()
+			if err != nil {
+				return nil, err
+			}
+			names := []string{}
+			for _, orb := range orbs.Orbs {
+				names = append(names, orb.Name)
+			}
+			return names, nil
+		})
+
+		// Leave room for the user to add an @version
+		return names, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+}
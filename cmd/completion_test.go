@@ -0,0 +1,34 @@
+package cmd_test
+
+import (
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Completion", func() {
+	generate := func(args ...string) string {
+		command := exec.Command(pathCLI, append([]string{"completion", "--skip-update-check"}, args...)...)
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(0))
+		return string(session.Out.Contents())
+	}
+
+	DescribeTable("leaves out descriptions with --no-descriptions", func(shell string) {
+		Expect(generate(shell)).ToNot(ContainSubstring("__completeNoDesc"))
+		Expect(generate(shell, "--no-descriptions")).To(ContainSubstring("__completeNoDesc"))
+	},
+		Entry("zsh", "zsh"),
+		Entry("fish", "fish"),
+		Entry("powershell", "powershell"),
+	)
+
+	It("accepts --no-descriptions for bash, whose completions never have them", func() {
+		Expect(generate("bash", "--no-descriptions")).To(Equal(generate("bash")))
+	})
+})
@@ -1,15 +1,19 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/CircleCI-Public/circleci-cli/api"
 	"github.com/CircleCI-Public/circleci-cli/api/graphql"
 	"github.com/CircleCI-Public/circleci-cli/filetree"
 	"github.com/CircleCI-Public/circleci-cli/local"
 	"github.com/CircleCI-Public/circleci-cli/pipeline"
-	"github.com/CircleCI-Public/circleci-cli/proxy"
 	"github.com/CircleCI-Public/circleci-cli/settings"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -18,9 +22,11 @@ import (
 )
 
 type configOptions struct {
-	cfg  *settings.Config
-	cl   *graphql.Client
-	args []string
+	cfg     *settings.Config
+	cl      *graphql.Client
+	args    []string
+	exclude []string
+	dryRun  bool
 }
 
 // Path to the config.yml file to operate on.
@@ -55,6 +61,8 @@ func newConfigCommand(config *settings.Config) *cobra.Command {
 		Annotations: make(map[string]string),
 	}
 	packCommand.Annotations["<path>"] = configAnnotations["<path>"]
+	packCommand.Flags().StringArrayVar(&opts.exclude, "exclude", nil, "leave out the files and directories matching a glob pattern, relative to <path>, such as `test/*`. Can be given more than once.")
+	packCommand.Flags().BoolVar(&opts.dryRun, "dry-run", false, "list the files that would be packed instead of packing them")
 
 	validateCommand := &cobra.Command{
 		Use:     "validate <path>",
@@ -77,6 +85,13 @@ func newConfigCommand(config *settings.Config) *cobra.Command {
 		panic(err)
 	}
 	validateCommand.Flags().StringP("org-slug", "o", "", "organization slug (for example: github/example-org), used when a config depends on private orbs belonging to that org")
+	validateCommand.Flags().Bool("verbose", false, "after a successful validation, print the concrete version each orb reference resolved to, which takes an extra API request for each orb that isn't pinned to a full version")
+	validateCommand.Flags().String("output-format", "text", "how to print the result, one of text or json. json prints an array of {severity, message, line, column, path} objects for each problem found, for editors and other tools")
+	validateCommand.Flags().String("format-errors", "", "also print the problems found in another format, currently only github, which prints GitHub Actions workflow commands to annotate them on the config")
+	validateCommand.Flags().Bool("offline", false, "only run local checks of the config's YAML and structure, skipping orb resolution and other server-side validation")
+	validateCommand.Flags().Bool("exit-zero", false, "report deprecations, such as a deprecated machine image, as warnings that don't fail the validation. Configs with errors still fail")
+	validateCommand.Flags().String("schema", "", "path to a JSON Schema file to check the config against locally, in place of the bundled config schema, before any server-side validation")
+	validateCommand.Flags().Bool("watch", false, "validate the config again each time it's saved, until interrupted with Ctrl-C. Combine with --offline for quicker local checks")
 
 	processCommand := &cobra.Command{
 		Use:   "process <path>",
@@ -93,23 +108,39 @@ func newConfigCommand(config *settings.Config) *cobra.Command {
 	}
 	processCommand.Annotations["<path>"] = configAnnotations["<path>"]
 	processCommand.Flags().StringP("org-slug", "o", "", "organization slug (for example: github/example-org), used when a config depends on private orbs belonging to that org")
-	processCommand.Flags().StringP("pipeline-parameters", "", "", "YAML/JSON map of pipeline parameters, accepts either YAML/JSON directly or file path (for example: my-params.yml)")
+	processCommand.Flags().StringArray("pipeline-parameters", nil, "a pipeline parameter as name=value (for example: deploy=true), which can be repeated, or a YAML/JSON map of pipeline parameters, accepts either YAML/JSON directly or file path (for example: my-params.yml)")
+	processCommand.Flags().String("pipeline-parameters-file", "", "path to a JSON file containing a map of pipeline parameters")
+	processCommand.Flags().String("output-format", "yaml", "format of the processed config, either yaml or json")
+	processCommand.Flags().String("pipeline-values-file", "", "path to a JSON file of pipeline values (for example: {\"git\": {\"branch\": \"main\"}}) to use instead of those inferred from the local git checkout")
+	processCommand.Flags().Bool("no-pipeline-values", false, "don't infer pipeline values from the local git checkout, and fail when the config references a pipeline value or parameter that wasn't given")
 
 	migrateCommand := &cobra.Command{
-		Use:   "migrate",
-		Short: "Migrate a pre-release 2.0 config to the official release version",
+		Use:   "migrate <path>",
+		Short: "Apply safe fixes for deprecated syntax to a config",
+		Long: strings.Join([]string{
+			"Rewrite deprecated syntax in the config at <path> (.circleci/config.yml by default) where it can be done without changing how the config runs.",
+			"", // purposeful new-line
+			"Anything deprecated that can't be fixed safely is left untouched and reported, so that it can be fixed by hand.",
+		}, "\n"),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			opts.args = args
 		},
-		RunE: func(_ *cobra.Command, _ []string) error {
-			return migrateConfig(opts)
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return migrateConfig(opts, cmd.Flags())
 		},
-		Hidden:             true,
-		DisableFlagParsing: true,
+		Args:        cobra.MaximumNArgs(1),
+		Annotations: make(map[string]string),
+	}
+	migrateCommand.Annotations["<path>"] = configAnnotations["<path>"]
+	migrateCommand.Flags().Bool("dry-run", false, "print a unified diff of the changes instead of writing them to the config")
+	// Accepted for compatibility with the migrate command of circleci-agent
+	migrateCommand.Flags().StringP("config", "c", "", "path to config file")
+	migrateCommand.Flags().BoolP("in-place", "i", true, "update the config file in place")
+	for _, name := range []string{"config", "in-place"} {
+		if err := migrateCommand.Flags().MarkHidden(name); err != nil {
+			panic(err)
+		}
 	}
-	// These flags are for documentation and not actually parsed
-	migrateCommand.PersistentFlags().StringP("config", "c", ".circleci/config.yml", "path to config file")
-	migrateCommand.PersistentFlags().BoolP("in-place", "i", false, "whether to update file in place.  If false, emits to stdout")
 
 	configCmd.AddCommand(packCommand)
 	configCmd.AddCommand(validateCommand)
@@ -127,72 +158,341 @@ func validateConfig(opts configOptions, flags *pflag.FlagSet) error {
 		path = configPath
 	}
 
+	watch, _ := flags.GetBool("watch")
+
 	// Then, if an arg is passed in, choose that instead
 	if len(opts.args) == 1 {
 		path = opts.args[0]
+	} else if !watch && !flags.Changed("config") && stdinIsPiped() {
+		// Otherwise read the config being piped in, as with `-`
+		path = "-"
+	}
+
+	outputFormat, _ := flags.GetString("output-format")
+	formatErrors, _ := flags.GetString("format-errors")
+	verbose, _ := flags.GetBool("verbose")
+	offline, _ := flags.GetBool("offline")
+	switch {
+	case outputFormat != "text" && outputFormat != "json":
+		return fmt.Errorf("unknown output format '%s', expected text or json", outputFormat)
+	case outputFormat == "json" && (verbose || offline):
+		return errors.New("--output-format json can't be used with --verbose or --offline")
+	case formatErrors != "" && formatErrors != "github":
+		return fmt.Errorf("unknown error format '%s', expected github", formatErrors)
+	case formatErrors != "" && outputFormat == "json":
+		return errors.New("--format-errors can't be used with --output-format json")
+	case watch && path == "-":
+		return errors.New("--watch needs the path to a config file, it can't watch STDIN")
+	case watch && outputFormat == "json":
+		return errors.New("--watch can't be used with --output-format json")
+	}
+
+	// The schema is checked once, before any config is validated against it
+	var schema *configSchema
+	if schemaPath, _ := flags.GetString("schema"); schemaPath != "" {
+		var err error
+		if schema, err = loadConfigSchema(schemaPath); err != nil {
+			return err
+		}
+	}
+
+	if watch {
+		return watchConfig(path, func() error {
+			return validateConfigOnce(opts, flags, path, schema)
+		})
+	}
+
+	return validateConfigOnce(opts, flags, path, schema)
+}
+
+// validateConfigOnce validates the config at path, with the flags already
+// checked by validateConfig. The config is checked against schema, when it
+// is given with --schema, before it is sent to the API.
+func validateConfigOnce(opts configOptions, flags *pflag.FlagSet, path string, schema *configSchema) error {
+	outputFormat, _ := flags.GetString("output-format")
+	formatErrors, _ := flags.GetString("format-errors")
+	verbose, _ := flags.GetBool("verbose")
+	offline, _ := flags.GetBool("offline")
+	exitZero, _ := flags.GetBool("exit-zero")
+
+	// The problems found are annotated in addition to being reported as usual
+	var config string
+	annotate := func(err error) error {
+		if err != nil && formatErrors == "github" {
+			printGitHubAnnotations(os.Stdout, path, config, err)
+		}
+		return err
+	}
+
+	if offline {
+		return annotate(validateConfigOffline(path, schema))
 	}
 
 	orgSlug, _ := flags.GetString("org-slug")
 
-	response, err := api.ConfigQuery(opts.cl, path, orgSlug, nil, pipeline.LocalPipelineValues())
+	config, err := api.LoadYaml(path)
 	if err != nil {
-		return err
+		return annotate(err)
+	}
+
+	if schema != nil {
+		if err := checkConfigSchema(config, schema); err != nil {
+			return annotate(err)
+		}
+	}
+
+	response, err := api.ConfigSourceQuery(opts.cl, config, orgSlug, nil, pipeline.LocalPipelineValues())
+	if outputFormat == "json" {
+		return printConfigDiagnostics(config, response, err, exitZero)
+	}
+	if err != nil {
+		return annotate(err)
 	}
 
 	// check if a deprecated Linux VM image is being used
 	// link here to blog post when available
-	// returns an error if a deprecated image is used
+	// returns an error if a deprecated image is used, or with --exit-zero
+	// only warns about it
 	if !ignoreDeprecatedImages {
 		err := deprecatedImageCheck(response)
+		if err != nil && !exitZero {
+			return annotate(err)
+		}
 		if err != nil {
-			return err
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+			if formatErrors == "github" {
+				printGitHubDiagnostics(os.Stdout, path, []configDiagnostic{{Severity: "warning", Message: err.Error()}})
+			}
 		}
 	}
 
 	if path == "-" {
-		fmt.Printf("Config input is valid.\n")
+		infof("Config input is valid.\n")
 	} else {
-		fmt.Printf("Config file at %s is valid.\n", path)
+		infof("Config file at %s is valid.\n", path)
+	}
+
+	if verbose {
+		printResolvedOrbs(opts.cl, config)
 	}
 
 	return nil
 }
 
-func processConfig(opts configOptions, flags *pflag.FlagSet) error {
-	orgSlug, _ := flags.GetString("org-slug")
-	paramsYaml, _ := flags.GetString("pipeline-parameters")
+// pinnedOrbVersion matches the orb versions that don't need resolving.
+var pinnedOrbVersion = regexp.MustCompile(`^(\d+\.\d+\.\d+|dev:.+)$`)
 
-	var params pipeline.Parameters
+// printResolvedOrbs prints the version each orb imported by config resolved
+// to, asking the API for any that aren't already pinned to a full version.
+func printResolvedOrbs(cl *graphql.Client, config string) {
+	var source struct {
+		Orbs yaml.Node `yaml:"orbs"`
+	}
+	if err := yaml.Unmarshal([]byte(config), &source); err != nil || source.Orbs.Kind != yaml.MappingNode {
+		return
+	}
 
-	if len(paramsYaml) > 0 {
-		// The 'src' value can be a filepath, or a yaml string. If the file cannot be read sucessfully,
-		// proceed with the assumption that the value is already valid yaml.
-		raw, err := ioutil.ReadFile(paramsYaml)
-		if err != nil {
-			raw = []byte(paramsYaml)
+	infoln("Resolved orbs:")
+	for i := 0; i+1 < len(source.Orbs.Content); i += 2 {
+		name, ref := source.Orbs.Content[i].Value, source.Orbs.Content[i+1]
+		if ref.Kind != yaml.ScalarNode {
+			infof("  %s: inline orb\n", name)
+			continue
 		}
 
-		err = yaml.Unmarshal(raw, &params)
+		orb, version := ref.Value, ""
+		if at := strings.LastIndex(orb, "@"); at >= 0 {
+			orb, version = orb[:at], orb[at+1:]
+		}
+		if pinnedOrbVersion.MatchString(version) {
+			infof("  %s: %s\n", name, ref.Value)
+			continue
+		}
+
+		resolved, err := api.OrbResolvedVersion(cl, ref.Value)
 		if err != nil {
-			return fmt.Errorf("invalid 'pipeline-parameters' provided: %s", err.Error())
+			infof("  %s: %s (could not be resolved: %s)\n", name, ref.Value, err)
+			continue
 		}
+		infof("  %s: %s => %s@%s\n", name, ref.Value, orb, resolved)
+	}
+}
+
+// stdinIsPiped reports whether stdin is piped or redirected rather than being
+// attached to a terminal.
+func stdinIsPiped() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) == 0
+}
+
+// configSourceName returns the name used to refer to the config at path in
+// messages, which is `<stdin>` when reading from STDIN.
+func configSourceName(path string) string {
+	if path == "-" {
+		return "<stdin>"
+	}
+	return path
+}
+
+func processConfig(opts configOptions, flags *pflag.FlagSet) error {
+	orgSlug, _ := flags.GetString("org-slug")
+	paramArgs, _ := flags.GetStringArray("pipeline-parameters")
+	paramsFile, _ := flags.GetString("pipeline-parameters-file")
+	outputFormat, _ := flags.GetString("output-format")
+	valuesFile, _ := flags.GetString("pipeline-values-file")
+	strict, _ := flags.GetBool("no-pipeline-values")
+
+	if err := checkOutputFormat(outputFormat); err != nil {
+		return err
 	}
 
-	response, err := api.ConfigQuery(opts.cl, opts.args[0], orgSlug, params, pipeline.LocalPipelineValues())
+	config, err := api.LoadYaml(opts.args[0])
 	if err != nil {
 		return err
 	}
 
-	fmt.Print(response.OutputYaml)
+	values, err := pipelineValues(valuesFile, !strict)
+	if err != nil {
+		return err
+	}
+
+	params, err := pipelineParameters(config, paramArgs, paramsFile)
+	if err != nil {
+		return err
+	}
+
+	if strict {
+		if err := checkPipelineReferences(config, params, values); err != nil {
+			return err
+		}
+	}
+
+	response, err := api.ConfigSourceQuery(opts.cl, config, orgSlug, params, values)
+	if err != nil {
+		return err
+	}
+
+	return printProcessed(response.OutputYaml, outputFormat)
+}
+
+// checkOutputFormat checks the --output-format of `config process` and
+// `orb process`.
+func checkOutputFormat(format string) error {
+	if format != "yaml" && format != "json" {
+		return fmt.Errorf("unsupported output format '%s', expected yaml or json", format)
+	}
+	return nil
+}
+
+// printProcessed prints YAML from the API to stdout as it is, or converted
+// to JSON when format is json.
+func printProcessed(source, format string) error {
+	if format == "json" {
+		output, err := yamlToJSON(source)
+		if err != nil {
+			return errors.Wrap(err, "Failed to convert the processed YAML to JSON")
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	fmt.Print(source)
+	return nil
+}
+
+// yamlToJSON converts a YAML document to indented JSON, keeping the keys of
+// each map in the order they appear in the YAML.
+func yamlToJSON(source string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(source), &doc); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if len(doc.Content) == 0 {
+		buf.WriteString("null")
+	} else if err := writeYAMLNodeAsJSON(&buf, doc.Content[0]); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// marshalJSONValue encodes v without escaping HTML characters, which are
+// common in configs, e.g. `<< pipeline.id >>`.
+func marshalJSONValue(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func writeYAMLNodeAsJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return writeYAMLNodeAsJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteString("{")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			key, err := marshalJSONValue(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteString(":")
+			if err := writeYAMLNodeAsJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case yaml.SequenceNode:
+		buf.WriteString("[")
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeYAMLNodeAsJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		scalar, err := marshalJSONValue(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(scalar)
+	}
 	return nil
 }
 
 func packConfig(opts configOptions) error {
-	tree, err := filetree.NewTree(opts.args[0])
+	tree, err := filetree.NewTreeExcluding(opts.args[0], opts.exclude)
 	if err != nil {
 		return errors.Wrap(err, "An error occurred trying to build the tree")
 	}
 
+	if opts.dryRun {
+		return printPackedFiles(opts.args[0], tree)
+	}
+
 	y, err := yaml.Marshal(&tree)
 	if err != nil {
 		return errors.Wrap(err, "Failed trying to marshal the tree to YAML ")
@@ -201,6 +501,19 @@ func packConfig(opts configOptions) error {
 	return nil
 }
 
-func migrateConfig(opts configOptions) error {
-	return proxy.Exec([]string{"config", "migrate"}, opts.args)
+// printPackedFiles lists the files in tree relative to root, one per line.
+func printPackedFiles(root string, tree *filetree.Node) error {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range tree.Files() {
+		rel, err := filepath.Rel(absRoot, file)
+		if err != nil {
+			return err
+		}
+		fmt.Println(filepath.ToSlash(rel))
+	}
+	return nil
 }
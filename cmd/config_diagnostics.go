@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-cli/api"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// A configDiagnostic is a problem found by validating a config, in a form
+// that editors can show against the line it was found on. The location is
+// nil when the API doesn't say where the problem is.
+type configDiagnostic struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Line     *int   `json:"line"`
+	Column   *int   `json:"column"`
+	// Path is the JSON pointer, such as /jobs/build/steps/0, of the part of
+	// the config the problem was found in.
+	Path *string `json:"path"`
+}
+
+// configErrorLocation matches the lines of a validation error that begin
+// with the location of the problem as a JSON pointer, such as
+// "[#/jobs/build] extraneous key [foo] is not permitted". These may be
+// numbered when there is more than one.
+var configErrorLocation = regexp.MustCompile(`^\s*(?:\d+\.\s+)?\[#(/[^\]]*)?\]\s*(.*)$`)
+
+// configDiagnostics turns the errors from validating config into
+// diagnostics, finding the line and column of each problem in config from
+// the location given in the error where there is one.
+func configDiagnostics(config string, errs api.GQLErrorsCollection) []configDiagnostic {
+	var root *yaml.Node
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(config), &doc); err == nil && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+
+	diagnostics := []configDiagnostic{}
+	for _, e := range errs {
+		found := false
+		for _, line := range strings.Split(e.Message, "\n") {
+			match := configErrorLocation.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			found = true
+
+			pointer := match[1]
+			diagnostic := configDiagnostic{
+				Severity: "error",
+				Message:  match[2],
+				Path:     &pointer,
+			}
+			if node := configNodeAt(root, pointer); node != nil {
+				nodeLine, nodeColumn := node.Line, node.Column
+				diagnostic.Line = &nodeLine
+				diagnostic.Column = &nodeColumn
+			}
+			diagnostics = append(diagnostics, diagnostic)
+		}
+
+		if !found {
+			diagnostics = append(diagnostics, configDiagnostic{
+				Severity: "error",
+				Message:  e.Message,
+			})
+		}
+	}
+
+	return diagnostics
+}
+
+// configNodeAt follows the JSON pointer from root, returning the deepest
+// node it could be followed to, or nil without a root.
+func configNodeAt(root *yaml.Node, pointer string) *yaml.Node {
+	if root == nil {
+		return nil
+	}
+
+	node := root
+	for _, segment := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			next = mappingValue(node, segment)
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(node.Content) {
+				next = node.Content[i]
+			}
+		}
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+
+	return node
+}
+
+// printConfigDiagnostics prints the outcome of validating config as a JSON
+// array of diagnostics, which is empty when the config is valid. An error is
+// returned when there are any diagnostics, or when the config couldn't be
+// validated at all. With exitZero, deprecations are warnings, which don't
+// cause an error.
+func printConfigDiagnostics(config string, response *api.ConfigResponse, validateErr error, exitZero bool) error {
+	diagnostics := []configDiagnostic{}
+
+	if validateErr != nil {
+		errs, ok := validateErr.(*api.GQLErrorsCollection)
+		if !ok {
+			return validateErr
+		}
+		diagnostics = configDiagnostics(config, *errs)
+	} else if !ignoreDeprecatedImages {
+		if err := deprecatedImageCheck(response); err != nil {
+			severity := "error"
+			if exitZero {
+				severity = "warning"
+			}
+			diagnostics = append(diagnostics, configDiagnostic{
+				Severity: severity,
+				Message:  err.Error(),
+			})
+		}
+	}
+
+	output, err := json.MarshalIndent(diagnostics, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Failed to convert to JSON")
+	}
+	fmt.Println(string(output))
+
+	problems := 0
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == "error" {
+			problems++
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("config is invalid, found %d problem(s)", problems)
+	}
+	return nil
+}
+
+// printGitHubAnnotations prints the problems that validating the config at
+// path found as GitHub Actions workflow commands, so that they are annotated
+// on the file and, where it is known, the line they were found on. Errors
+// other than those from validating the config are annotated on the file.
+func printGitHubAnnotations(w io.Writer, path, config string, validateErr error) {
+	var diagnostics []configDiagnostic
+	if errs, ok := validateErr.(*api.GQLErrorsCollection); ok {
+		diagnostics = configDiagnostics(config, *errs)
+	} else {
+		diagnostics = []configDiagnostic{{Severity: "error", Message: validateErr.Error()}}
+	}
+	printGitHubDiagnostics(w, path, diagnostics)
+}
+
+// printGitHubDiagnostics prints diagnostics found in the config at path as
+// GitHub Actions workflow commands.
+func printGitHubDiagnostics(w io.Writer, path string, diagnostics []configDiagnostic) {
+	for _, diagnostic := range diagnostics {
+		var properties []string
+		if path != "-" {
+			properties = append(properties, "file="+escapeGitHubProperty(path))
+		}
+		if diagnostic.Line != nil {
+			properties = append(properties, fmt.Sprintf("line=%d", *diagnostic.Line))
+		}
+		if diagnostic.Column != nil {
+			properties = append(properties, fmt.Sprintf("col=%d", *diagnostic.Column))
+		}
+		fmt.Fprintf(w, "::%s %s::%s\n", diagnostic.Severity, strings.Join(properties, ","), escapeGitHubData(diagnostic.Message))
+	}
+}
+
+// escapeGitHubData escapes the message of a workflow command, see
+// https://github.com/actions/toolkit/blob/main/packages/core/src/command.ts
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes the value of a property of a workflow command.
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
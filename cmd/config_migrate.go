@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-cli/local"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// A configMigration is a fix `config migrate` found for deprecated syntax in
+// a config. Fixes that can't be made without changing how the config runs
+// have no edit, and are only reported.
+type configMigration struct {
+	line    int
+	message string
+	edit    *configEdit
+}
+
+// A configEdit replaces old, found at line and column of the config, with
+// new, or removes the whole line.
+type configEdit struct {
+	line       int
+	column     int
+	old        string
+	new        string
+	deleteLine bool
+}
+
+// migrateConfig applies the safe fixes found for the config at the path given
+// to the command, writing it back in place, or printing a diff with
+// --dry-run. The fixes that aren't safe are reported and left for the user.
+func migrateConfig(opts configOptions, flags *pflag.FlagSet) error {
+	path := local.DefaultConfigPath
+	if legacyPath, _ := flags.GetString("config"); legacyPath != "" {
+		path = legacyPath
+	}
+	if len(opts.args) == 1 {
+		path = opts.args[0]
+	}
+	dryRun, _ := flags.GetBool("dry-run")
+	inPlace, _ := flags.GetBool("in-place")
+
+	var (
+		raw []byte
+		err error
+	)
+	if path == "-" {
+		raw, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		raw, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Could not load config file at %s", configSourceName(path))
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return errors.Wrapf(err, "Config at %s is not valid YAML", configSourceName(path))
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("Config at %s must be a map", configSourceName(path))
+	}
+
+	lines := strings.SplitAfter(string(raw), "\n")
+	migrations := findConfigMigrations(doc.Content[0], lines)
+	migrated := applyConfigEdits(lines, migrations)
+
+	// The migrated config is the output when it isn't written back to a file,
+	// so the report goes to stderr instead
+	report := os.Stdout
+	if !dryRun && (path == "-" || !inPlace) {
+		report = os.Stderr
+	}
+	reportConfigMigrations(report, configSourceName(path), migrations)
+
+	switch {
+	case dryRun:
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        lines,
+			B:        strings.SplitAfter(migrated, "\n"),
+			FromFile: configSourceName(path),
+			ToFile:   configSourceName(path),
+			Context:  3,
+		})
+		if err != nil {
+			return errors.Wrap(err, "Unable to diff the migrated config")
+		}
+		fmt.Print(diff)
+		fmt.Printf("Dry run, %s was not changed.\n", configSourceName(path))
+		return nil
+
+	case path == "-" || !inPlace:
+		fmt.Print(migrated)
+		return nil
+
+	case migrated == string(raw):
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, []byte(migrated), info.Mode()); err != nil {
+		return errors.Wrapf(err, "Unable to write the migrated config to %s", path)
+	}
+	fmt.Printf("Wrote the migrated config to %s.\n", path)
+
+	return nil
+}
+
+func reportConfigMigrations(out *os.File, name string, migrations []configMigration) {
+	var applied, manual []configMigration
+	for _, m := range migrations {
+		if m.edit != nil {
+			applied = append(applied, m)
+		} else {
+			manual = append(manual, m)
+		}
+	}
+
+	if len(migrations) == 0 {
+		fmt.Fprintf(out, "Nothing to migrate in %s.\n", name)
+		return
+	}
+	if len(applied) > 0 {
+		fmt.Fprintf(out, "Migrated %s:\n", name)
+		for _, m := range applied {
+			fmt.Fprintf(out, "  line %d: %s\n", m.line, m.message)
+		}
+	}
+	if len(manual) > 0 {
+		fmt.Fprintln(out, "Left unchanged, as these can't be fixed safely:")
+		for _, m := range manual {
+			fmt.Fprintf(out, "  line %d: %s\n", m.line, m.message)
+		}
+	}
+}
+
+// applyConfigEdits returns the config with the edits of migrations made to
+// its lines. Edits are made from the end of the config backwards, so that
+// each one's position is still correct when it is made.
+func applyConfigEdits(lines []string, migrations []configMigration) string {
+	var edits []*configEdit
+	for _, m := range migrations {
+		if m.edit != nil {
+			edits = append(edits, m.edit)
+		}
+	}
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].line != edits[j].line {
+			return edits[i].line > edits[j].line
+		}
+		return edits[i].column > edits[j].column
+	})
+
+	result := append([]string{}, lines...)
+	for _, e := range edits {
+		i := e.line - 1
+		if e.deleteLine {
+			result = append(result[:i], result[i+1:]...)
+			continue
+		}
+		col := e.column - 1
+		result[i] = result[i][:col] + e.new + result[i][col+len(e.old):]
+	}
+
+	return strings.Join(result, "")
+}
+
+// findConfigMigrations looks for deprecated syntax in config.
+func findConfigMigrations(config *yaml.Node, lines []string) []configMigration {
+	var migrations []configMigration
+
+	version := mappingValue(config, "version")
+
+	// Workflows don't need a version in 2.1, and it is ignored
+	if workflows := mappingValue(config, "workflows"); version != nil && version.Value == "2.1" && workflows != nil && workflows.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(workflows.Content); i += 2 {
+			key, value := workflows.Content[i], workflows.Content[i+1]
+			if key.Value != "version" {
+				continue
+			}
+			m := configMigration{line: key.Line, message: "removed the workflows version, which 2.1 configs don't use"}
+			if value.Kind == yaml.ScalarNode && value.Line == key.Line && strings.HasPrefix(strings.TrimSpace(lines[key.Line-1]), "version:") {
+				m.edit = &configEdit{line: key.Line, deleteLine: true}
+			} else {
+				m.message = "the workflows version isn't used by 2.1 configs and can be removed"
+			}
+			migrations = append(migrations, m)
+		}
+	}
+
+	for _, section := range []string{"jobs", "executors"} {
+		node := mappingValue(config, section)
+		if node == nil || node.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, job := node.Content[i].Value, node.Content[i+1]
+			if job.Kind != yaml.MappingNode {
+				continue
+			}
+			migrations = append(migrations, findImageMigrations(job)...)
+			if section == "jobs" {
+				migrations = append(migrations, findDeployStepMigrations(name, job, lines)...)
+			}
+		}
+	}
+
+	if commands := mappingValue(config, "commands"); commands != nil && commands.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(commands.Content); i += 2 {
+			name, command := commands.Content[i].Value, commands.Content[i+1]
+			for _, step := range deploySteps(command) {
+				migrations = append(migrations, configMigration{
+					line:    step.Line,
+					message: fmt.Sprintf("the deploy step of command '%s' is deprecated, use a run step instead, unless the command is used in a job with parallelism which only deploys from one container", name),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(migrations, func(i, j int) bool { return migrations[i].line < migrations[j].line })
+	return migrations
+}
+
+// findDeployStepMigrations replaces the deprecated deploy steps of job with
+// run steps. These only differ when the job runs in parallel, where deploy
+// only runs on one of the containers, so those are left alone.
+func findDeployStepMigrations(name string, job *yaml.Node, lines []string) []configMigration {
+	var migrations []configMigration
+
+	parallel := false
+	if parallelism := mappingValue(job, "parallelism"); parallelism != nil && parallelism.Value != "1" {
+		parallel = true
+	}
+
+	for _, step := range deploySteps(job) {
+		m := configMigration{
+			line:    step.Line,
+			message: fmt.Sprintf("replaced the deprecated deploy step of job '%s' with a run step", name),
+		}
+		if parallel {
+			m.message = fmt.Sprintf("the deploy step of job '%s' is deprecated, but it only runs on one of the job's parallel containers so can't be replaced with a run step", name)
+		} else if strings.HasPrefix(lines[step.Line-1][step.Column-1:], "deploy") {
+			m.edit = &configEdit{line: step.Line, column: step.Column, old: "deploy", new: "run"}
+		}
+		migrations = append(migrations, m)
+	}
+
+	return migrations
+}
+
+// deploySteps returns the key nodes of the deploy steps in the steps of node.
+func deploySteps(node *yaml.Node) []*yaml.Node {
+	var keys []*yaml.Node
+
+	steps := mappingValue(node, "steps")
+	if steps == nil || steps.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, step := range steps.Content {
+		if step.Kind == yaml.MappingNode && len(step.Content) == 2 && step.Content[0].Value == "deploy" {
+			keys = append(keys, step.Content[0])
+		}
+	}
+
+	return keys
+}
+
+// findImageMigrations reports the deprecated images used by a job or
+// executor. Changing the image changes the environment the job runs in, so
+// these are never migrated automatically.
+func findImageMigrations(job *yaml.Node) []configMigration {
+	var migrations []configMigration
+
+	if machine := mappingValue(job, "machine"); machine != nil {
+		switch {
+		case machine.Kind == yaml.ScalarNode && machine.Value == "true":
+			migrations = append(migrations, configMigration{
+				line:    machine.Line,
+				message: "`machine: true` uses a deprecated default image, set a machine image such as ubuntu-2004:current instead",
+			})
+		case machine.Kind == yaml.MappingNode:
+			if image := mappingValue(machine, "image"); image != nil {
+				for _, deprecated := range deprecatedImages {
+					if image.Value == deprecated {
+						migrations = append(migrations, configMigration{
+							line:    image.Line,
+							message: fmt.Sprintf("the machine image %s is deprecated, use a current image such as ubuntu-2004:current instead", image.Value),
+						})
+						break
+					}
+				}
+			}
+		}
+	}
+
+	if docker := mappingValue(job, "docker"); docker != nil && docker.Kind == yaml.SequenceNode {
+		for _, container := range docker.Content {
+			if container.Kind != yaml.MappingNode {
+				continue
+			}
+			if image := mappingValue(container, "image"); image != nil && strings.HasPrefix(image.Value, "circleci/") {
+				migrations = append(migrations, configMigration{
+					line:    image.Line,
+					message: fmt.Sprintf("the legacy convenience image %s is deprecated, use the cimg/ image that replaces it instead", image.Value),
+				})
+			}
+		}
+	}
+
+	return migrations
+}
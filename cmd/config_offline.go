@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// validateConfigOffline runs the local checks against the config at path and
+// reports which checks were run and which were skipped. When schema is given,
+// the config is checked against it instead of the bundled config schema.
+func validateConfigOffline(path string, schema *configSchema) error {
+	var (
+		raw []byte
+		err error
+	)
+	if path == "-" {
+		raw, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		raw, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Could not load config file at %s", configSourceName(path))
+	}
+
+	infoln("Ran local checks:")
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		infoln("  - YAML syntax: failed")
+		return errors.Wrapf(err, "Config at %s is not valid YAML", configSourceName(path))
+	}
+	infoln("  - YAML syntax: ok")
+
+	if len(doc.Content) == 0 {
+		return errors.New("Config is empty")
+	}
+	config := doc.Content[0]
+
+	if schema == nil {
+		if schema, err = bundledSchema("config-schema.json", "Config"); err != nil {
+			return err
+		}
+	}
+	if err := schema.check(config); err != nil {
+		infof("  - schema %s: failed\n", schema.path)
+		return err
+	}
+	infof("  - schema %s: ok\n", schema.path)
+
+	infoln("Skipped checks (--offline):")
+	infoln("  - orb resolution")
+	infoln("  - server-side config compilation and schema validation")
+
+	if path == "-" {
+		infof("Config input is valid, orb resolution was skipped.\n")
+	} else {
+		infof("Config file at %s is valid, orb resolution was skipped.\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-cli/pipeline"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// pipelineParameterArg matches a single `name=value` pipeline parameter.
+var pipelineParameterArg = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_-]*)=(.*)$`)
+
+// parsePipelineParameterValue infers the type of a parameter given on the
+// command line, which is a boolean, an integer or otherwise a string.
+func parsePipelineParameterValue(value string) interface{} {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
+	}
+	return value
+}
+
+// pipelineParameters collects the pipeline parameters given to config process.
+// Each value of --pipeline-parameters is either a `name=value` pair, or a
+// YAML/JSON map (or a path to one) as it has always been accepted. Parameters
+// from --pipeline-parameters-file are applied first and `name=value` pairs last.
+//
+// The parameters given as `name=value` or in a file must be declared in the
+// config, so that a typo doesn't go unnoticed.
+func pipelineParameters(config string, args []string, paramsFile string) (pipeline.Parameters, error) {
+	var params pipeline.Parameters
+	checked := pipeline.Parameters{}
+
+	set := func(name string, value interface{}) {
+		if params == nil {
+			params = pipeline.Parameters{}
+		}
+		params[name] = value
+	}
+
+	var pairs []string
+	for _, arg := range args {
+		if pipelineParameterArg.MatchString(arg) {
+			pairs = append(pairs, arg)
+			continue
+		}
+
+		// The 'src' value can be a filepath, or a yaml string. If the file cannot be read sucessfully,
+		// proceed with the assumption that the value is already valid yaml.
+		raw, err := ioutil.ReadFile(arg)
+		if err != nil {
+			raw = []byte(arg)
+		}
+
+		var values pipeline.Parameters
+		if err := yaml.Unmarshal(raw, &values); err != nil {
+			return nil, fmt.Errorf("invalid 'pipeline-parameters' provided: %s", err.Error())
+		}
+		for name, value := range values {
+			set(name, value)
+		}
+	}
+
+	if paramsFile != "" {
+		raw, err := ioutil.ReadFile(paramsFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not load pipeline parameters file at %s", paramsFile)
+		}
+		var values pipeline.Parameters
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, errors.Wrapf(err, "Pipeline parameters file at %s is not a valid JSON object", paramsFile)
+		}
+		for name, value := range values {
+			set(name, value)
+			checked[name] = value
+		}
+	}
+
+	for _, pair := range pairs {
+		match := pipelineParameterArg.FindStringSubmatch(pair)
+		value := parsePipelineParameterValue(match[2])
+		set(match[1], value)
+		checked[match[1]] = value
+	}
+
+	if len(checked) > 0 {
+		if err := checkPipelineParametersDeclared(config, checked); err != nil {
+			return nil, err
+		}
+	}
+
+	return params, nil
+}
+
+// checkPipelineParametersDeclared returns an error naming the first of params
+// which isn't declared in the top-level `parameters` of config.
+func checkPipelineParametersDeclared(config string, params pipeline.Parameters) error {
+	var declared struct {
+		Parameters map[string]interface{} `yaml:"parameters"`
+	}
+	if err := yaml.Unmarshal([]byte(config), &declared); err != nil {
+		// Leave reporting the broken config to the server
+		return nil
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := declared.Parameters[name]; ok {
+			continue
+		}
+		if len(declared.Parameters) == 0 {
+			return fmt.Errorf("unknown pipeline parameter '%s', the config doesn't declare any parameters", name)
+		}
+		known := make([]string, 0, len(declared.Parameters))
+		for name := range declared.Parameters {
+			known = append(known, name)
+		}
+		sort.Strings(known)
+		return fmt.Errorf("unknown pipeline parameter '%s', the config declares: %s", name, strings.Join(known, ", "))
+	}
+	return nil
+}
+
+// pipelineValues returns the `<< pipeline.x >>` values to process a config
+// with. Unless local is false, these are inferred from the local git
+// checkout, and overridden by any given in the JSON file at valuesFile, so
+// that the config is processed the way it would be for another branch, tag or
+// revision. Nested objects in the file are flattened, so
+// {"git": {"branch": "main"}} sets git.branch, and values the CLI doesn't know
+// about are still passed on to the API.
+func pipelineValues(valuesFile string, local bool) (pipeline.Values, error) {
+	values := pipeline.Values{}
+	if local {
+		values = pipeline.LocalPipelineValues()
+	}
+	if valuesFile == "" {
+		return values, nil
+	}
+
+	raw, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not load pipeline values file at %s", valuesFile)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var fromFile map[string]interface{}
+	if err := decoder.Decode(&fromFile); err != nil {
+		return nil, errors.Wrapf(err, "Pipeline values file at %s is not a valid JSON object", valuesFile)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("Pipeline values file at %s is not a valid JSON object: unexpected data after the object", valuesFile)
+	}
+
+	if err := flattenPipelineValues(values, "", fromFile); err != nil {
+		return nil, errors.Wrapf(err, "Invalid pipeline values file at %s", valuesFile)
+	}
+
+	return values, nil
+}
+
+func flattenPipelineValues(values pipeline.Values, prefix string, from map[string]interface{}) error {
+	for key, value := range from {
+		name := prefix + key
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flattenPipelineValues(values, name+".", v); err != nil {
+				return err
+			}
+		case string:
+			values[name] = v
+		case json.Number:
+			values[name] = v.String()
+		case bool:
+			values[name] = strconv.FormatBool(v)
+		case nil:
+			values[name] = ""
+		default:
+			return fmt.Errorf("the value of %s must be a string, number or boolean", name)
+		}
+	}
+	return nil
+}
+
+// pipelineReference matches a `<< pipeline.x >>` reference, capturing x.
+var pipelineReference = regexp.MustCompile(`<<\s*pipeline\.([A-Za-z0-9_.-]+)\s*>>`)
+
+// checkPipelineReferences returns an error listing each `<< pipeline.x >>`
+// reference in the values of config that neither values nor params give. A
+// parameter declared with a default doesn't need to be given.
+func checkPipelineReferences(config string, params pipeline.Parameters, values pipeline.Values) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(config), &doc); err != nil {
+		// Leave reporting the broken config to the server
+		return nil
+	}
+	var declared struct {
+		Parameters map[string]map[string]interface{} `yaml:"parameters"`
+	}
+	// Parameters that aren't maps are left to the server to report
+	_ = doc.Decode(&declared)
+
+	missing := map[string]bool{}
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.ScalarNode {
+			for _, match := range pipelineReference.FindAllStringSubmatch(node.Value, -1) {
+				name := match[1]
+				if parameter := strings.TrimPrefix(name, "parameters."); parameter != name {
+					if _, ok := params[parameter]; ok {
+						continue
+					}
+					if _, ok := declared.Parameters[parameter]["default"]; ok {
+						continue
+					}
+				} else if _, ok := values[name]; ok {
+					continue
+				}
+				missing["pipeline."+name] = true
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(&doc)
+
+	if len(missing) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("the config references pipeline values that weren't given:\n  %s", strings.Join(names, "\n  "))
+}
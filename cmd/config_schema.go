@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-cli/data"
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// A configSchema is a JSON Schema that configs are checked against, such as
+// one given with `config validate --schema`. Schemas without a $schema are
+// read as draft-07.
+type configSchema struct {
+	path string
+	// subject names the document in errors, such as Config
+	subject string
+	schema  *jsonschema.Schema
+}
+
+// loadConfigSchema reads the JSON Schema at path, checking that it is a
+// schema that can be used before any config is checked against it.
+func loadConfigSchema(path string) (*configSchema, error) {
+	raw, err := ioutil.ReadFile(path) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not load the schema at %s", path)
+	}
+	return compileSchema(path, "Config", raw)
+}
+
+// bundledSchema compiles the JSON Schema in _data with the given name, which
+// documents are checked against as subject.
+func bundledSchema(name, subject string) (*configSchema, error) {
+	raw, err := data.Schema(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not load the bundled schema %s", name)
+	}
+	return compileSchema(name, subject, raw)
+}
+
+// compileSchema compiles the JSON Schema raw, which is named path in errors.
+// The schema is checked against its meta-schema, and every $ref in it must
+// point to a schema without looping back on itself. A $ref can point to
+// another file next to the schema, but not to one on the network.
+func compileSchema(path, subject string, raw []byte) (*configSchema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		if !strings.HasPrefix(url, "file://") {
+			return nil, fmt.Errorf("unsupported $ref %s, only references within the schema or to local files are supported", url)
+		}
+		return jsonschema.LoadURL(url)
+	}
+
+	if err := compiler.AddResource(path, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %s", path, strings.TrimPrefix(err.Error(), "jsonschema: "))
+	}
+	schema, err := compiler.Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %s", path, describeSchemaError(err))
+	}
+	return &configSchema{path: path, subject: subject, schema: schema}, nil
+}
+
+// describeSchemaError returns why a schema couldn't be compiled, without the
+// URLs that the library gives schemas.
+func describeSchemaError(err error) string {
+	if schemaErr, ok := err.(*jsonschema.SchemaError); ok && schemaErr.Err != nil {
+		err = schemaErr.Err
+	}
+	switch err := err.(type) {
+	case *jsonschema.ValidationError:
+		leaf := err
+		for len(leaf.Causes) > 0 {
+			leaf = leaf.Causes[0]
+		}
+		return fmt.Sprintf("#%s: %s", leaf.InstanceLocation, leaf.Message)
+	case jsonschema.InfiniteLoopError:
+		ref := string(err)
+		return fmt.Sprintf("the $ref at %s loops back on itself", ref[strings.Index(ref, "#"):])
+	}
+	message := strings.TrimPrefix(err.Error(), "jsonschema: ")
+	if i := strings.Index(message, "#/"); i >= 0 && strings.HasSuffix(message, " not found") {
+		return fmt.Sprintf("$ref %s doesn't point to a schema", strings.TrimSuffix(message[i:], " not found"))
+	}
+	return message
+}
+
+// check checks the document doc against the schema.
+func (schema *configSchema) check(doc *yaml.Node) error {
+	value, err := nodeValue(doc)
+	if err != nil {
+		return err
+	}
+	err = schema.schema.Validate(value)
+	if mismatch, ok := err.(*jsonschema.ValidationError); ok {
+		return schema.mismatch(doc, mismatch)
+	}
+	return err
+}
+
+// mismatch describes the first way in which doc doesn't match the schema,
+// along with where in doc it is.
+func (schema *configSchema) mismatch(doc *yaml.Node, err *jsonschema.ValidationError) error {
+	for len(err.Causes) > 0 && !isAlternatives(err) {
+		err = earliestCause(doc, err.Causes)
+	}
+
+	message := err.Message
+	if isAlternatives(err) && len(err.Causes) > 0 {
+		first := err.Causes[0]
+		for len(first.Causes) > 0 {
+			first = earliestCause(doc, first.Causes)
+		}
+		message = fmt.Sprintf("%s, the first schema failed with: %s", message, first.Message)
+	}
+
+	if strings.HasSuffix(err.KeywordLocation, "/enum") {
+		message = uniqueEnumMessage(message)
+	}
+
+	node, path := locateNode(doc, err.InstanceLocation)
+
+	if strings.HasSuffix(err.KeywordLocation, "additionalProperties") && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if strings.Contains(err.Message, "'"+key.Value+"'") {
+				return unexpectedKey(path, key)
+			}
+		}
+	}
+
+	// A key whose schema is false is one that's never allowed
+	if err.Message == "not allowed" && err.InstanceLocation != "" {
+		i := strings.LastIndex(err.InstanceLocation, "/")
+		parent, parentPath := locateNode(doc, err.InstanceLocation[:i])
+		if parent.Kind == yaml.MappingNode {
+			name := strings.Replace(strings.Replace(err.InstanceLocation[i+1:], "~1", "/", -1), "~0", "~", -1)
+			for j := 0; j+1 < len(parent.Content); j += 2 {
+				if key := parent.Content[j]; key.Value == name {
+					return unexpectedKey(parentPath, key)
+				}
+			}
+		}
+	}
+
+	if path == "" {
+		path = schema.subject
+	}
+	return fmt.Errorf("%s on line %d: %s", path, node.Line, message)
+}
+
+// unexpectedKey reports key, in the mapping at path, as a key the schema
+// doesn't allow.
+func unexpectedKey(path string, key *yaml.Node) error {
+	if path == "" {
+		return fmt.Errorf("Unexpected top-level key '%s' on line %d", key.Value, key.Line)
+	}
+	return fmt.Errorf("Unexpected key '%s.%s' on line %d", path, key.Value, key.Line)
+}
+
+// uniqueEnumMessage drops repeated values from the library's message for an
+// enum mismatch. Numbers and strings are quoted alike in it, so an enum that
+// allows both 2.1 and "2.1" would otherwise list "2.1" twice.
+func uniqueEnumMessage(message string) string {
+	const prefix = "value must be one of "
+	if !strings.HasPrefix(message, prefix) {
+		return message
+	}
+	var values []string
+	seen := map[string]bool{}
+	for _, value := range strings.Split(strings.TrimPrefix(message, prefix), ", ") {
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	if len(values) == 1 {
+		return "value must be " + values[0]
+	}
+	return prefix + strings.Join(values, ", ")
+}
+
+// earliestCause returns the cause whose instance comes first in doc. The
+// library finds the causes in no particular order for properties, so
+// without this the error reported could change from one run to the next.
+func earliestCause(doc *yaml.Node, causes []*jsonschema.ValidationError) *jsonschema.ValidationError {
+	earliest := causes[0]
+	first, _ := locateNode(doc, earliest.InstanceLocation)
+	for _, cause := range causes[1:] {
+		node, _ := locateNode(doc, cause.InstanceLocation)
+		if node.Line < first.Line || node.Line == first.Line && node.Column < first.Column {
+			earliest, first = cause, node
+		}
+	}
+	return earliest
+}
+
+// isAlternatives reports whether err is the failure of anyOf or oneOf, whose
+// causes are the failures of each of the alternatives.
+func isAlternatives(err *jsonschema.ValidationError) bool {
+	return strings.HasSuffix(err.KeywordLocation, "/anyOf") || strings.HasSuffix(err.KeywordLocation, "/oneOf")
+}
+
+// locateNode returns the node at the JSON pointer in doc, along with its path
+// in the form jobs.build.steps[1]. When the pointer leads nowhere, the
+// closest node to it is returned.
+func locateNode(doc *yaml.Node, pointer string) (*yaml.Node, string) {
+	node, path := doc, ""
+	if pointer == "" {
+		return node, path
+	}
+	for _, part := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		part = strings.Replace(strings.Replace(part, "~1", "/", -1), "~0", "~", -1)
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			next = mappingValue(node, part)
+			if path != "" {
+				part = "." + part
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(part); err == nil && i < len(node.Content) {
+				next = node.Content[i]
+			}
+			part = "[" + part + "]"
+		}
+		if next == nil {
+			break
+		}
+		node, path = next, path+part
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node, path
+}
+
+// mappingValue returns the value node of key in the mapping node m, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// nodeValue returns the value of node as it would be decoded from JSON, so
+// that it can be checked against a schema.
+func nodeValue(node *yaml.Node) (interface{}, error) {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to check the schema of a document with keys that aren't strings")
+	}
+	var normalized interface{}
+	err = json.Unmarshal(raw, &normalized)
+	return normalized, err
+}
+
+// checkConfigSchema checks the source of a config against schema.
+func checkConfigSchema(config string, schema *configSchema) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(config), &doc); err != nil {
+		return errors.Wrap(err, "Config is not valid YAML")
+	}
+	if len(doc.Content) == 0 {
+		return errors.New("Config is empty")
+	}
+	return errors.Wrapf(schema.check(doc.Content[0]), "Config doesn't match the schema %s", schema.path)
+}
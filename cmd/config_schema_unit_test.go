@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config schema", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "circleci-cli-test-")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	load := func(source string) (*configSchema, error) {
+		path := filepath.Join(dir, "schema.json")
+		Expect(ioutil.WriteFile(path, []byte(source), 0600)).To(Succeed())
+		return loadConfigSchema(path)
+	}
+
+	DescribeTable("checking configs", func(schemaSource, config, expected string) {
+		schema, err := load(schemaSource)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = checkConfigSchema(config, schema)
+		if expected == "" {
+			Expect(err).ShouldNot(HaveOccurred())
+		} else {
+			Expect(err).To(MatchError(ContainSubstring(expected)))
+		}
+	},
+		Entry("a matching type", `{"type": "object"}`, "version: 2.1\n", ""),
+		Entry("a mismatched type", `{"properties": {"jobs": {"type": "object"}}}`, "jobs: [a]\n", "jobs on line 1: expected object, but got array"),
+		Entry("integers are numbers", `{"properties": {"parallelism": {"type": "number", "minimum": 1}}}`, "parallelism: 2\n", ""),
+		Entry("a number below the minimum", `{"properties": {"parallelism": {"minimum": 1}}}`, "parallelism: 0\n", "parallelism on line 1: must be >= 1 but found 0"),
+		Entry("a value outside the enum", `{"properties": {"version": {"enum": [2, 2.1]}}}`, "version: 3\n", `version on line 1: value must be one of "2", "2.1"`),
+		Entry("an enum of the same value as a number and a string", `{"properties": {"version": {"enum": [2.1, "2.1"]}}}`, "version: 2\n", `version on line 1: value must be "2.1"`),
+		Entry("an unexpected key", `{"additionalProperties": false, "properties": {"version": true}}`, "version: 2.1\nsetup: true\n", "Unexpected top-level key 'setup' on line 2"),
+		Entry("a key that's never allowed", `{"properties": {"workflows": false}}`, "version: 2.1\nworkflows:\n  main: {}\n", "Unexpected top-level key 'workflows' on line 2"),
+		Entry("a nested key that's never allowed", `{"properties": {"display": {"properties": {"home": false}}}}`, "display:\n  home: x\n", "Unexpected key 'display.home' on line 2"),
+		Entry("an unexpected nested key", `{"properties": {"display": {"additionalProperties": false}}}`, "display:\n  home: x\n", "Unexpected key 'display.home' on line 2"),
+		Entry("keys matching a pattern", `{"patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": false}`, "x-team: web\n", ""),
+		Entry("a missing key", `{"properties": {"jobs": {"additionalProperties": {"required": ["steps"]}}}}`, "jobs:\n  build:\n    docker: []\n", "jobs.build on line 3: missing properties: 'steps'"),
+		Entry("too few items", `{"properties": {"steps": {"minItems": 1}}}`, "steps: []\n", "steps on line 1: minimum 1 items required, but found 0 items"),
+		Entry("an item that doesn't match", `{"properties": {"steps": {"items": {"type": "string"}}}}`, "steps: [checkout, {run: make}]\n", "steps[1] on line 1: expected string, but got object"),
+		Entry("anyOf", `{"properties": {"image": {"anyOf": [{"pattern": "^cimg/"}, {"pattern": "^internal/"}]}}}`, "image: ubuntu\n", "image on line 1: anyOf failed, the first schema failed with: does not match pattern '^cimg/'"),
+		Entry("oneOf matching more than one", `{"oneOf": [{"type": "object"}, {"required": ["version"]}]}`, "version: 2.1\n", "Config on line 1: valid against schemas at indexes 0 and 1"),
+		Entry("not", `{"properties": {"resource_class": {"not": {"const": "xlarge"}}}}`, "resource_class: xlarge\n", "resource_class on line 1: not failed"),
+		Entry("if and then", `{"if": {"required": ["machine"]}, "then": {"required": ["resource_class"]}}`, "machine: true\n", "Config on line 1: missing properties: 'resource_class'"),
+		Entry("unique items", `{"properties": {"branches": {"uniqueItems": true}}}`, "branches: [main, main]\n", "branches on line 1: items at index 0 and 1 are equal"),
+		Entry("an exclusive minimum", `{"properties": {"parallelism": {"exclusiveMinimum": 1}}}`, "parallelism: 1\n", "parallelism on line 1: must be > 1 but found 1"),
+		Entry("property names", `{"propertyNames": {"pattern": "^[a-z]+$"}}`, "Jobs: {}\n", "does not match pattern '^[a-z]+$'"),
+		Entry("a $ref to the root", `{"properties": {"version": {"type": "number"}, "child": {"$ref": "#"}}}`, "child:\n  version: two\n", "child.version on line 2: expected number, but got string"),
+		Entry("the first of several mismatches", `{"additionalProperties": {"type": "string"}}`, "a: x\nb: 1\nc: x\nd: 2\ne: 3\n", "b on line 2: expected string, but got number"),
+		Entry("aliases", `{"properties": {"b": {"type": "string"}}}`, "a: &x [1]\nb: *x\n", "b on line 1: expected string, but got array"),
+	)
+
+	DescribeTable("refusing invalid schemas", func(schemaSource, expected string) {
+		_, err := load(schemaSource)
+		Expect(err).To(MatchError(ContainSubstring(expected)))
+	},
+		Entry("invalid JSON", `{"type": `, "unexpected EOF"),
+		Entry("a schema that isn't an object", `[]`, "#: expected object or boolean, but got array"),
+		Entry("an unknown type", `{"type": ["string", "text"]}`, "#/type: value must be one of"),
+		Entry("an invalid pattern", `{"properties": {"name": {"pattern": "("}}}`, "#/properties/name/pattern: '(' is not valid 'regex'"),
+		Entry("a missing $ref", `{"$ref": "#/definitions/job"}`, "$ref #/definitions/job doesn't point to a schema"),
+		Entry("a $ref to a value that isn't a schema", `{"definitions": {"a": {"type": "string"}}, "$ref": "#/definitions/a/type"}`, "#/definitions/a/type: expected object or boolean, but got string"),
+		Entry("a $ref that loops", `{"anyOf": [{"$ref": "#"}]}`, "the $ref at #/anyOf/0/$ref loops back on itself"),
+		Entry("a remote $ref", `{"$ref": "https://json.schemastore.org/circleciconfig.json"}`, "only references within the schema or to local files are supported"),
+	)
+})
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/CircleCI-Public/circleci-cli/api/graphql"
@@ -95,6 +98,61 @@ var _ = Describe("Config", func() {
 			})
 		})
 
+		Describe("with --exclude and --dry-run", func() {
+			It("lists the files that would be packed", func() {
+				command = exec.Command(pathCLI,
+					"config", "pack",
+					"--skip-update-check",
+					"--dry-run",
+					"testdata/myorb/test")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(Equal("@orb.yml\ncommands/zomg.yml\n"))
+			})
+
+			It("leaves out the files matching a pattern", func() {
+				command = exec.Command(pathCLI,
+					"config", "pack",
+					"--skip-update-check",
+					"--dry-run",
+					"--exclude", "commands/*",
+					"testdata/myorb/test")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(Equal("@orb.yml\n"))
+			})
+
+			It("doesn't pack the excluded files", func() {
+				command = exec.Command(pathCLI,
+					"config", "pack",
+					"--skip-update-check",
+					"--exclude", "commands",
+					"testdata/myorb/test")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).NotTo(ContainSubstring("zomg"))
+			})
+
+			It("rejects an invalid pattern", func() {
+				command = exec.Command(pathCLI,
+					"config", "pack",
+					"--skip-update-check",
+					"--exclude", "[",
+					"testdata/myorb/test")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(string(session.Err.Contents())).To(ContainSubstring("invalid exclude pattern ["))
+			})
+		})
+
 		Describe("with a large nested config including rails orb", func() {
 			BeforeEach(func() {
 				var path string = "test-with-large-nested-rails-orb"
@@ -286,29 +344,23 @@ var _ = Describe("Config", func() {
 			})
 		})
 
-		Describe("validating configs with private orbs", func() {
+		Describe("processing configs as JSON", func() {
 			config := "version: 2.1"
-			orgSlug := "circleci"
 			var expReq string
 
 			BeforeEach(func() {
 				command = exec.Command(pathCLI,
-					"config", "validate",
+					"config", "process",
 					"--skip-update-check",
 					"--token", token,
 					"--host", tempSettings.TestServer.URL(),
-					"--org-slug", orgSlug,
+					"--output-format", "json",
 					"-",
 				)
-
-				stdin, err := command.StdinPipe()
-				Expect(err).ToNot(HaveOccurred())
-				_, err = io.WriteString(stdin, config)
-				Expect(err).ToNot(HaveOccurred())
-				stdin.Close()
+				command.Stdin = strings.NewReader(config)
 
 				query := `query ValidateConfig ($config: String!, $pipelineParametersJson: String, $pipelineValues: [StringKeyVal!], $orgSlug: String) {
-			buildConfig(configYaml: $config, pipelineValues: $pipelineValues, orgSlug: $orgSlug) {
+			buildConfig(configYaml: $config, pipelineValues: $pipelineValues) {
 				valid,
 				errors { message },
 				sourceYaml,
@@ -318,7 +370,6 @@ var _ = Describe("Config", func() {
 
 				r := graphql.NewRequest(query)
 				r.Variables["config"] = config
-				r.Variables["orgSlug"] = orgSlug
 				r.Variables["pipelineValues"] = pipeline.PrepareForGraphQL(pipeline.LocalPipelineValues())
 
 				req, err := r.Encode()
@@ -326,12 +377,10 @@ var _ = Describe("Config", func() {
 				expReq = req.String()
 			})
 
-			It("returns an error when validating a config with a private orb", func() {
+			It("prints the processed config as JSON, keeping the key order", func() {
 				expResp := `{
 					"buildConfig": {
-								"errors": [
-									{"message": "permission denied"}
-								]
+						"outputYaml": "version: 2\njobs:\n  test:\n    steps:\n    - run: echo << hi >>\n    parallelism: 2\n  build:\n    steps: [checkout]\n"
 					}
 				}`
 
@@ -343,25 +392,944 @@ var _ = Describe("Config", func() {
 
 				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
 				Expect(err).ShouldNot(HaveOccurred())
-				Eventually(session.Err, time.Second*3).Should(gbytes.Say("Error: permission denied"))
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(Equal(`{
+  "version": 2,
+  "jobs": {
+    "test": {
+      "steps": [
+        {
+          "run": "echo << hi >>"
+        }
+      ],
+      "parallelism": 2
+    },
+    "build": {
+      "steps": [
+        "checkout"
+      ]
+    }
+  }
+}
+`))
+			})
+		})
+
+		Describe("processing configs with name=value pipeline parameters", func() {
+			config := "version: 2.1\nparameters:\n  deploy:\n    type: boolean\n    default: false\n  replicas:\n    type: integer\n    default: 1\n  env:\n    type: string\n    default: staging\n"
+
+			It("infers the type of each parameter", func() {
+				command = exec.Command(pathCLI,
+					"config", "process",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--pipeline-parameters", "deploy=true",
+					"--pipeline-parameters", "replicas=3",
+					"--pipeline-parameters", "env=production",
+					"-",
+				)
+				command.Stdin = strings.NewReader(config)
+
+				query := `query ValidateConfig ($config: String!, $pipelineParametersJson: String, $pipelineValues: [StringKeyVal!], $orgSlug: String) {
+			buildConfig(configYaml: $config, pipelineValues: $pipelineValues, pipelineParametersJson: $pipelineParametersJson) {
+				valid,
+				errors { message },
+				sourceYaml,
+				outputYaml
+			}
+		}`
+
+				r := graphql.NewRequest(query)
+				r.Variables["config"] = config
+				r.Variables["pipelineValues"] = pipeline.PrepareForGraphQL(pipeline.LocalPipelineValues())
+				pipelineParams, err := json.Marshal(pipeline.Parameters{
+					"deploy":   true,
+					"replicas": 3,
+					"env":      "production",
+				})
+				Expect(err).ToNot(HaveOccurred())
+				r.Variables["pipelineParametersJson"] = string(pipelineParams)
+
+				req, err := r.Encode()
+				Expect(err).ShouldNot(HaveOccurred())
+
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  req.String(),
+					Response: `{"buildConfig": {"outputYaml": "version: 2\n"}}`,
+				})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Eventually(session.Out).Should(gbytes.Say("version: 2"))
+			})
+
+			It("rejects a parameter the config doesn't declare", func() {
+				command = exec.Command(pathCLI,
+					"config", "process",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--pipeline-parameters", "deplyo=true",
+					"-",
+				)
+				command.Stdin = strings.NewReader(config)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: unknown pipeline parameter 'deplyo', the config declares: deploy, env, replicas"))
 				Eventually(session).Should(clitest.ShouldFail())
 			})
+		})
 
-			It("returns successfully when validating a config with private orbs", func() {
-				expResp := `{
-					"buildConfig": {}
-				}`
+		Describe("processing configs with a pipeline values file", func() {
+			config := "version: 2.1\njobs:\n  build:\n    docker:\n      - image: cimg/base:stable\n    steps:\n      - run: echo << pipeline.git.branch >>\n"
+
+			It("overrides the local pipeline values, passing unknown ones through", func() {
+				valuesFile := clitest.OpenTmpFile(tempSettings.Home, "values.json")
+				valuesFile.Write([]byte(`{"git": {"branch": "release", "tag": "v1.0.0"}, "number": 42, "trigger.source": "webhook"}`))
+
+				command = exec.Command(pathCLI,
+					"config", "process",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--pipeline-values-file", valuesFile.Path,
+					"-",
+				)
+				command.Stdin = strings.NewReader(config)
+
+				values := pipeline.LocalPipelineValues()
+				values["git.branch"] = "release"
+				values["git.tag"] = "v1.0.0"
+				values["number"] = "42"
+				values["trigger.source"] = "webhook"
+
+				r := graphql.NewRequest(`query ValidateConfig ($config: String!, $pipelineParametersJson: String, $pipelineValues: [StringKeyVal!], $orgSlug: String) {
+			buildConfig(configYaml: $config, pipelineValues: $pipelineValues) {
+				valid,
+				errors { message },
+				sourceYaml,
+				outputYaml
+			}
+		}`)
+				r.Variables["config"] = config
+				r.Variables["pipelineValues"] = pipeline.PrepareForGraphQL(values)
+				req, err := r.Encode()
+				Expect(err).ShouldNot(HaveOccurred())
 
 				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
 					Status:   http.StatusOK,
-					Request:  expReq,
-					Response: expResp,
+					Request:  req.String(),
+					Response: `{"buildConfig": {"outputYaml": "version: 2\n"}}`,
 				})
 
 				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
 				Expect(err).ShouldNot(HaveOccurred())
-				Eventually(session.Out, time.Second*3).Should(gbytes.Say("Config input is valid."))
 				Eventually(session).Should(gexec.Exit(0))
+				Eventually(session.Out).Should(gbytes.Say("version: 2"))
+			})
+
+			It("rejects a file that isn't a JSON object before calling the API", func() {
+				valuesFile := clitest.OpenTmpFile(tempSettings.Home, "values.json")
+				valuesFile.Write([]byte(`{"git": {"branch": "release"`))
+
+				command = exec.Command(pathCLI,
+					"config", "process",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--pipeline-values-file", valuesFile.Path,
+					"-",
+				)
+				command.Stdin = strings.NewReader(config)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: Pipeline values file at .*values.json is not a valid JSON object"))
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+
+		Describe("processing configs with --no-pipeline-values", func() {
+			config := "version: 2.1\nparameters:\n  deploy:\n    type: boolean\n  env:\n    type: string\n    default: staging\njobs:\n  build:\n    docker:\n      - image: cimg/base:stable\n    steps:\n      - run: echo << pipeline.git.branch >> << pipeline.parameters.env >>\n      - run: echo << pipeline.parameters.deploy >> << pipeline.number >>\n# << pipeline.id >>\n"
+
+			It("lists each reference that wasn't given before calling the API", func() {
+				command = exec.Command(pathCLI,
+					"config", "process",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--no-pipeline-values",
+					"-",
+				)
+				command.Stdin = strings.NewReader(config)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(string(session.Err.Contents())).To(ContainSubstring(`Error: the config references pipeline values that weren't given:
+  pipeline.git.branch
+  pipeline.number
+  pipeline.parameters.deploy
+`))
+				Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("only sends the values that were given", func() {
+				valuesFile := clitest.OpenTmpFile(tempSettings.Home, "values.json")
+				valuesFile.Write([]byte(`{"git": {"branch": "main"}, "number": 7}`))
+
+				command = exec.Command(pathCLI,
+					"config", "process",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--no-pipeline-values",
+					"--pipeline-values-file", valuesFile.Path,
+					"--pipeline-parameters", "deploy=true",
+					"-",
+				)
+				command.Stdin = strings.NewReader(config)
+
+				r := graphql.NewRequest(`query ValidateConfig ($config: String!, $pipelineParametersJson: String, $pipelineValues: [StringKeyVal!], $orgSlug: String) {
+			buildConfig(configYaml: $config, pipelineValues: $pipelineValues, pipelineParametersJson: $pipelineParametersJson) {
+				valid,
+				errors { message },
+				sourceYaml,
+				outputYaml
+			}
+		}`)
+				r.Variables["config"] = config
+				r.Variables["pipelineValues"] = pipeline.PrepareForGraphQL(pipeline.Values{"git.branch": "main", "number": "7"})
+				r.Variables["pipelineParametersJson"] = `{"deploy":true}`
+				req, err := r.Encode()
+				Expect(err).ShouldNot(HaveOccurred())
+
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  req.String(),
+					Response: `{"buildConfig": {"outputYaml": "version: 2\n"}}`,
+				})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Eventually(session.Out).Should(gbytes.Say("version: 2"))
+			})
+		})
+
+		Describe("validating configs verbosely", func() {
+			config := "version: 2.1\norbs:\n  node: circleci/node@5\n  slack: circleci/slack@4.1.0\n  local:\n    commands: {}\n"
+
+			It("prints the version each orb resolved to", func() {
+				command = exec.Command(pathCLI,
+					"config", "validate",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--verbose",
+					"-",
+				)
+				command.Stdin = strings.NewReader(config)
+
+				query := `query ValidateConfig ($config: String!, $pipelineParametersJson: String, $pipelineValues: [StringKeyVal!], $orgSlug: String) {
+			buildConfig(configYaml: $config, pipelineValues: $pipelineValues) {
+				valid,
+				errors { message },
+				sourceYaml,
+				outputYaml
+			}
+		}`
+				r := graphql.NewRequest(query)
+				r.Variables["config"] = config
+				r.Variables["pipelineValues"] = pipeline.PrepareForGraphQL(pipeline.LocalPipelineValues())
+				validateReq, err := r.Encode()
+				Expect(err).ShouldNot(HaveOccurred())
+
+				r = graphql.NewRequest(`query($orbVersionRef: String!) {
+			    orbVersion(orbVersionRef: $orbVersionRef) {
+			        id
+			        version
+			    }
+		      }`)
+				r.SetToken(token)
+				r.Variables["orbVersionRef"] = "circleci/node@5"
+				resolveReq, err := r.Encode()
+				Expect(err).ShouldNot(HaveOccurred())
+
+				tempSettings.AppendPostHandler(token,
+					clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  validateReq.String(),
+						Response: `{"buildConfig": {}}`,
+					},
+					clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  resolveReq.String(),
+						Response: `{"orbVersion": {"id": "some-id", "version": "5.0.2"}}`,
+					},
+				)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(Equal(`Config input is valid.
+Resolved orbs:
+  node: circleci/node@5 => circleci/node@5.0.2
+  slack: circleci/slack@4.1.0
+  local: inline orb
+`))
+			})
+		})
+
+		Describe("validating configs with json output", func() {
+			config := "version: 2.1\njobs:\n  build:\n    docker:\n      - image: cimg/base:stable\n    foo: bar\n"
+			var validateReq string
+
+			BeforeEach(func() {
+				command = exec.Command(pathCLI,
+					"config", "validate",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--output-format", "json",
+					"-",
+				)
+				command.Stdin = strings.NewReader(config)
+
+				query := `query ValidateConfig ($config: String!, $pipelineParametersJson: String, $pipelineValues: [StringKeyVal!], $orgSlug: String) {
+			buildConfig(configYaml: $config, pipelineValues: $pipelineValues) {
+				valid,
+				errors { message },
+				sourceYaml,
+				outputYaml
+			}
+		}`
+				r := graphql.NewRequest(query)
+				r.Variables["config"] = config
+				r.Variables["pipelineValues"] = pipeline.PrepareForGraphQL(pipeline.LocalPipelineValues())
+				req, err := r.Encode()
+				Expect(err).ShouldNot(HaveOccurred())
+				validateReq = req.String()
+			})
+
+			It("prints an empty array for a valid config", func() {
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  validateReq,
+					Response: `{"buildConfig": {"valid": true}}`,
+				})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(Equal("[]\n"))
+			})
+
+			It("maps the location of each error to a line and column", func() {
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:  http.StatusOK,
+					Request: validateReq,
+					Response: `{"buildConfig": {"errors": [
+						{"message": "ERROR IN CONFIG FILE:\n[#/jobs/build] only 1 subschema matches out of 2\n1. [#/jobs/build] extraneous key [foo] is not permitted"},
+						{"message": "Cannot find a definition for command named node/install"}
+					]}}`,
+				})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(string(session.Out.Contents())).To(MatchJSON(`[
+					{"severity": "error", "message": "only 1 subschema matches out of 2", "line": 4, "column": 5, "path": "/jobs/build"},
+					{"severity": "error", "message": "extraneous key [foo] is not permitted", "line": 4, "column": 5, "path": "/jobs/build"},
+					{"severity": "error", "message": "Cannot find a definition for command named node/install", "line": null, "column": null, "path": null}
+				]`))
+				Expect(session.Err).To(gbytes.Say("Error: config is invalid, found 3 problem\\(s\\)"))
+			})
+		})
+
+		Describe("validating configs with --exit-zero", func() {
+			config := "version: 2.1\njobs:\n  build:\n    machine:\n      image: ubuntu-1604:202104-01\n"
+			deprecatedResp := `{"buildConfig": {"valid": true, "outputYaml": "version: 2\njobs:\n  build:\n    machine:\n      image: ubuntu-1604:202104-01\n"}}`
+			var validateReq string
+
+			validateCommand := func(args ...string) *exec.Cmd {
+				command := exec.Command(pathCLI, append([]string{
+					"config", "validate",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+				}, append(args, "-")...)...)
+				command.Stdin = strings.NewReader(config)
+				return command
+			}
+
+			BeforeEach(func() {
+				query := `query ValidateConfig ($config: String!, $pipelineParametersJson: String, $pipelineValues: [StringKeyVal!], $orgSlug: String) {
+			buildConfig(configYaml: $config, pipelineValues: $pipelineValues) {
+				valid,
+				errors { message },
+				sourceYaml,
+				outputYaml
+			}
+		}`
+				r := graphql.NewRequest(query)
+				r.Variables["config"] = config
+				r.Variables["pipelineValues"] = pipeline.PrepareForGraphQL(pipeline.LocalPipelineValues())
+				req, err := r.Encode()
+				Expect(err).ShouldNot(HaveOccurred())
+				validateReq = req.String()
+			})
+
+			It("fails on a deprecated image without it", func() {
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  validateReq,
+					Response: deprecatedResp,
+				})
+
+				session, err := gexec.Start(validateCommand(), GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(session.Err).To(gbytes.Say("Error: The config is using a deprecated Linux VM image \\(ubuntu-1604:202104-01\\)"))
+			})
+
+			It("only warns about a deprecated image", func() {
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  validateReq,
+					Response: deprecatedResp,
+				})
+
+				session, err := gexec.Start(validateCommand("--exit-zero"), GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Err).To(gbytes.Say("Warning: The config is using a deprecated Linux VM image \\(ubuntu-1604:202104-01\\)"))
+				Expect(session.Out).To(gbytes.Say("Config input is valid."))
+			})
+
+			It("still fails on errors", func() {
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  validateReq,
+					Response: `{"buildConfig": {"errors": [{"message": "Cannot find a definition for command named node/install"}]}}`,
+				})
+
+				session, err := gexec.Start(validateCommand("--exit-zero"), GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(session.Err).To(gbytes.Say("Error: Cannot find a definition for command named node/install"))
+			})
+
+			It("marks deprecations as warnings in json output", func() {
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  validateReq,
+					Response: deprecatedResp,
+				})
+
+				session, err := gexec.Start(validateCommand("--exit-zero", "--output-format", "json"), GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(ContainSubstring(`"severity": "warning"`))
+			})
+		})
+
+		Describe("validating configs with github error annotations", func() {
+			config := "version: 2.1\njobs:\n  build:\n    docker:\n      - image: cimg/base:stable\n    foo: bar\n"
+			var (
+				configPath  string
+				validateReq string
+			)
+
+			BeforeEach(func() {
+				configFile := clitest.OpenTmpFile(tempSettings.Home, "config.yml")
+				configFile.Write([]byte(config))
+				configPath = configFile.Path
+
+				command = exec.Command(pathCLI,
+					"config", "validate",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--format-errors", "github",
+					configPath,
+				)
+
+				query := `query ValidateConfig ($config: String!, $pipelineParametersJson: String, $pipelineValues: [StringKeyVal!], $orgSlug: String) {
+			buildConfig(configYaml: $config, pipelineValues: $pipelineValues) {
+				valid,
+				errors { message },
+				sourceYaml,
+				outputYaml
+			}
+		}`
+				r := graphql.NewRequest(query)
+				r.Variables["config"] = config
+				r.Variables["pipelineValues"] = pipeline.PrepareForGraphQL(pipeline.LocalPipelineValues())
+				req, err := r.Encode()
+				Expect(err).ShouldNot(HaveOccurred())
+				validateReq = req.String()
+			})
+
+			It("annotates each problem on its line, or on the file", func() {
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:  http.StatusOK,
+					Request: validateReq,
+					Response: `{"buildConfig": {"errors": [
+						{"message": "ERROR IN CONFIG FILE:\n[#/jobs/build] extraneous key [foo] is not permitted"},
+						{"message": "Cannot find a definition for command named node/install, 100% sure"}
+					]}}`,
+				})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(string(session.Out.Contents())).To(Equal(fmt.Sprintf(
+					"::error file=%s,line=4,col=5::extraneous key [foo] is not permitted\n"+
+						"::error file=%s::Cannot find a definition for command named node/install, 100%%25 sure\n",
+					configPath, configPath)))
+				Expect(session.Err).To(gbytes.Say("Error: ERROR IN CONFIG FILE:\n\\[#/jobs/build\\] extraneous key \\[foo\\] is not permitted"))
+			})
+
+			It("prints nothing extra for a valid config", func() {
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  validateReq,
+					Response: `{"buildConfig": {"valid": true}}`,
+				})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(Equal(fmt.Sprintf("Config file at %s is valid.\n", configPath)))
+			})
+
+			It("prints nothing for a valid config with --quiet", func() {
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  validateReq,
+					Response: `{"buildConfig": {"valid": true}}`,
+				})
+				command.Args = append(command.Args, "--quiet")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(BeEmpty())
+			})
+		})
+
+		Describe("validating configs with private orbs", func() {
+			config := "version: 2.1"
+			orgSlug := "circleci"
+			var expReq string
+
+			BeforeEach(func() {
+				command = exec.Command(pathCLI,
+					"config", "validate",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--org-slug", orgSlug,
+					"-",
+				)
+
+				stdin, err := command.StdinPipe()
+				Expect(err).ToNot(HaveOccurred())
+				_, err = io.WriteString(stdin, config)
+				Expect(err).ToNot(HaveOccurred())
+				stdin.Close()
+
+				query := `query ValidateConfig ($config: String!, $pipelineParametersJson: String, $pipelineValues: [StringKeyVal!], $orgSlug: String) {
+			buildConfig(configYaml: $config, pipelineValues: $pipelineValues, orgSlug: $orgSlug) {
+				valid,
+				errors { message },
+				sourceYaml,
+				outputYaml
+			}
+		}`
+
+				r := graphql.NewRequest(query)
+				r.Variables["config"] = config
+				r.Variables["orgSlug"] = orgSlug
+				r.Variables["pipelineValues"] = pipeline.PrepareForGraphQL(pipeline.LocalPipelineValues())
+
+				req, err := r.Encode()
+				Expect(err).ShouldNot(HaveOccurred())
+				expReq = req.String()
+			})
+
+			It("returns an error when validating a config with a private orb", func() {
+				expResp := `{
+					"buildConfig": {
+								"errors": [
+									{"message": "permission denied"}
+								]
+					}
+				}`
+
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  expReq,
+					Response: expResp,
+				})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err, time.Second*3).Should(gbytes.Say("Error: permission denied"))
+				Eventually(session).Should(clitest.ShouldFail())
+			})
+
+			It("returns successfully when validating a config with private orbs", func() {
+				expResp := `{
+					"buildConfig": {}
+				}`
+
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  expReq,
+					Response: expResp,
+				})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Out, time.Second*3).Should(gbytes.Say("Config input is valid."))
+				Eventually(session).Should(gexec.Exit(0))
+			})
+		})
+
+		Describe("validating configs offline", func() {
+			var config *clitest.TmpFile
+
+			BeforeEach(func() {
+				config = clitest.OpenTmpFile(tempSettings.Home, "config.yml")
+
+				command = exec.Command(pathCLI,
+					"config", "validate",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+					"--offline",
+					config.Path,
+				)
+			})
+
+			AfterEach(func() {
+				config.Close()
+			})
+
+			It("validates the config locally without calling the API", func() {
+				config.Write([]byte(`version: 2.1
+jobs:
+  build:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - checkout
+workflows:
+  main:
+    jobs:
+      - build
+`))
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out).To(gbytes.Say("YAML syntax: ok"))
+				Expect(session.Out).To(gbytes.Say("Skipped checks"))
+				Expect(session.Out).To(gbytes.Say("orb resolution"))
+				Expect(session.Out).To(gbytes.Say(fmt.Sprintf("Config file at %s is valid, orb resolution was skipped.", config.Path)))
+				Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("fails on malformed YAML", func() {
+				config.Write([]byte("version: 2.1\njobs: [\n"))
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(session.Out).To(gbytes.Say("YAML syntax: failed"))
+				Expect(session.Err).To(gbytes.Say(fmt.Sprintf("Error: Config at %s is not valid YAML", config.Path)))
+			})
+
+			It("keeps its own --config for the pipeline config, apart from --cli-config", func() {
+				config.Write([]byte("version: 2.1\n"))
+				cliConfig := filepath.Join(tempSettings.Home, "other-cli.yml")
+				Expect(ioutil.WriteFile(cliConfig, []byte("profiles:\n  staging:\n    host: https://staging.example.com\n"), 0600)).To(Succeed())
+
+				command = commandWithHome(pathCLI, tempSettings.Home,
+					"config", "validate",
+					"--skip-update-check",
+					"--offline",
+					"--config", config.Path,
+					"--cli-config", cliConfig,
+					"--profile", "staging",
+				)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out).To(gbytes.Say(fmt.Sprintf("Config file at %s is valid, orb resolution was skipped.", config.Path)))
+			})
+
+			It("reads the config from stdin when no path is given", func() {
+				command = exec.Command(pathCLI,
+					"config", "validate",
+					"--skip-update-check",
+					"--offline",
+				)
+				command.Stdin = strings.NewReader("version: 2.1\n")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out).To(gbytes.Say("Config input is valid, orb resolution was skipped."))
+			})
+
+			It("validates the config again each time it's saved with --watch", func() {
+				config.Write([]byte("version: 2.1\n"))
+				command = exec.Command(pathCLI,
+					"config", "validate",
+					"--skip-update-check",
+					"--offline",
+					"--watch",
+					config.Path,
+				)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say(`\[\d\d:\d\d:\d\d\] Config is valid.`))
+				Eventually(session.Err).Should(gbytes.Say(`Watching .* for changes`))
+
+				// An invalid config is reported without stopping the watch
+				Expect(ioutil.WriteFile(config.Path, []byte("version: 2.1\njobs: [\n"), 0600)).To(Succeed())
+				Eventually(session.Err).Should(gbytes.Say(`\[\d\d:\d\d:\d\d\] Config is invalid: Config at .* is not valid YAML`))
+				Consistently(session).ShouldNot(gexec.Exit())
+
+				// Editors that save by renaming a new file over the config
+				replacement := filepath.Join(tempSettings.Home, "config.yml.new")
+				Expect(ioutil.WriteFile(replacement, []byte("version: 2.1\n"), 0600)).To(Succeed())
+				Expect(os.Rename(replacement, config.Path)).To(Succeed())
+				Eventually(session.Err).Should(gbytes.Say(`Config is valid.`))
+
+				session.Interrupt()
+				Eventually(session.Err).Should(gbytes.Say("Stopped watching."))
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("reports stdin as the source of errors", func() {
+				command = exec.Command(pathCLI,
+					"config", "validate",
+					"--skip-update-check",
+					"--offline",
+					"-",
+				)
+				command.Stdin = strings.NewReader("version: [\n")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(session.Err).To(gbytes.Say("Error: Config at <stdin> is not valid YAML"))
+			})
+
+			It("fails when the version is missing", func() {
+				config.Write([]byte("jobs: {}\n"))
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(session.Out).To(gbytes.Say("schema config-schema.json: failed"))
+				Expect(session.Err).To(gbytes.Say("Error: Config on line 1: missing properties: 'version'"))
+			})
+
+			It("allows top-level keys that hold anchors", func() {
+				config.Write([]byte(`version: 2.1
+references:
+  docker: &docker
+    - image: cimg/base:stable
+jobs:
+  build:
+    docker: *docker
+    steps:
+      - checkout
+`))
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out).To(gbytes.Say("schema config-schema.json: ok"))
+			})
+
+			It("fails when a job isn't a map", func() {
+				config.Write([]byte("version: 2.1\njobs:\n  build:\n    - checkout\n"))
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(session.Err).To(gbytes.Say("Error: jobs.build on line 4: expected object, but got array"))
+			})
+
+			Describe("with --schema", func() {
+				var schema *clitest.TmpFile
+
+				BeforeEach(func() {
+					schema = clitest.OpenTmpFile(tempSettings.Home, "schema.json")
+					schema.Write([]byte(`{
+  "type": "object",
+  "required": ["version", "jobs"],
+  "properties": {
+    "version": {"enum": [2.1]},
+    "jobs": {"type": "object", "additionalProperties": {"$ref": "#/definitions/job"}}
+  },
+  "definitions": {
+    "job": {
+      "type": "object",
+      "required": ["resource_class"],
+      "properties": {"resource_class": {"type": "string", "pattern": "^(small|medium)$"}}
+    }
+  }
+}`))
+					command.Args = append(command.Args, "--schema", schema.Path)
+				})
+
+				AfterEach(func() {
+					schema.Close()
+				})
+
+				It("checks the config against the schema in place of the bundled schema", func() {
+					config.Write([]byte("version: 2.1\njobs:\n  build:\n    resource_class: small\n"))
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session).Should(gexec.Exit(0))
+					Expect(session.Out).To(gbytes.Say(fmt.Sprintf("schema %s: ok", schema.Path)))
+					Expect(string(session.Out.Contents())).ToNot(ContainSubstring("config-schema.json"))
+				})
+
+				It("reports where the config doesn't match the schema", func() {
+					config.Write([]byte("version: 2.1\njobs:\n  build:\n    docker: []\n  lint:\n    resource_class: large\n"))
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session).Should(clitest.ShouldFail())
+					Expect(session.Out).To(gbytes.Say(fmt.Sprintf("schema %s: failed", schema.Path)))
+					Expect(session.Err).To(gbytes.Say("Error: jobs.build on line 4: missing properties: 'resource_class'"))
+				})
+
+				It("fails before validating when the schema is invalid", func() {
+					Expect(ioutil.WriteFile(schema.Path, []byte(`{"properties": {"version": {"type": "decimal"}}}`), 0600)).To(Succeed())
+					config.Write([]byte("version: 2.1\n"))
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session).Should(clitest.ShouldFail())
+					Expect(session.Err).To(gbytes.Say(fmt.Sprintf("Error: invalid schema %s: #/properties/version/type: value must be one of ", schema.Path)))
+					Expect(session.Out.Contents()).To(BeEmpty())
+				})
+
+				It("checks the schema before asking the API when not offline", func() {
+					command = exec.Command(pathCLI,
+						"config", "validate",
+						"--skip-update-check",
+						"--host", tempSettings.TestServer.URL(),
+						"--schema", schema.Path,
+						config.Path,
+					)
+					config.Write([]byte("version: 2.1\njobs:\n  lint:\n    resource_class: large\n"))
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session).Should(clitest.ShouldFail())
+					Expect(session.Err).To(gbytes.Say(fmt.Sprintf("Error: Config doesn't match the schema %s: jobs.lint.resource_class on line 4: does not match pattern", schema.Path)))
+					Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+				})
+			})
+		})
+
+		Describe("migrating configs", func() {
+			var config *clitest.TmpFile
+			source := `version: 2.1
+jobs:
+  build:
+    machine: true
+    steps:
+      - checkout
+      - deploy:
+          command: ./deploy.sh
+  test:
+    parallelism: 4
+    docker:
+      - image: circleci/node:14
+    steps:
+      - deploy:
+          command: ./deploy.sh
+workflows:
+  version: 2
+  main:
+    jobs:
+      - build
+`
+
+			BeforeEach(func() {
+				config = clitest.OpenTmpFile(tempSettings.Home, "config.yml")
+				config.Write([]byte(source))
+			})
+
+			AfterEach(func() {
+				config.Close()
+			})
+
+			It("applies the safe fixes and reports the others", func() {
+				command = exec.Command(pathCLI,
+					"config", "migrate",
+					"--skip-update-check",
+					config.Path,
+				)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(Equal(fmt.Sprintf(`Migrated %s:
+  line 7: replaced the deprecated deploy step of job 'build' with a run step
+  line 17: removed the workflows version, which 2.1 configs don't use
+Left unchanged, as these can't be fixed safely:
+  line 4: `+"`machine: true`"+` uses a deprecated default image, set a machine image such as ubuntu-2004:current instead
+  line 12: the legacy convenience image circleci/node:14 is deprecated, use the cimg/ image that replaces it instead
+  line 14: the deploy step of job 'test' is deprecated, but it only runs on one of the job's parallel containers so can't be replaced with a run step
+Wrote the migrated config to %s.
+`, config.Path, config.Path)))
+
+				migrated, err := ioutil.ReadFile(config.Path)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(string(migrated)).To(Equal(strings.Replace(
+					strings.Replace(source, "      - deploy:\n          command: ./deploy.sh\n  test:", "      - run:\n          command: ./deploy.sh\n  test:", 1),
+					"  version: 2\n", "", 1)))
+			})
+
+			It("prints a diff without changing the config with --dry-run", func() {
+				command = exec.Command(pathCLI,
+					"config", "migrate",
+					"--skip-update-check",
+					"--dry-run",
+					config.Path,
+				)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out).To(gbytes.Say(fmt.Sprintf(`--- %s\n\+\+\+ %s\n`, config.Path, config.Path)))
+				Expect(session.Out).To(gbytes.Say(`-      - deploy:\n\+      - run:\n`))
+				Expect(session.Out).To(gbytes.Say(`-  version: 2\n`))
+				Expect(session.Out).To(gbytes.Say("Dry run, .* was not changed."))
+
+				unchanged, err := ioutil.ReadFile(config.Path)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(string(unchanged)).To(Equal(source))
 			})
 		})
 	})
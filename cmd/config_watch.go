@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// watchConfig runs validate each time the config at path is saved, until
+// interrupted, printing a timestamped result for each run to stderr. An
+// invalid config is reported without stopping the watch.
+//
+// The directory of the config is watched rather than the file itself, as
+// many editors save by writing a new file and renaming it over the old one,
+// which would otherwise end the watch of the original file.
+func watchConfig(path string, validate func() error) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "Unable to watch for changes")
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return errors.Wrapf(err, "Unable to watch %s", path)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	revalidateConfig(validate)
+	fmt.Fprintf(os.Stderr, "Watching %s for changes, press Ctrl-C to stop.\n", path)
+
+	target := filepath.Clean(path)
+	var debounce <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != target || event.Op == fsnotify.Chmod {
+				continue
+			}
+			debounce = time.After(watchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "[%s] Error watching for changes: %s\n", watchTimestamp(), err)
+
+		case <-debounce:
+			debounce = nil
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				// Removed, and not yet replaced by the editor saving it
+				continue
+			}
+			revalidateConfig(validate)
+
+		case <-interrupt:
+			fmt.Fprintln(os.Stderr, "Stopped watching.")
+			return nil
+		}
+	}
+}
+
+// revalidateConfig runs validate, printing whether the config passed. Errors
+// are printed rather than returned.
+func revalidateConfig(validate func() error) {
+	if err := validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] Config is invalid: %s\n", watchTimestamp(), err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[%s] Config is valid.\n", watchTimestamp())
+}
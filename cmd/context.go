@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 
 func newContextCommand(config *settings.Config) *cobra.Command {
 	var contextClient api.ContextInterface
+	orgOpts := orgOptions{cfg: config}
 
 	initClient := func(cmd *cobra.Command, args []string) (e error) {
 		contextClient, e = api.NewContextRestClient(*config)
@@ -39,35 +41,122 @@ func newContextCommand(config *settings.Config) *cobra.Command {
 		Use:   "context",
 		Short: "Contexts provide a mechanism for securing and sharing environment variables across projects. The environment variables are defined as name/value pairs and are injected at runtime.",
 	}
+	addOrgFlags(command.PersistentFlags(), &orgOpts)
 
+	var listJSON bool
 	listCommand := &cobra.Command{
 		Short:   "List all contexts",
 		Use:     "list <vcs-type> <org-name>",
 		PreRunE: initClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return listContexts(contextClient, args[0], args[1])
+			org, _, err := orgOpts.organization(args, 0)
+			if err != nil {
+				return err
+			}
+			return listContexts(contextClient, org.VCSType, org.Name, listJSON)
 		},
-		Args: cobra.ExactArgs(2),
+		Args: orgArgs(&orgOpts, 0),
 	}
+	listCommand.Flags().BoolVar(&listJSON, "json", false, "print each context's id, name and created_at as json instead of a table")
+	addFieldFlag(listCommand)
 
+	var showJSON bool
 	showContextCommand := &cobra.Command{
 		Short:   "Show a context",
+		Long:    "Show the names of the environment variables stored in a context, and when they were created. Values are write-only, so they are never shown.",
 		Use:     "show <vcs-type> <org-name> <context-name>",
 		PreRunE: initClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return showContext(contextClient, args[0], args[1], args[2])
+			org, args, err := orgOpts.organization(args, 0)
+			if err != nil {
+				return err
+			}
+			return showContext(contextClient, org.VCSType, org.Name, args[0], showJSON)
 		},
-		Args: cobra.ExactArgs(3),
+		Args: orgArgs(&orgOpts, 1),
 	}
+	showContextCommand.Flags().BoolVar(&showJSON, "json", false, "print the context's id and name, and the variable and created_at of each of its environment variables, as json instead of a table")
+	addFieldFlag(showContextCommand)
 
+	var fromFile string
 	storeCommand := &cobra.Command{
-		Short:   "Store a new environment variable in the named context. The value is read from stdin.",
+		Short:   "Store a new environment variable in the named context. The value is read from stdin, or from the file given with --from-file.",
 		Use:     "store-secret <vcs-type> <org-name> <context-name> <secret name>",
 		PreRunE: initClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return storeEnvVar(contextClient, args[0], args[1], args[2], args[3])
+			org, args, err := orgOpts.organization(args, 0)
+			if err != nil {
+				return err
+			}
+			return storeEnvVar(contextClient, org.VCSType, org.Name, args[0], args[1], fromFile)
 		},
-		Args: cobra.ExactArgs(4),
+		Args: orgArgs(&orgOpts, 2),
+	}
+	storeCommand.Flags().StringVar(&fromFile, "from-file", "", "read the secret value from a file (use \"-\" for STDIN); the contents are stored unmodified")
+
+	var diffOtherVcs, diffOtherOrg string
+	var diffJSON bool
+	diffOtherOrgOpts := orgOptions{cfg: config}
+	diffCommand := &cobra.Command{
+		Short: "Compare the environment variable names of two contexts",
+		Long: strings.Join([]string{
+			"Compare the environment variable names of two contexts. Values are write-only, so only names are compared.",
+			"", // purposeful new-line
+			"Both contexts belong to the given organization, unless --other-org (and --other-vcs-type) or --other-org-id is given for the second one.",
+		}, "\n"),
+		Use:     "diff <vcs-type> <org-name> <context-name> <other-context-name>",
+		PreRunE: initClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			org, args, err := orgOpts.organization(args, 0)
+			if err != nil {
+				return err
+			}
+			otherVcs, otherOrg := org.VCSType, org.Name
+			if diffOtherOrgOpts.given() {
+				if diffOtherVcs != "" || diffOtherOrg != "" {
+					return errors.New("--other-org-id can't be combined with --other-org or --other-vcs-type")
+				}
+				other, _, err := diffOtherOrgOpts.organization(nil, 0)
+				if err != nil {
+					return err
+				}
+				otherVcs, otherOrg = other.VCSType, other.Name
+			}
+			if diffOtherVcs != "" {
+				otherVcs = diffOtherVcs
+			}
+			if diffOtherOrg != "" {
+				otherOrg = diffOtherOrg
+			}
+			return diffContexts(contextClient, org.VCSType, org.Name, args[0], otherVcs, otherOrg, args[1], diffJSON)
+		},
+		Args: orgArgs(&orgOpts, 2),
+	}
+	diffCommand.Flags().StringVar(&diffOtherOrg, "other-org", "", "the organization of the second context, when it differs")
+	diffCommand.Flags().StringVar(&diffOtherVcs, "other-vcs-type", "", "the VCS provider of the second context's organization, when it differs")
+	diffCommand.Flags().StringVar(&diffOtherOrgOpts.id, "other-org-id", "", "the ID of the organization of the second context, in place of --other-org and --other-vcs-type")
+	diffCommand.Flags().BoolVar(&diffJSON, "json", false, "print output as json instead of a table")
+	addFieldFlag(diffCommand)
+
+	var envFile string
+	var dryRun bool
+	importCommand := &cobra.Command{
+		Short:   "Store every variable of a dotenv file as an environment variable in the named context",
+		Use:     "import <vcs-type> <org-name> <context-name>",
+		PreRunE: initClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			org, args, err := orgOpts.organization(args, 0)
+			if err != nil {
+				return err
+			}
+			return importEnvVars(contextClient, org.VCSType, org.Name, args[0], envFile, dryRun)
+		},
+		Args: orgArgs(&orgOpts, 1),
+	}
+	importCommand.Flags().StringVar(&envFile, "from-env-file", "", "path to a dotenv file of KEY=VALUE lines")
+	importCommand.Flags().BoolVar(&dryRun, "dry-run", false, "list the variables that would be stored without storing them")
+	if err := importCommand.MarkFlagRequired("from-env-file"); err != nil {
+		panic(err)
 	}
 
 	removeCommand := &cobra.Command{
@@ -75,20 +164,56 @@ func newContextCommand(config *settings.Config) *cobra.Command {
 		Use:     "remove-secret <vcs-type> <org-name> <context-name> <secret name>",
 		PreRunE: initClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return removeEnvVar(contextClient, args[0], args[1], args[2], args[3])
+			org, args, err := orgOpts.organization(args, 0)
+			if err != nil {
+				return err
+			}
+			return removeEnvVar(contextClient, org.VCSType, org.Name, args[0], args[1])
 		},
-		Args: cobra.ExactArgs(4),
+		Args: orgArgs(&orgOpts, 2),
 	}
 
+	var createOrgIDs []string
+	var createJSON bool
 	createContextCommand := &cobra.Command{
-		Short:   "Create a new context",
+		Short: "Create a new context",
+		Long: strings.Join([]string{
+			"Create a new context.",
+			"", // purposeful new-line
+			"Repeat --org-id to create the context in each of several organizations. Every organization is tried, and the command fails if the context couldn't be created in any of them.",
+			"",
+			fmt.Sprintf("When a context of the same name already exists, the command exits with %d, so that scripts can treat it as done.", exitContextExists),
+		}, "\n"),
 		Use:     "create <vcs-type> <org-name> <context-name>",
 		PreRunE: initClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return createContext(contextClient, args[0], args[1], args[2])
+			if len(createOrgIDs) > 1 {
+				return createContextInOrgs(contextClient, config, createOrgIDs, args[0])
+			}
+			org, args, err := orgOpts.organization(args, 0)
+			if err != nil {
+				return err
+			}
+			return createContext(contextClient, org.VCSType, org.Name, args[0], createJSON)
+		},
+		Args: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case len(createOrgIDs) > 1 && orgOpts.slug != "":
+				return errors.New("--org-slug can't be combined with more than one --org-id")
+			case len(createOrgIDs) > 1 && createJSON:
+				return errors.New("--json can't be combined with more than one --org-id")
+			case len(createOrgIDs) > 1:
+				return cobra.ExactArgs(1)(cmd, args)
+			case len(createOrgIDs) == 1:
+				orgOpts.id = createOrgIDs[0]
+			}
+			return orgArgs(&orgOpts, 1)(cmd, args)
 		},
-		Args: cobra.ExactArgs(3),
 	}
+	// This --org-id takes the place of the one shared by the context commands
+	createContextCommand.Flags().StringArrayVar(&createOrgIDs, "org-id", nil, "the ID of the organization, in place of <vcs-type> <org-name>; repeat it to create the context in each of several organizations")
+	createContextCommand.Flags().BoolVar(&createJSON, "json", false, "print the new context's id, name and created_at as json")
+	addFieldFlag(createContextCommand)
 
 	force := false
 	deleteContextCommand := &cobra.Command{
@@ -96,16 +221,37 @@ func newContextCommand(config *settings.Config) *cobra.Command {
 		Use:     "delete <vcs-type> <org-name> <context-name>",
 		PreRunE: initClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return deleteContext(contextClient, force, args[0], args[1], args[2])
+			org, args, err := orgOpts.organization(args, 0)
+			if err != nil {
+				return err
+			}
+			return deleteContext(contextClient, force, org.VCSType, org.Name, args[0])
 		},
-		Args: cobra.ExactArgs(3),
+		Args: orgArgs(&orgOpts, 1),
 	}
 
 	deleteContextCommand.Flags().BoolVarP(&force, "force", "f", false, "Delete the context without asking for confirmation.")
+	deleteContextCommand.Flags().BoolVar(&force, "no-prompt", false, "The same as --force.")
+
+	// Suggest the org's context names when completing a <context-name>
+	completionClient := func(cmd *cobra.Command, args []string) (api.ContextInterface, error) {
+		err := initClient(cmd, args)
+		return contextClient, err
+	}
+	listCommand.ValidArgsFunction = completeContextArgs(completionClient)
+	showContextCommand.ValidArgsFunction = completeContextArgs(completionClient, 2)
+	storeCommand.ValidArgsFunction = completeContextArgs(completionClient, 2)
+	importCommand.ValidArgsFunction = completeContextArgs(completionClient, 2)
+	diffCommand.ValidArgsFunction = completeContextArgs(completionClient, 2, 3)
+	removeCommand.ValidArgsFunction = completeContextArgs(completionClient, 2)
+	createContextCommand.ValidArgsFunction = completeContextArgs(completionClient)
+	deleteContextCommand.ValidArgsFunction = completeContextArgs(completionClient, 2)
 
 	command.AddCommand(listCommand)
 	command.AddCommand(showContextCommand)
 	command.AddCommand(storeCommand)
+	command.AddCommand(importCommand)
+	command.AddCommand(diffCommand)
 	command.AddCommand(removeCommand)
 	command.AddCommand(createContextCommand)
 	command.AddCommand(deleteContextCommand)
@@ -113,13 +259,27 @@ func newContextCommand(config *settings.Config) *cobra.Command {
 	return command
 }
 
-func listContexts(contextClient api.ContextInterface, vcs, org string) error {
+func listContexts(contextClient api.ContextInterface, vcs, org string, asJSON bool) error {
 	contexts, err := contextClient.Contexts(vcs, org)
 
 	if err != nil {
 		return err
 	}
 
+	if asJSON {
+		// An org without contexts is printed as [], rather than null
+		list := []api.Context{}
+		if contexts != nil {
+			list = append(list, *contexts...)
+		}
+		contextsJSON, err := marshalJSON(list)
+		if err != nil {
+			return errors.Wrap(err, "Failed to convert to JSON")
+		}
+		fmt.Println(string(contextsJSON))
+		return nil
+	}
+
 	table := tablewriter.NewWriter(os.Stdout)
 
 	table.SetHeader([]string{"Provider", "Organization", "Name", "Created At"})
@@ -137,8 +297,15 @@ func listContexts(contextClient api.ContextInterface, vcs, org string) error {
 	return nil
 }
 
-func showContext(client api.ContextInterface, vcsType, orgName, contextName string) error {
-	context, err := client.ContextByName(vcsType, orgName, contextName)
+// contextVariableJSON is an environment variable of a context, as printed by
+// `context show --json`.
+type contextVariableJSON struct {
+	Variable  string    `json:"variable"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func showContext(client api.ContextInterface, vcsType, orgName, contextName string, asJSON bool) error {
+	context, err := uniqueContextByName(client, vcsType, orgName, contextName)
 	if err != nil {
 		return err
 	}
@@ -147,23 +314,81 @@ func showContext(client api.ContextInterface, vcsType, orgName, contextName stri
 		return err
 	}
 
+	if asJSON {
+		shown := struct {
+			ID        string                `json:"id"`
+			Name      string                `json:"name"`
+			Variables []contextVariableJSON `json:"variables"`
+		}{ID: context.ID, Name: context.Name, Variables: []contextVariableJSON{}}
+		for _, envVar := range *envVars {
+			shown.Variables = append(shown.Variables, contextVariableJSON{Variable: envVar.Variable, CreatedAt: envVar.CreatedAt})
+		}
+		shownJSON, err := marshalJSON(shown)
+		if err != nil {
+			return errors.Wrap(err, "Failed to convert to JSON")
+		}
+		fmt.Println(string(shownJSON))
+		return nil
+	}
+
 	fmt.Printf("Context: %s\n", context.Name)
 
 	table := tablewriter.NewWriter(os.Stdout)
 
-	table.SetHeader([]string{"Environment Variable", "Value"})
+	table.SetHeader([]string{"Environment Variable", "Value", "Created At"})
 
 	for _, envVar := range *envVars {
-		table.Append([]string{envVar.Variable, "••••"})
+		table.Append([]string{envVar.Variable, "••••", envVar.CreatedAt.Format(time.RFC3339)})
 	}
 	table.Render()
 
 	return nil
 }
 
-func readSecretValue() (string, error) {
-	stat, _ := os.Stdin.Stat()
-	if (stat.Mode() & os.ModeCharDevice) == 0 {
+// uniqueContextByName finds the context of the organization with the given
+// name, which mustn't be shared by any other of its contexts.
+func uniqueContextByName(client api.ContextInterface, vcsType, orgName, contextName string) (*api.Context, error) {
+	contexts, err := client.Contexts(vcsType, orgName)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []api.Context
+	for _, context := range *contexts {
+		if context.Name == contextName {
+			found = append(found, context)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no context named %s was found in %s/%s", contextName, vcsType, orgName)
+	case 1:
+		return &found[0], nil
+	default:
+		ids := make([]string, len(found))
+		for i, context := range found {
+			ids[i] = context.ID
+		}
+		return nil, fmt.Errorf("%d contexts are named %s in %s/%s, with the IDs %s", len(found), contextName, vcsType, orgName, strings.Join(ids, ", "))
+	}
+}
+
+// readSecretValue reads the secret from the file at path, or from stdin when
+// path is "-". Without a path the secret is read from stdin when it's piped,
+// and otherwise the user is prompted for it.
+func readSecretValue(path string) (string, error) {
+	switch path {
+	case "":
+	case "-":
+		bytes, err := ioutil.ReadAll(os.Stdin)
+		return string(bytes), err
+	default:
+		bytes, err := ioutil.ReadFile(path)
+		return string(bytes), err
+	}
+
+	if stdinIsPiped() {
 		bytes, err := ioutil.ReadAll(os.Stdin)
 		return string(bytes), err
 	} else {
@@ -174,9 +399,62 @@ func readSecretValue() (string, error) {
 	}
 }
 
-func createContext(client api.ContextInterface, vcsType, orgName, contextName string) error {
-	err := client.CreateContext(vcsType, orgName, contextName)
-	return err
+// exitContextExists is the exit code of `context create` when the context
+// already exists.
+const exitContextExists = 3
+
+func createContext(client api.ContextInterface, vcsType, orgName, contextName string, asJSON bool) error {
+	if err := client.CreateContext(vcsType, orgName, contextName); err != nil {
+		// The REST and GraphQL APIs report a duplicate name differently, so
+		// check for the context rather than the message
+		if _, lookupErr := client.ContextByName(vcsType, orgName, contextName); lookupErr == nil {
+			return &exitCodeError{
+				error: fmt.Errorf("context %s already exists in %s/%s", contextName, vcsType, orgName),
+				code:  exitContextExists,
+			}
+		}
+		return err
+	}
+	if !asJSON {
+		return nil
+	}
+
+	// The GraphQL API doesn't return the new context, so look it up
+	context, err := client.ContextByName(vcsType, orgName, contextName)
+	if err != nil {
+		return errors.Wrap(err, "Created the context, but failed to look it up")
+	}
+	contextJSON, err := marshalJSON(context)
+	if err != nil {
+		return errors.Wrap(err, "Failed to convert to JSON")
+	}
+	fmt.Println(string(contextJSON))
+	return nil
+}
+
+// createContextInOrgs creates the context in each organization, carrying on
+// past the ones it fails for, and fails when any of them did.
+func createContextInOrgs(client api.ContextInterface, config *settings.Config, orgIDs []string, contextName string) error {
+	failed := 0
+	for _, id := range orgIDs {
+		opts := orgOptions{cfg: config, id: id}
+		org, _, err := opts.organization(nil, 0)
+		if err == nil {
+			err = createContext(client, org.VCSType, org.Name, contextName, false)
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "Failed to create context %s in the organization %s: %s\n", contextName, id, err)
+			continue
+		}
+		infof("Created context %s in %s/%s (%s).\n", contextName, org.VCSType, org.Name, id)
+	}
+
+	infof("Created context %s in %d of %d organizations.\n", contextName, len(orgIDs)-failed, len(orgIDs))
+	if failed > 0 {
+		return fmt.Errorf("failed to create context %s in %d of %d organizations", contextName, failed, len(orgIDs))
+	}
+	return nil
 }
 
 func removeEnvVar(client api.ContextInterface, vcsType, orgName, contextName, varName string) error {
@@ -187,16 +465,19 @@ func removeEnvVar(client api.ContextInterface, vcsType, orgName, contextName, va
 	return client.DeleteEnvironmentVariable(context.ID, varName)
 }
 
-func storeEnvVar(client api.ContextInterface, vcsType, orgName, contextName, varName string) error {
+func storeEnvVar(client api.ContextInterface, vcsType, orgName, contextName, varName, fromFile string) error {
 
 	context, err := client.ContextByName(vcsType, orgName, contextName)
 
 	if err != nil {
 		return err
 	}
-	secretValue, err := readSecretValue()
+	secretValue, err := readSecretValue(fromFile)
 
 	if err != nil {
+		if fromFile != "" && fromFile != "-" {
+			return errors.Wrapf(err, "Failed to read secret value from %s", fromFile)
+		}
 		return errors.Wrap(err, "Failed to read secret value from stdin")
 	}
 
@@ -204,6 +485,196 @@ func storeEnvVar(client api.ContextInterface, vcsType, orgName, contextName, var
 	return err
 }
 
+// An envFileEntry is a single KEY=VALUE pair from a dotenv file.
+type envFileEntry struct {
+	Key   string
+	Value string
+}
+
+// parseEnvFile parses dotenv style KEY=VALUE lines, skipping blank lines and
+// comments. Lines may start with `export` and values may be quoted.
+func parseEnvFile(contents string) ([]envFileEntry, error) {
+	var entries []envFileEntry
+	for i, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		parts := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid line %d, expected KEY=VALUE", i+1)
+		}
+
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 {
+			switch {
+			case value[0] == '"' && value[len(value)-1] == '"':
+				value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+			case value[0] == '\'' && value[len(value)-1] == '\'':
+				value = value[1 : len(value)-1]
+			}
+		}
+		entries = append(entries, envFileEntry{Key: key, Value: value})
+	}
+	return entries, nil
+}
+
+func importEnvVars(client api.ContextInterface, vcsType, orgName, contextName, envFile string, dryRun bool) error {
+	contents, err := ioutil.ReadFile(envFile)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read %s", envFile)
+	}
+	entries, err := parseEnvFile(string(contents))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to parse %s", envFile)
+	}
+
+	context, err := client.ContextByName(vcsType, orgName, contextName)
+	if err != nil {
+		return err
+	}
+	envVars, err := client.EnvironmentVariables(context.ID)
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for _, envVar := range *envVars {
+		existing[envVar.Variable] = true
+	}
+
+	created, updated := 0, 0
+	for _, entry := range entries {
+		action := "create"
+		if existing[entry.Key] {
+			action = "update"
+		}
+
+		if dryRun {
+			fmt.Printf("Would %s %s\n", action, entry.Key)
+		} else if err := client.CreateEnvironmentVariable(context.ID, entry.Key, entry.Value); err != nil {
+			return errors.Wrapf(err, "Failed to store %s", entry.Key)
+		}
+
+		if action == "update" {
+			updated++
+		} else {
+			created++
+		}
+		existing[entry.Key] = true
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: would create %d and update %d environment variables in context %s.\n", created, updated, context.Name)
+	} else {
+		infof("Created %d and updated %d environment variables in context %s.\n", created, updated, context.Name)
+	}
+	return nil
+}
+
+// A contextDiff lists which environment variable names two contexts share.
+type contextDiff struct {
+	First        string   `json:"first"`
+	Second       string   `json:"second"`
+	OnlyInFirst  []string `json:"only_in_first"`
+	OnlyInSecond []string `json:"only_in_second"`
+	InBoth       []string `json:"in_both"`
+}
+
+func contextVariableNames(client api.ContextInterface, vcsType, orgName, contextName string) (map[string]bool, error) {
+	context, err := client.ContextByName(vcsType, orgName, contextName)
+	if err != nil {
+		return nil, err
+	}
+	envVars, err := client.EnvironmentVariables(context.ID)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, envVar := range *envVars {
+		names[envVar.Variable] = true
+	}
+	return names, nil
+}
+
+func compareContexts(client api.ContextInterface, vcsType, orgName, contextName, otherVcsType, otherOrgName, otherContextName string) (*contextDiff, error) {
+	first, err := contextVariableNames(client, vcsType, orgName, contextName)
+	if err != nil {
+		return nil, err
+	}
+	second, err := contextVariableNames(client, otherVcsType, otherOrgName, otherContextName)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := contextDiff{
+		First:        contextName,
+		Second:       otherContextName,
+		OnlyInFirst:  []string{},
+		OnlyInSecond: []string{},
+		InBoth:       []string{},
+	}
+	for name := range first {
+		if second[name] {
+			diff.InBoth = append(diff.InBoth, name)
+		} else {
+			diff.OnlyInFirst = append(diff.OnlyInFirst, name)
+		}
+	}
+	for name := range second {
+		if !first[name] {
+			diff.OnlyInSecond = append(diff.OnlyInSecond, name)
+		}
+	}
+	sort.Strings(diff.OnlyInFirst)
+	sort.Strings(diff.OnlyInSecond)
+	sort.Strings(diff.InBoth)
+
+	return &diff, nil
+}
+
+func diffContexts(client api.ContextInterface, vcsType, orgName, contextName, otherVcsType, otherOrgName, otherContextName string, asJSON bool) error {
+	diff, err := compareContexts(client, vcsType, orgName, contextName, otherVcsType, otherOrgName, otherContextName)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		diffJSON, err := marshalJSON(diff)
+		if err != nil {
+			return errors.Wrap(err, "Failed to convert to JSON")
+		}
+		fmt.Println(string(diffJSON))
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Environment Variable", diff.First, diff.Second})
+	rows := map[string][]string{}
+	for _, name := range diff.OnlyInFirst {
+		rows[name] = []string{name, "yes", "no"}
+	}
+	for _, name := range diff.OnlyInSecond {
+		rows[name] = []string{name, "no", "yes"}
+	}
+	for _, name := range diff.InBoth {
+		rows[name] = []string{name, "yes", "yes"}
+	}
+	names := make([]string, 0, len(rows))
+	for name := range rows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		table.Append(rows[name])
+	}
+	table.Render()
+
+	return nil
+}
+
 func askForConfirmation(message string) bool {
 	fmt.Println(message)
 	var response string
@@ -221,6 +692,12 @@ func deleteContext(client api.ContextInterface, force bool, vcsType, orgName, co
 		return err
 	}
 
+	// Without a terminal to answer on, the confirmation would be read from
+	// whatever was piped in
+	if !force && stdinIsPiped() {
+		return fmt.Errorf("not deleting context %s without confirmation, as stdin isn't a terminal, use --force to delete it without asking", context.Name)
+	}
+
 	message := fmt.Sprintf("Are you sure that you want to delete this context: %s/%s %s (y/n)?",
 		vcsType, orgName, context.Name)
 
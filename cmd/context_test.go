@@ -1,13 +1,17 @@
 package cmd_test
 
 import (
+	"net/http"
+	"net/url"
 	"os/exec"
+	"strings"
 
 	"github.com/CircleCI-Public/circleci-cli/clitest"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
 )
 
 var _ = Describe("Context integration tests", func() {
@@ -38,5 +42,504 @@ https://circleci.com/account/api`))
 		})
 	})
 
+	Describe("when listing contexts as json", func() {
+		var (
+			command      *exec.Cmd
+			tempSettings *clitest.TempSettings
+		)
+
+		BeforeEach(func() {
+			tempSettings = clitest.WithTempSettings()
+			command = commandWithHome(pathCLI, tempSettings.Home,
+				"context", "list", "github", "test-org",
+				"--json",
+				"--skip-update-check",
+				"--token", "testtoken",
+				"--host", tempSettings.TestServer.URL(),
+			)
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/openapi.json"),
+					ghttp.RespondWith(http.StatusOK, `{"paths": {"/context": {}}}`),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			tempSettings.Close()
+		})
+
+		It("prints the contexts of every page", func() {
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/context", "owner-slug=github%2Ftest-org"),
+					ghttp.RespondWith(http.StatusOK, `{
+						"items": [{"id": "ctx1", "name": "staging", "created_at": "2021-01-02T03:04:05Z"}],
+						"next_page_token": "page2"
+					}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/context", "owner-slug=github%2Ftest-org&page-token=page2"),
+					ghttp.RespondWith(http.StatusOK, `{
+						"items": [{"id": "ctx2", "name": "production", "created_at": "2021-02-03T04:05:06Z"}],
+						"next_page_token": null
+					}`),
+				),
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out.Contents()).To(MatchJSON(`[
+				{"id": "ctx1", "name": "staging", "created_at": "2021-01-02T03:04:05Z"},
+				{"id": "ctx2", "name": "production", "created_at": "2021-02-03T04:05:06Z"}
+			]`))
+		})
+
+		It("prints an empty list when there are no contexts", func() {
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/context"),
+					ghttp.RespondWith(http.StatusOK, `{"items": [], "next_page_token": null}`),
+				),
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal("[]\n"))
+		})
+	})
+
+	Describe("when showing a context", func() {
+		var (
+			tempSettings *clitest.TempSettings
+			args         []string
+		)
+
+		BeforeEach(func() {
+			tempSettings = clitest.WithTempSettings()
+			args = []string{
+				"context", "show", "github", "test-org", "staging",
+				"--skip-update-check",
+				"--token", "testtoken",
+				"--host", tempSettings.TestServer.URL(),
+			}
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/openapi.json"),
+					ghttp.RespondWith(http.StatusOK, `{"paths": {"/context": {}}}`),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			tempSettings.Close()
+		})
+
+		appendContexts := func(contexts string) {
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/context", "owner-slug=github%2Ftest-org"),
+					ghttp.RespondWith(http.StatusOK, `{"items": `+contexts+`, "next_page_token": null}`),
+				),
+			)
+		}
+
+		appendVariables := func() {
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/context/ctx1/environment-variable"),
+					ghttp.RespondWith(http.StatusOK, `{
+						"items": [
+							{"variable": "AWS_KEY", "context_id": "ctx1", "created_at": "2021-01-02T03:04:05Z"},
+							{"variable": "NPM_TOKEN", "context_id": "ctx1", "created_at": "2021-02-03T04:05:06Z"}
+						],
+						"next_page_token": null
+					}`),
+				),
+			)
+		}
+
+		It("lists the names of its variables and when they were created", func() {
+			appendContexts(`[{"id": "ctx1", "name": "staging", "created_at": "2021-01-01T00:00:00Z"}]`)
+			appendVariables()
+
+			session, err := gexec.Start(commandWithHome(pathCLI, tempSettings.Home, args...), GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say("Context: staging"))
+			Expect(session.Out).To(gbytes.Say(`AWS_KEY\s+\|\s+••••\s+\|\s+2021-01-02T03:04:05Z`))
+			Expect(session.Out).To(gbytes.Say(`NPM_TOKEN\s+\|\s+••••\s+\|\s+2021-02-03T04:05:06Z`))
+		})
+
+		It("prints them as json", func() {
+			appendContexts(`[{"id": "ctx1", "name": "staging", "created_at": "2021-01-01T00:00:00Z"}]`)
+			appendVariables()
+
+			session, err := gexec.Start(commandWithHome(pathCLI, tempSettings.Home, append(args, "--json")...), GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out.Contents()).To(MatchJSON(`{
+				"id": "ctx1",
+				"name": "staging",
+				"variables": [
+					{"variable": "AWS_KEY", "created_at": "2021-01-02T03:04:05Z"},
+					{"variable": "NPM_TOKEN", "created_at": "2021-02-03T04:05:06Z"}
+				]
+			}`))
+		})
+
+		It("fails when there is no context with the name", func() {
+			appendContexts(`[{"id": "ctx2", "name": "production", "created_at": "2021-01-01T00:00:00Z"}]`)
+
+			session, err := gexec.Start(commandWithHome(pathCLI, tempSettings.Home, args...), GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say("Error: no context named staging was found in github/test-org"))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+
+		It("fails when more than one context has the name", func() {
+			appendContexts(`[
+				{"id": "ctx1", "name": "staging", "created_at": "2021-01-01T00:00:00Z"},
+				{"id": "ctx3", "name": "staging", "created_at": "2021-01-01T00:00:00Z"}
+			]`)
+
+			session, err := gexec.Start(commandWithHome(pathCLI, tempSettings.Home, args...), GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say("Error: 2 contexts are named staging in github/test-org, with the IDs ctx1, ctx3"))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+	})
+
+	Describe("when creating a context in several organizations", func() {
+		var tempSettings *clitest.TempSettings
+
+		orgQuery := func(id string) string {
+			return `{"query": "query($id: ID!) {\n\t\t\t\torganization(id: $id) {\n\t\t\t\t\tid\n\t\t\t\t\tname\n\t\t\t\t\tvcsType\n\t\t\t\t}\n\t\t\t}", "variables": {"id": "` + id + `"}}`
+		}
+
+		BeforeEach(func() {
+			tempSettings = clitest.WithTempSettings()
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/openapi.json"),
+					ghttp.RespondWith(http.StatusOK, `{"paths": {"/context": {}}}`),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			tempSettings.Close()
+		})
+
+		It("creates the context in each of them, carrying on past failures", func() {
+			tempSettings.AppendPostHandler("testtoken", clitest.MockRequestResponse{
+				Status:   http.StatusOK,
+				Request:  orgQuery("org1"),
+				Response: `{"organization": {"id": "org1", "name": "first-org", "vcsType": "GITHUB"}}`,
+			})
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v2/context"),
+					ghttp.VerifyJSON(`{"name": "staging", "owner": {"slug": "github/first-org"}}`),
+					ghttp.RespondWith(http.StatusOK, `{"id": "ctx1", "name": "staging", "created_at": "2021-01-01T00:00:00Z"}`),
+				),
+			)
+			tempSettings.AppendPostHandler("testtoken", clitest.MockRequestResponse{
+				Status:   http.StatusOK,
+				Request:  orgQuery("org2"),
+				Response: `{"organization": {"id": "", "name": "", "vcsType": ""}}`,
+			})
+			tempSettings.AppendPostHandler("testtoken", clitest.MockRequestResponse{
+				Status:   http.StatusOK,
+				Request:  orgQuery("org3"),
+				Response: `{"organization": {"id": "org3", "name": "third-org", "vcsType": "BITBUCKET"}}`,
+			})
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v2/context"),
+					ghttp.VerifyJSON(`{"name": "staging", "owner": {"slug": "bitbucket/third-org"}}`),
+					ghttp.RespondWith(http.StatusOK, `{"id": "ctx3", "name": "staging", "created_at": "2021-01-01T00:00:00Z"}`),
+				),
+			)
+
+			session, err := gexec.Start(commandWithHome(pathCLI, tempSettings.Home,
+				"context", "create", "staging",
+				"--org-id", "org1",
+				"--org-id", "org2",
+				"--org-id", "org3",
+				"--skip-update-check",
+				"--token", "testtoken",
+				"--host", tempSettings.TestServer.URL(),
+			), GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(string(session.Out.Contents())).To(Equal(`Created context staging in github/first-org (org1).
+Created context staging in bitbucket/third-org (org3).
+Created context staging in 2 of 3 organizations.
+`))
+			Expect(session.Err).To(gbytes.Say("Failed to create context staging in the organization org2: the organization with id 'org2' does not exist"))
+			Expect(session.Err).To(gbytes.Say("Error: failed to create context staging in 1 of 3 organizations"))
+		})
+
+		It("doesn't accept --org-slug along with them", func() {
+			session, err := gexec.Start(commandWithHome(pathCLI, tempSettings.Home,
+				"context", "create", "staging",
+				"--org-id", "org1",
+				"--org-id", "org2",
+				"--org-slug", "github/first-org",
+				"--skip-update-check",
+				"--token", "testtoken",
+				"--host", tempSettings.TestServer.URL(),
+			), GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say("Error: --org-slug can't be combined with more than one --org-id"))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+	})
+
+	Describe("when comparing contexts in two organizations", func() {
+		var tempSettings *clitest.TempSettings
+
+		BeforeEach(func() {
+			tempSettings = clitest.WithTempSettings()
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/openapi.json"),
+					ghttp.RespondWith(http.StatusOK, `{"paths": {"/context": {}}}`),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			tempSettings.Close()
+		})
+
+		appendContext := func(slug, id, name string, variables ...string) {
+			var items []string
+			for _, variable := range variables {
+				items = append(items, `{"variable": "`+variable+`", "context_id": "`+id+`", "created_at": "2021-01-02T03:04:05Z"}`)
+			}
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/context", "owner-slug="+url.QueryEscape(slug)),
+					ghttp.RespondWith(http.StatusOK, `{"items": [{"id": "`+id+`", "name": "`+name+`", "created_at": "2021-01-01T00:00:00Z"}], "next_page_token": null}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/context/"+id+"/environment-variable"),
+					ghttp.RespondWith(http.StatusOK, `{"items": [`+strings.Join(items, ",")+`], "next_page_token": null}`),
+				),
+			)
+		}
+
+		It("finds the organization of the second context by --other-org-id", func() {
+			tempSettings.AppendPostHandler("testtoken", clitest.MockRequestResponse{
+				Status:   http.StatusOK,
+				Request:  `{"query": "query($id: ID!) {\n\t\t\t\torganization(id: $id) {\n\t\t\t\t\tid\n\t\t\t\t\tname\n\t\t\t\t\tvcsType\n\t\t\t\t}\n\t\t\t}", "variables": {"id": "org2"}}`,
+				Response: `{"organization": {"id": "org2", "name": "other-org", "vcsType": "BITBUCKET"}}`,
+			})
+			appendContext("github/test-org", "ctx1", "staging", "SHARED", "DEBUG")
+			appendContext("bitbucket/other-org", "ctx2", "production", "SHARED", "DEPLOY_KEY")
+
+			session, err := gexec.Start(commandWithHome(pathCLI, tempSettings.Home,
+				"context", "diff", "github", "test-org", "staging", "production",
+				"--other-org-id", "org2",
+				"--json",
+				"--skip-update-check",
+				"--token", "testtoken",
+				"--host", tempSettings.TestServer.URL(),
+			), GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out.Contents()).To(MatchJSON(`{
+				"first": "staging",
+				"second": "production",
+				"only_in_first": ["DEBUG"],
+				"only_in_second": ["DEPLOY_KEY"],
+				"in_both": ["SHARED"]
+			}`))
+		})
+
+		It("doesn't accept --other-org along with --other-org-id", func() {
+			session, err := gexec.Start(commandWithHome(pathCLI, tempSettings.Home,
+				"context", "diff", "github", "test-org", "staging", "production",
+				"--other-org-id", "org2",
+				"--other-org", "other-org",
+				"--skip-update-check",
+				"--token", "testtoken",
+				"--host", tempSettings.TestServer.URL(),
+			), GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say("Error: --other-org-id can't be combined with --other-org or --other-vcs-type"))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+	})
+
+	Describe("when creating a context", func() {
+		var (
+			tempSettings *clitest.TempSettings
+			args         []string
+		)
+
+		BeforeEach(func() {
+			tempSettings = clitest.WithTempSettings()
+			args = []string{
+				"context", "create", "github", "test-org", "staging",
+				"--skip-update-check",
+				"--token", "testtoken",
+				"--host", tempSettings.TestServer.URL(),
+			}
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/openapi.json"),
+					ghttp.RespondWith(http.StatusOK, `{"paths": {"/context": {}}}`),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			tempSettings.Close()
+		})
+
+		It("prints the new context's id with --json --field id", func() {
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v2/context"),
+					ghttp.VerifyJSON(`{"name": "staging", "owner": {"slug": "github/test-org"}}`),
+					ghttp.RespondWith(http.StatusOK, `{"id": "ctx1", "name": "staging", "created_at": "2021-01-02T03:04:05Z"}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/context", "owner-slug=github%2Ftest-org"),
+					ghttp.RespondWith(http.StatusOK, `{
+						"items": [{"id": "ctx1", "name": "staging", "created_at": "2021-01-02T03:04:05Z"}],
+						"next_page_token": null
+					}`),
+				),
+			)
+
+			session, err := gexec.Start(commandWithHome(pathCLI, tempSettings.Home, append(args, "--json", "--field", "id")...), GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal("ctx1\n"))
+		})
+
+		It("exits with 3 when the context already exists", func() {
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v2/context"),
+					ghttp.RespondWith(http.StatusBadRequest, `{"message": "A context named staging already exists."}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/context", "owner-slug=github%2Ftest-org"),
+					ghttp.RespondWith(http.StatusOK, `{
+						"items": [{"id": "ctx1", "name": "staging", "created_at": "2021-01-02T03:04:05Z"}],
+						"next_page_token": null
+					}`),
+				),
+			)
+
+			session, err := gexec.Start(commandWithHome(pathCLI, tempSettings.Home, append(args, "--json")...), GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(3))
+			Expect(session.Err).To(gbytes.Say("Error: context staging already exists in github/test-org"))
+			Expect(session.Out.Contents()).To(BeEmpty())
+		})
+
+		It("fails as usual for other errors", func() {
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v2/context"),
+					ghttp.RespondWith(http.StatusForbidden, `{"message": "Permission denied."}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/context", "owner-slug=github%2Ftest-org"),
+					ghttp.RespondWith(http.StatusOK, `{"items": [], "next_page_token": null}`),
+				),
+			)
+
+			session, err := gexec.Start(commandWithHome(pathCLI, tempSettings.Home, args...), GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(session.Err).To(gbytes.Say("Error: Permission denied."))
+		})
+	})
+
+	Describe("when deleting a context", func() {
+		var (
+			tempSettings *clitest.TempSettings
+			args         []string
+		)
+
+		BeforeEach(func() {
+			tempSettings = clitest.WithTempSettings()
+			args = []string{
+				"context", "delete", "github", "test-org", "staging",
+				"--skip-update-check",
+				"--token", "testtoken",
+				"--host", tempSettings.TestServer.URL(),
+			}
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/openapi.json"),
+					ghttp.RespondWith(http.StatusOK, `{"paths": {"/context": {}}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/context", "owner-slug=github%2Ftest-org"),
+					ghttp.RespondWith(http.StatusOK, `{
+						"items": [{"id": "ctx1", "name": "staging", "created_at": "2021-01-02T03:04:05Z"}],
+						"next_page_token": null
+					}`),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			tempSettings.Close()
+		})
+
+		It("refuses to ask for confirmation when stdin isn't a terminal", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home, args...)
+			command.Stdin = strings.NewReader("y\n")
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(session.Err).To(gbytes.Say("Error: not deleting context staging without confirmation, as stdin isn't a terminal, use --force to delete it without asking"))
+			Expect(tempSettings.TestServer.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("deletes it without asking with --no-prompt", func() {
+			tempSettings.TestServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/api/v2/context/ctx1"),
+					ghttp.RespondWith(http.StatusOK, `{"message": "Context deleted."}`),
+				),
+			)
+			command := commandWithHome(pathCLI, tempSettings.Home, append(args, "--no-prompt")...)
+			command.Stdin = strings.NewReader("")
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).ToNot(ContainSubstring("Are you sure"))
+		})
+	})
+
 	// TODO: add integration tests for happy path cases
 })
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/CircleCI-Public/circleci-cli/api"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+// fakeContextClient is an in-memory api.ContextInterface.
+type fakeContextClient struct {
+	contexts map[string]*api.Context
+	envVars  map[string]map[string]string
+}
+
+func newFakeContextClient(names ...string) *fakeContextClient {
+	client := &fakeContextClient{
+		contexts: map[string]*api.Context{},
+		envVars:  map[string]map[string]string{},
+	}
+	for _, name := range names {
+		Expect(client.CreateContext("github", "test-org", name)).To(Succeed())
+	}
+	return client
+}
+
+func (c *fakeContextClient) Contexts(vcs, org string) (*[]api.Context, error) {
+	contexts := []api.Context{}
+	for _, context := range c.contexts {
+		contexts = append(contexts, *context)
+	}
+	return &contexts, nil
+}
+
+func (c *fakeContextClient) ContextByName(vcs, org, name string) (*api.Context, error) {
+	context, ok := c.contexts[name]
+	if !ok {
+		return nil, fmt.Errorf("context %s not found", name)
+	}
+	return context, nil
+}
+
+func (c *fakeContextClient) DeleteContext(contextID string) error {
+	for name, context := range c.contexts {
+		if context.ID == contextID {
+			delete(c.contexts, name)
+		}
+	}
+	return nil
+}
+
+func (c *fakeContextClient) CreateContext(vcs, org, name string) error {
+	c.contexts[name] = &api.Context{ID: "id-" + name, Name: name}
+	c.envVars["id-"+name] = map[string]string{}
+	return nil
+}
+
+func (c *fakeContextClient) EnvironmentVariables(contextID string) (*[]api.EnvironmentVariable, error) {
+	envVars := []api.EnvironmentVariable{}
+	for variable := range c.envVars[contextID] {
+		envVars = append(envVars, api.EnvironmentVariable{Variable: variable, ContextID: contextID})
+	}
+	return &envVars, nil
+}
+
+func (c *fakeContextClient) CreateEnvironmentVariable(contextID, variable, value string) error {
+	c.envVars[contextID][variable] = value
+	return nil
+}
+
+func (c *fakeContextClient) DeleteEnvironmentVariable(contextID, variable string) error {
+	delete(c.envVars[contextID], variable)
+	return nil
+}
+
+var _ = Describe("Context", func() {
+	var (
+		client *fakeContextClient
+		dir    string
+	)
+
+	BeforeEach(func() {
+		var err error
+		client = newFakeContextClient("my-ctx")
+		dir, err = ioutil.TempDir("", "circleci-cli-context-test-")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	Describe("storing a secret from a file", func() {
+		It("stores the contents unmodified", func() {
+			contents := "-----BEGIN KEY-----\nabc\n-----END KEY-----\n\n"
+			path := filepath.Join(dir, "key.pem")
+			Expect(ioutil.WriteFile(path, []byte(contents), 0600)).To(Succeed())
+
+			Expect(storeEnvVar(client, "github", "test-org", "my-ctx", "MY_KEY", path)).To(Succeed())
+			Expect(client.envVars["id-my-ctx"]["MY_KEY"]).To(Equal(contents))
+		})
+
+		It("reports a missing file", func() {
+			path := filepath.Join(dir, "missing.pem")
+
+			err := storeEnvVar(client, "github", "test-org", "my-ctx", "MY_KEY", path)
+			Expect(err).To(MatchError(ContainSubstring("Failed to read secret value from " + path)))
+			Expect(client.envVars["id-my-ctx"]).To(BeEmpty())
+		})
+	})
+
+	Describe("importing a dotenv file", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(dir, ".env")
+			Expect(ioutil.WriteFile(path, []byte(`# comment
+EXISTING=new value
+
+export NEW="multi\nline"
+QUOTED='single # quoted'
+`), 0600)).To(Succeed())
+			Expect(client.CreateEnvironmentVariable("id-my-ctx", "EXISTING", "old value")).To(Succeed())
+		})
+
+		It("stores each variable", func() {
+			Expect(importEnvVars(client, "github", "test-org", "my-ctx", path, false)).To(Succeed())
+			Expect(client.envVars["id-my-ctx"]).To(Equal(map[string]string{
+				"EXISTING": "new value",
+				"NEW":      "multi\nline",
+				"QUOTED":   "single # quoted",
+			}))
+		})
+
+		It("doesn't store anything in a dry run", func() {
+			Expect(importEnvVars(client, "github", "test-org", "my-ctx", path, true)).To(Succeed())
+			Expect(client.envVars["id-my-ctx"]).To(Equal(map[string]string{"EXISTING": "old value"}))
+		})
+
+		It("reports malformed lines", func() {
+			Expect(ioutil.WriteFile(path, []byte("GOOD=1\nnot a variable\n"), 0600)).To(Succeed())
+
+			err := importEnvVars(client, "github", "test-org", "my-ctx", path, false)
+			Expect(err).To(MatchError(ContainSubstring("invalid line 2, expected KEY=VALUE")))
+			Expect(client.envVars["id-my-ctx"]).To(Equal(map[string]string{"EXISTING": "old value"}))
+		})
+	})
+
+	Describe("comparing two contexts", func() {
+		It("groups variable names by which context has them", func() {
+			client = newFakeContextClient("staging", "production")
+			Expect(client.CreateEnvironmentVariable("id-staging", "SHARED", "a")).To(Succeed())
+			Expect(client.CreateEnvironmentVariable("id-production", "SHARED", "b")).To(Succeed())
+			Expect(client.CreateEnvironmentVariable("id-staging", "DEBUG", "1")).To(Succeed())
+			Expect(client.CreateEnvironmentVariable("id-production", "DEPLOY_KEY", "k")).To(Succeed())
+			Expect(client.CreateEnvironmentVariable("id-production", "API_KEY", "k")).To(Succeed())
+
+			diff, err := compareContexts(client, "github", "test-org", "staging", "github", "other-org", "production")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(*diff).To(Equal(contextDiff{
+				First:        "staging",
+				Second:       "production",
+				OnlyInFirst:  []string{"DEBUG"},
+				OnlyInSecond: []string{"API_KEY", "DEPLOY_KEY"},
+				InBoth:       []string{"SHARED"},
+			}))
+		})
+
+		It("reports a missing context", func() {
+			_, err := compareContexts(client, "github", "test-org", "my-ctx", "github", "test-org", "missing")
+			Expect(err).To(MatchError("context missing not found"))
+		})
+	})
+
+	Describe("completing context names", func() {
+		var calls int
+
+		BeforeEach(func() {
+			calls = 0
+			completionCache = map[string][]string{}
+		})
+
+		complete := completeContextArgs(func(*cobra.Command, []string) (api.ContextInterface, error) {
+			calls++
+			return client, nil
+		}, 2)
+
+		It("suggests the VCS types first", func() {
+			suggestions, _ := complete(nil, []string{}, "")
+			Expect(suggestions).To(Equal([]string{"github", "bitbucket"}))
+			Expect(calls).To(Equal(0))
+		})
+
+		It("suggests the org's contexts once, for the context name", func() {
+			suggestions, directive := complete(nil, []string{"github", "test-org"}, "")
+			Expect(suggestions).To(Equal([]string{"my-ctx"}))
+			Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp))
+
+			suggestions, _ = complete(nil, []string{"github", "test-org"}, "my")
+			Expect(suggestions).To(Equal([]string{"my-ctx"}))
+			Expect(calls).To(Equal(1))
+		})
+
+		It("doesn't suggest anything for later arguments", func() {
+			suggestions, _ := complete(nil, []string{"github", "test-org", "my-ctx"}, "")
+			Expect(suggestions).To(BeEmpty())
+			Expect(calls).To(Equal(0))
+		})
+
+		It("doesn't suggest anything when the API can't be reached", func() {
+			offline := completeContextArgs(func(*cobra.Command, []string) (api.ContextInterface, error) {
+				return nil, fmt.Errorf("no route to host")
+			}, 2)
+			suggestions, directive := offline(nil, []string{"github", "test-org"}, "")
+			Expect(suggestions).To(BeEmpty())
+			Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp))
+		})
+	})
+})
@@ -7,6 +7,8 @@ import (
 	"github.com/CircleCI-Public/circleci-cli/api"
 	"github.com/CircleCI-Public/circleci-cli/api/graphql"
 	"github.com/CircleCI-Public/circleci-cli/settings"
+	"github.com/CircleCI-Public/circleci-cli/version"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +16,23 @@ type diagnosticOptions struct {
 	cfg  *settings.Config
 	cl   *graphql.Client
 	args []string
+	json bool
+}
+
+// diagnosticReport is what `diagnostic --json` prints. The token itself is
+// never included, only whether one is set.
+type diagnosticReport struct {
+	ConfigFile   string `json:"config_file"`
+	Profile      string `json:"profile,omitempty"`
+	DefaultOrgID string `json:"default_org_id,omitempty"`
+	Debug        bool   `json:"debug"`
+	Host         string `json:"api_host"`
+	Endpoint     string `json:"api_endpoint"`
+	Version      string `json:"cli_version"`
+	TokenSet     bool   `json:"token_set"`
+	TokenSource  string `json:"token_source,omitempty"`
+	APIReachable bool   `json:"api_reachable"`
+	User         string `json:"user,omitempty"`
 }
 
 func newDiagnosticCommand(config *settings.Config) *cobra.Command {
@@ -24,15 +43,29 @@ func newDiagnosticCommand(config *settings.Config) *cobra.Command {
 	diagnosticCommand := &cobra.Command{
 		Use:   "diagnostic",
 		Short: "Check the status of your CircleCI CLI.",
+		Long: `Check the status of your CircleCI CLI.
+
+The token is the first one found of, in order:
+  --token
+  --token-stdin
+  CIRCLECI_CLI_TOKEN
+  the file named by CIRCLECI_CLI_TOKEN_FILE
+  the config file, or the keychain when the config file says it's kept there`,
 		PreRun: func(cmd *cobra.Command, args []string) {
 			opts.args = args
 			opts.cl = graphql.NewClient(config.HTTPClient, config.Host, config.Endpoint, config.Token, config.Debug)
 		},
 		RunE: func(_ *cobra.Command, _ []string) error {
+			if opts.json {
+				return diagnosticJSON(opts)
+			}
 			return diagnostic(opts)
 		},
 	}
 
+	diagnosticCommand.Flags().BoolVar(&opts.json, "json", false, "print the results of the checks as json, exiting with an error when a token is missing or the API can't be reached")
+	addFieldFlag(diagnosticCommand)
+
 	return diagnosticCommand
 }
 
@@ -40,14 +73,23 @@ func diagnostic(opts diagnosticOptions) error {
 	fmt.Println("\n---\nCircleCI CLI Diagnostics\n---")
 	fmt.Printf("Debugger mode: %v\n", opts.cfg.Debug)
 	fmt.Printf("Config found: %v\n", opts.cfg.FileUsed)
+	if opts.cfg.Profile != "" {
+		fmt.Printf("Profile: %s\n", opts.cfg.Profile)
+	}
 	fmt.Printf("API host: %s\n", opts.cfg.Host)
 	fmt.Printf("API endpoint: %s\n", opts.cfg.Endpoint)
+	if opts.cfg.DefaultOrgID != "" {
+		fmt.Printf("Default organization ID: %s\n", opts.cfg.DefaultOrgID)
+	}
 
 	if err := validateToken(opts.cfg); err != nil {
 		return err
 	}
 
 	fmt.Println("OK, got a token.")
+	if opts.cfg.TokenSource != "" {
+		fmt.Printf("Token from: %s\n", opts.cfg.TokenSource)
+	}
 
 	fmt.Println("Trying an introspection query on API... ")
 
@@ -80,3 +122,49 @@ func diagnostic(opts diagnosticOptions) error {
 
 	return nil
 }
+
+func diagnosticJSON(opts diagnosticOptions) error {
+	report := diagnosticReport{
+		ConfigFile:   opts.cfg.FileUsed,
+		Profile:      opts.cfg.Profile,
+		DefaultOrgID: opts.cfg.DefaultOrgID,
+		Debug:        opts.cfg.Debug,
+		Host:         opts.cfg.Host,
+		Endpoint:     opts.cfg.Endpoint,
+		Version:      fmt.Sprintf("%s+%s (%s)", version.Version, version.Commit, version.PackageManager()),
+	}
+
+	tokenErr := validateToken(opts.cfg)
+	report.TokenSet = tokenErr == nil
+	if report.TokenSet {
+		report.TokenSource = opts.cfg.TokenSource
+	}
+
+	var apiErr error
+	if report.TokenSet {
+		responseIntro, err := api.IntrospectionQuery(opts.cl)
+		if err != nil {
+			apiErr = err
+		} else if responseIntro.Schema.QueryType.Name == "" {
+			apiErr = errors.New("Unable to make a query against the GraphQL API, please check your settings")
+		}
+		report.APIReachable = apiErr == nil
+
+		if report.APIReachable {
+			if responseWho, err := api.WhoamiQuery(opts.cl); err == nil {
+				report.User = responseWho.Me.Name
+			}
+		}
+	}
+
+	reportJSON, err := marshalJSON(report)
+	if err != nil {
+		return errors.Wrap(err, "Failed to convert to JSON")
+	}
+	fmt.Println(string(reportJSON))
+
+	if tokenErr != nil {
+		return tokenErr
+	}
+	return apiErr
+}
@@ -1,10 +1,12 @@
 package cmd_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/CircleCI-Public/circleci-cli/api/graphql"
 	"github.com/CircleCI-Public/circleci-cli/clitest"
@@ -136,6 +138,71 @@ token: mytoken
 			})
 		})
 
+		Context("token sources", func() {
+			BeforeEach(func() {
+				tempSettings.Config.Write([]byte(`token: filetoken`))
+			})
+
+			It("reports a token from the config file", func() {
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Out).Should(gbytes.Say("Token from: the config file"))
+				Eventually(session).Should(gexec.Exit(0))
+			})
+
+			It("reads the token from stdin with --token-stdin", func() {
+				command.Args = append(command.Args, "--token-stdin", "--debug")
+				command.Stdin = strings.NewReader("stdintoken\n")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Out).Should(gbytes.Say("Token from: --token-stdin"))
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Err.Contents())).NotTo(ContainSubstring("stdintoken"))
+			})
+
+			It("fails with --token-stdin and nothing on stdin", func() {
+				command.Args = append(command.Args, "--token-stdin")
+				command.Stdin = strings.NewReader("")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: --token-stdin was given, but stdin didn't start with a token"))
+				Eventually(session).Should(clitest.ShouldFail())
+			})
+
+			It("refuses both --token and --token-stdin", func() {
+				command.Args = append(command.Args, "--token-stdin", "--token", "flagtoken")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: --token and --token-stdin can't be used together"))
+				Eventually(session).Should(clitest.ShouldFail())
+			})
+
+			It("reads the token from CIRCLECI_CLI_TOKEN_FILE", func() {
+				tokenFile := clitest.OpenTmpFile(tempSettings.Home, "token")
+				tokenFile.Write([]byte("filetoken2\n"))
+				command.Env = append(command.Env, "CIRCLECI_CLI_TOKEN_FILE="+tokenFile.Path)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Out).Should(gbytes.Say("Token from: CIRCLECI_CLI_TOKEN_FILE"))
+				Eventually(session).Should(gexec.Exit(0))
+			})
+
+			It("prefers CIRCLECI_CLI_TOKEN to CIRCLECI_CLI_TOKEN_FILE", func() {
+				tokenFile := clitest.OpenTmpFile(tempSettings.Home, "token")
+				tokenFile.Write([]byte("filetoken2\n"))
+				command.Env = append(command.Env, "CIRCLECI_CLI_TOKEN_FILE="+tokenFile.Path, "CIRCLECI_CLI_TOKEN=envtoken")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Out).Should(gbytes.Say("Token from: CIRCLECI_CLI_TOKEN\n"))
+				Eventually(session).Should(gexec.Exit(0))
+			})
+		})
+
 		Context("debug outputs introspection query results", func() {
 			BeforeEach(func() {
 				tempSettings.Config.Write([]byte(`token: zomg`))
@@ -154,6 +221,122 @@ token: mytoken
 				Eventually(session.Err).Should(gbytes.Say("Introspection query result with Schema.QueryType of QueryRoot"))
 				Eventually(session).Should(gexec.Exit(0))
 			})
+
+			It("logs the requests with the token redacted", func() {
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+
+				stderr := string(session.Err.Contents())
+				Expect(stderr).To(ContainSubstring(fmt.Sprintf("> POST %s/graphql-unstable\n", tempSettings.TestServer.URL())))
+				Expect(stderr).To(ContainSubstring("> Authorization: [REDACTED]\n"))
+				Expect(stderr).To(ContainSubstring("< 200 OK"))
+				Expect(stderr).NotTo(ContainSubstring("zomg"))
+			})
+		})
+
+		Context("with --config", func() {
+			var configPath string
+
+			BeforeEach(func() {
+				configPath = filepath.Join(tempSettings.Home, "other.yml")
+				clitest.OpenTmpFile(tempSettings.Home, "other.yml").Write([]byte(`token: othertoken`))
+				tempSettings.Config.Write([]byte(`token: `))
+			})
+
+			It("reports the config file that is used", func() {
+				command = commandWithHome(pathCLI, tempSettings.Home,
+					"diagnostic",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+					"--cli-config", configPath,
+				)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Out).Should(gbytes.Say(fmt.Sprintf("Config found: %s", configPath)))
+				Eventually(session.Out).Should(gbytes.Say(fmt.Sprintf("API host: %s", tempSettings.TestServer.URL())))
+				Eventually(session.Out).Should(gbytes.Say("OK, got a token."))
+				Eventually(session).Should(gexec.Exit(0))
+			})
+
+			It("reports the config file given by CIRCLECI_CLI_CONFIG", func() {
+				command.Env = append(command.Env, "CIRCLECI_CLI_CONFIG="+configPath)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Out).Should(gbytes.Say(fmt.Sprintf("Config found: %s", configPath)))
+				Eventually(session.Out).Should(gbytes.Say("OK, got a token."))
+				Eventually(session).Should(gexec.Exit(0))
+			})
+		})
+
+		Context("with a profile", func() {
+			BeforeEach(func() {
+				tempSettings.Config.Write([]byte(`
+token:
+profiles:
+  staging:
+    token: stagingtoken
+    default_org_id: staging-org
+`))
+			})
+
+			It("reports the profile that is used", func() {
+				command.Env = append(command.Env, "CIRCLECI_CLI_PROFILE=staging")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Out).Should(gbytes.Say("Profile: staging"))
+				Eventually(session.Out).Should(gbytes.Say(fmt.Sprintf("API host: %s", tempSettings.TestServer.URL())))
+				Eventually(session.Out).Should(gbytes.Say("Default organization ID: staging-org"))
+				Eventually(session.Out).Should(gbytes.Say("OK, got a token."))
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out.Contents()).ShouldNot(ContainSubstring("stagingtoken"))
+			})
+		})
+
+		Context("with --json", func() {
+			BeforeEach(func() {
+				command = commandWithHome(pathCLI, tempSettings.Home,
+					"diagnostic",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+					"--json",
+				)
+			})
+
+			It("prints the checks as json", func() {
+				tempSettings.Config.Write([]byte(`token: mytoken`))
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+
+				var report map[string]interface{}
+				Expect(json.Unmarshal(session.Out.Contents(), &report)).To(Succeed())
+				Expect(report).To(HaveKeyWithValue("config_file", tempSettings.Config.Path))
+				Expect(report).To(HaveKeyWithValue("api_host", tempSettings.TestServer.URL()))
+				Expect(report).To(HaveKeyWithValue("api_endpoint", defaultEndpoint))
+				Expect(report).To(HaveKeyWithValue("token_set", true))
+				Expect(report).To(HaveKeyWithValue("api_reachable", true))
+				Expect(report).To(HaveKey("cli_version"))
+				Expect(session.Out.Contents()).ShouldNot(ContainSubstring("mytoken"))
+			})
+
+			It("fails when no token is set", func() {
+				tempSettings.Config.Write([]byte(`token: `))
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(session.Err).To(gbytes.Say("Error: please set a token with 'circleci setup'"))
+
+				var report map[string]interface{}
+				Expect(json.Unmarshal(session.Out.Contents(), &report)).To(Succeed())
+				Expect(report).To(HaveKeyWithValue("token_set", false))
+				Expect(report).To(HaveKeyWithValue("api_reachable", false))
+			})
 		})
 	})
 
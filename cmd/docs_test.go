@@ -0,0 +1,113 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/CircleCI-Public/circleci-cli/clitest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"gopkg.in/yaml.v3"
+)
+
+var _ = Describe("Docs", func() {
+	var (
+		command      *exec.Cmd
+		tempSettings *clitest.TempSettings
+		outputDir    string
+	)
+
+	BeforeEach(func() {
+		tempSettings = clitest.WithTempSettings()
+		outputDir = filepath.Join(tempSettings.Home, "reference")
+	})
+
+	AfterEach(func() {
+		tempSettings.Close()
+	})
+
+	docs := func(format string, args ...string) *gexec.Session {
+		command = commandWithHome(pathCLI, tempSettings.Home, append([]string{
+			"docs",
+			"--format", format,
+			"--output-dir", outputDir,
+			"--skip-update-check",
+		}, args...)...)
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		return session
+	}
+
+	It("writes the reference as JSON", func() {
+		Eventually(docs("json")).Should(gexec.Exit(0))
+
+		contents, err := ioutil.ReadFile(filepath.Join(outputDir, "circleci.json"))
+		Expect(err).ShouldNot(HaveOccurred())
+		var reference struct {
+			Path     string
+			Commands []struct{ Path string }
+		}
+		Expect(json.Unmarshal(contents, &reference)).To(Succeed())
+		Expect(reference.Path).To(Equal("circleci"))
+		var paths []string
+		for _, command := range reference.Commands {
+			paths = append(paths, command.Path)
+		}
+		Expect(paths).To(ContainElement("circleci orb"))
+	})
+
+	It("writes the reference as YAML", func() {
+		Eventually(docs("yaml")).Should(gexec.Exit(0))
+
+		contents, err := ioutil.ReadFile(filepath.Join(outputDir, "circleci.yml"))
+		Expect(err).ShouldNot(HaveOccurred())
+		var reference struct {
+			Path string `yaml:"path"`
+		}
+		Expect(yaml.Unmarshal(contents, &reference)).To(Succeed())
+		Expect(reference.Path).To(Equal("circleci"))
+	})
+
+	It("writes a markdown page for each command", func() {
+		Eventually(docs("markdown")).Should(gexec.Exit(0))
+
+		Expect(filepath.Join(outputDir, "circleci.md")).To(BeAnExistingFile())
+		Expect(filepath.Join(outputDir, "circleci_orb.md")).To(BeAnExistingFile())
+	})
+
+	It("removes the pages of removed commands with --clean", func() {
+		Expect(os.MkdirAll(outputDir, 0700)).To(Succeed())
+		stale := filepath.Join(outputDir, "circleci_orb_removed.md")
+		Expect(ioutil.WriteFile(stale, []byte("stale"), 0600)).To(Succeed())
+
+		Eventually(docs("markdown", "--clean")).Should(gexec.Exit(0))
+
+		Expect(stale).ToNot(BeAnExistingFile())
+		Expect(filepath.Join(outputDir, "circleci_orb.md")).To(BeAnExistingFile())
+	})
+
+	It("only cleans markdown", func() {
+		session := docs("json", "--clean")
+		Eventually(session.Err).Should(gbytes.Say("Error: --clean can only be used with --format=markdown"))
+		Eventually(session).Should(clitest.ShouldFail())
+	})
+
+	It("rejects other formats", func() {
+		session := docs("html")
+		Eventually(session.Err).Should(gbytes.Say("Error: invalid --format html, expected json, yaml or markdown"))
+		Eventually(session).Should(clitest.ShouldFail())
+	})
+
+	It("isn't listed in the help", func() {
+		command = commandWithHome(pathCLI, tempSettings.Home, "help", "--skip-update-check")
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(0))
+		Expect(string(session.Out.Contents())).ToNot(MatchRegexp(`(?m)^\s+docs\s`))
+	})
+})
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// jsonField is the path given with --field, which selects the part of a
+// command's JSON output to print.
+var jsonField string
+
+// addFieldFlag adds --field to cmd, which must also have a --json flag.
+// Giving --field without --json is an error.
+func addFieldFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&jsonField, "field", "", "with --json, print only the value at a dotted path of the output, such as `latest.version` or orbs.0.name")
+
+	preRunE, preRun := cmd.PreRunE, cmd.PreRun
+	cmd.PreRun = nil
+	cmd.PreRunE = func(c *cobra.Command, args []string) error {
+		if asJSON, _ := c.Flags().GetBool("json"); jsonField != "" && !asJSON {
+			return errors.New("--field can only be used with --json")
+		}
+		if preRunE != nil {
+			return preRunE(c, args)
+		}
+		if preRun != nil {
+			preRun(c, args)
+		}
+		return nil
+	}
+}
+
+// marshalJSON encodes v as indented JSON, or only the part of it selected
+// with --field. A selected string is returned without quotes, so that
+// scripts can use it as it is.
+func marshalJSON(v interface{}) ([]byte, error) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil || jsonField == "" {
+		return out, err
+	}
+
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	selected, err := selectJSONField(doc, jsonField)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := selected.(string); ok {
+		return []byte(s), nil
+	}
+	return json.MarshalIndent(selected, "", "  ")
+}
+
+// selectJSONField returns the value at the dotted path in doc. Each part of
+// the path is the key of an object or the index of an array.
+func selectJSONField(doc interface{}, path string) (interface{}, error) {
+	value := doc
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		at := strings.Join(parts[:i], ".")
+		if at == "" {
+			at = "the output"
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			field, ok := v[part]
+			if !ok {
+				return nil, fmt.Errorf("invalid --field %s, %s has no field %s", path, at, part)
+			}
+			value = field
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --field %s, %s is a list, expected an index in place of %s", path, at, part)
+			}
+			if index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("invalid --field %s, %s has %d items, there is no index %d", path, at, len(v), index)
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("invalid --field %s, %s has no fields", path, at)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSON field selection", func() {
+	output := map[string]interface{}{
+		"name":   "circleci/node",
+		"latest": map[string]interface{}{"version": "5.0.2", "total": 12},
+		"orbs":   []map[string]string{{"name": "first"}, {"name": "second"}},
+	}
+
+	AfterEach(func() {
+		jsonField = ""
+	})
+
+	It("prints the whole output without --field", func() {
+		out, err := marshalJSON(map[string]string{"name": "circleci/node"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(out)).To(Equal("{\n  \"name\": \"circleci/node\"\n}"))
+	})
+
+	It("prints a selected string without quotes", func() {
+		jsonField = "latest.version"
+		out, err := marshalJSON(output)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(out)).To(Equal("5.0.2"))
+	})
+
+	It("prints a selected number or subtree as json", func() {
+		jsonField = "latest.total"
+		out, err := marshalJSON(output)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(out)).To(Equal("12"))
+
+		jsonField = "orbs.1"
+		out, err = marshalJSON(output)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(out)).To(Equal("{\n  \"name\": \"second\"\n}"))
+	})
+
+	It("indexes into lists", func() {
+		jsonField = "orbs.0.name"
+		out, err := marshalJSON(output)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(out)).To(Equal("first"))
+	})
+
+	It("reports invalid paths", func() {
+		for field, message := range map[string]string{
+			"missing":        "invalid --field missing, the output has no field missing",
+			"latest.missing": "invalid --field latest.missing, latest has no field missing",
+			"orbs.first":     "invalid --field orbs.first, orbs is a list, expected an index in place of first",
+			"orbs.2":         "invalid --field orbs.2, orbs has 2 items, there is no index 2",
+			"name.first":     "invalid --field name.first, name has no fields",
+		} {
+			jsonField = field
+			_, err := marshalJSON(output)
+			Expect(err).To(MatchError(message))
+		}
+	})
+})
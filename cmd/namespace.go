@@ -2,12 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 
 	"github.com/CircleCI-Public/circleci-cli/api"
 	"github.com/CircleCI-Public/circleci-cli/api/graphql"
 	"github.com/CircleCI-Public/circleci-cli/prompt"
 	"github.com/CircleCI-Public/circleci-cli/settings"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -15,9 +19,12 @@ type namespaceOptions struct {
 	cfg  *settings.Config
 	cl   *graphql.Client
 	args []string
+	org  orgOptions
 
 	// Allows user to skip y/n confirm when creating a namespace
 	noPrompt bool
+	// Linked with --json flag of the list command
+	listJSON bool
 	// This lets us pass in our own interface for testing
 	tty createNamespaceUserInterface
 	// Linked with --integration-testing flag for stubbing UI in gexec tests
@@ -47,6 +54,7 @@ func newNamespaceCommand(config *settings.Config) *cobra.Command {
 	opts := namespaceOptions{
 		cfg: config,
 		tty: createNamespaceInteractiveUI{},
+		org: orgOptions{cfg: config},
 	}
 
 	namespaceCmd := &cobra.Command{
@@ -74,7 +82,7 @@ Please note that at this time all namespaces created in the registry are world-r
 
 			return createNamespace(opts)
 		},
-		Args:        cobra.ExactArgs(3),
+		Args:        orgArgs(&opts.org, 1),
 		Annotations: make(map[string]string),
 	}
 
@@ -87,8 +95,73 @@ Please note that at this time all namespaces created in the registry are world-r
 		panic(err)
 	}
 	createCmd.Flags().BoolVar(&opts.noPrompt, "no-prompt", false, "Disable prompt to bypass interactive UI.")
+	addOrgFlags(createCmd.Flags(), &opts.org)
+
+	renameCmd := &cobra.Command{
+		Use:   "rename <old-name> <new-name>",
+		Short: "Rename a namespace",
+		Long: `Rename a namespace.
+
+The old name is kept as an alias of the new one, so that existing references to
+its orbs continue to work until the alias is deleted.`,
+		PreRunE: func(_ *cobra.Command, args []string) error {
+			opts.args = args
+			opts.cl = graphql.NewClient(config.HTTPClient, config.Host, config.Endpoint, config.Token, config.Debug)
+
+			return validateToken(opts.cfg)
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if opts.integrationTesting {
+				opts.tty = createNamespaceTestUI{
+					confirm: true,
+				}
+			}
+
+			return renameNamespace(opts)
+		},
+		Args:        cobra.ExactArgs(2),
+		Annotations: make(map[string]string),
+	}
+
+	renameCmd.Annotations["<old-name>"] = "The current name of the namespace"
+	renameCmd.Annotations["<new-name>"] = "The new name you want to give the namespace"
+
+	renameCmd.Flags().BoolVar(&opts.integrationTesting, "integration-testing", false, "Enable test mode to bypass interactive UI.")
+	if err := renameCmd.Flags().MarkHidden("integration-testing"); err != nil {
+		panic(err)
+	}
+	renameCmd.Flags().BoolVar(&opts.noPrompt, "no-prompt", false, "Disable prompt to bypass interactive UI.")
+
+	listCmd := &cobra.Command{
+		Use:   "list [<vcs-type> <org-name>]",
+		Short: "List the namespaces of an organization",
+		Long: `List the namespaces of an organization that you can see.
+
+The organization is given with --org-id, --org-slug or the <vcs-type> <org-name>
+arguments, or otherwise is the default organization of the config file.`,
+		PreRunE: func(_ *cobra.Command, args []string) error {
+			opts.args = args
+			opts.cl = graphql.NewClient(config.HTTPClient, config.Host, config.Endpoint, config.Token, config.Debug)
+
+			return validateToken(opts.cfg)
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return listNamespaces(opts)
+		},
+		Args:        orgArgs(&opts.org, 0),
+		Annotations: make(map[string]string),
+	}
+
+	listCmd.Annotations["<vcs-type>"] = `Your VCS provider, can be either "github" or "bitbucket"`
+	listCmd.Annotations["<org-name>"] = `The name used for your organization`
+
+	listCmd.Flags().BoolVar(&opts.listJSON, "json", false, "print the namespaces as JSON")
+	addOrgFlags(listCmd.Flags(), &opts.org)
+	addFieldFlag(listCmd)
 
 	namespaceCmd.AddCommand(createCmd)
+	namespaceCmd.AddCommand(renameCmd)
+	namespaceCmd.AddCommand(listCmd)
 
 	return namespaceCmd
 }
@@ -106,6 +179,11 @@ func deleteNamespaceAlias(opts namespaceOptions) error {
 func createNamespace(opts namespaceOptions) error {
 	namespaceName := opts.args[0]
 
+	org, _, err := opts.org.organizationID(opts.args, 1)
+	if err != nil {
+		return err
+	}
+
 	if !opts.noPrompt {
 		fmt.Printf(`You are creating a namespace called "%s".
 
@@ -113,28 +191,50 @@ This is the only namespace permitted for your %s organization, %s.
 
 To change the namespace, you will have to contact CircleCI customer support.
 
-`, namespaceName, strings.ToLower(opts.args[1]), opts.args[2])
+`, namespaceName, strings.ToLower(org.VCSType), org.Name)
 	}
 
 	confirm := fmt.Sprintf("Are you sure you wish to create the namespace: `%s`", namespaceName)
 	if opts.noPrompt || opts.tty.askUserToConfirm(confirm) {
-		_, err := api.CreateNamespace(opts.cl, namespaceName, opts.args[2], strings.ToUpper(opts.args[1]))
+		_, err := api.CreateNamespaceWithOwnerID(opts.cl, namespaceName, org.ID)
 
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Namespace `%s` created.\n", namespaceName)
-		fmt.Println("Please note that any orbs you publish in this namespace are open orbs and are world-readable.")
+		infof("Namespace `%s` created.\n", namespaceName)
+		infoln("Please note that any orbs you publish in this namespace are open orbs and are world-readable.")
 	}
 
 	return nil
 }
 
+// namespaceName matches the names that the registry allows for namespaces.
+var namespaceName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+func validateNamespaceName(name string) error {
+	if !namespaceName.MatchString(name) {
+		return fmt.Errorf("invalid namespace name %s, a namespace name may only contain lowercase letters, numbers, - and _", name)
+	}
+	return nil
+}
+
 func renameNamespace(opts namespaceOptions) error {
 	oldName := opts.args[0]
 	newName := opts.args[1]
 
+	if err := validateNamespaceName(newName); err != nil {
+		return err
+	}
+
+	if !opts.noPrompt {
+		fmt.Printf(`Renaming the namespace will change how its orbs are referenced, from %s/<orb> to %s/<orb>.
+
+Configs and orbs that refer to them as %s/<orb> will break once the %s alias is deleted.
+
+`, oldName, newName, oldName, oldName)
+	}
+
 	confirm := fmt.Sprintf("Are you sure you wish to rename the namespace `%s` to `%s`?", oldName, newName)
 	if opts.noPrompt || opts.tty.askUserToConfirm(confirm) {
 		_, err := api.RenameNamespace(opts.cl, oldName, newName)
@@ -143,7 +243,47 @@ func renameNamespace(opts namespaceOptions) error {
 			return err
 		}
 
-		fmt.Printf("Namespace `%s` renamed to `%s`. `%s` is an alias for `%s` so existing usages will continue to work, unless you delete the `%s` alias with `delete-namespace-alias %s`", oldName, newName, oldName, newName, oldName, oldName)
+		infof("Namespace `%s` renamed to `%s`. `%s` is an alias for `%s` so existing usages will continue to work, unless you delete the `%s` alias with `delete-namespace-alias %s`\n", oldName, newName, oldName, newName, oldName, oldName)
+		infof("Orbs in the namespace are now referenced as `%s/<orb>`.\n", newName)
 	}
 	return nil
 }
+
+func listNamespaces(opts namespaceOptions) error {
+	org, _, err := opts.org.organizationID(opts.args, 0)
+	if err != nil {
+		return err
+	}
+	slug := fmt.Sprintf("%s/%s", strings.ToLower(org.VCSType), org.Name)
+
+	namespaces, err := api.ListOrganizationNamespaces(opts.cl, org.ID)
+	if err != nil {
+		if isAuthorizationFailure(err) {
+			return fmt.Errorf("you don't have permission to list the namespaces of the organization %s, only its members can: %s", slug, errors.Cause(err))
+		}
+		return err
+	}
+
+	if opts.listJSON {
+		out, err := marshalJSON(namespaces)
+		if err != nil {
+			return errors.Wrap(err, "Failed to convert to JSON")
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	if len(namespaces) == 0 {
+		infof("The organization %s has no namespaces.\n", slug)
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "ID", "Created At"})
+	for _, namespace := range namespaces {
+		table.Append([]string{namespace.Name, namespace.ID, namespace.CreatedAt})
+	}
+	table.Render()
+
+	return nil
+}
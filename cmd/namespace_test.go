@@ -235,4 +235,322 @@ Please note that any orbs you publish in this namespace are open orbs and are wo
 			})
 		})
 	})
+
+	Context("create, with --org-id or --org-slug", func() {
+		expectedNsRequest := `{
+            "query": "\n\t\t\tmutation($name: String!, $organizationId: UUID!) {\n\t\t\t\tcreateNamespace(\n\t\t\t\t\tname: $name,\n\t\t\t\t\torganizationId: $organizationId\n\t\t\t\t) {\n\t\t\t\t\tnamespace {\n\t\t\t\t\t\tid\n\t\t\t\t\t}\n\t\t\t\t\terrors {\n\t\t\t\t\t\tmessage\n\t\t\t\t\t\ttype\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t}",
+            "variables": {
+              "name": "foo-ns",
+              "organizationId": "bb604b45-b6b0-4b81-ad80-796f15eddf87"
+            }
+          }`
+
+		gqlNsResponse := `{
+			"createNamespace": {
+				"errors": [],
+				"namespace": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87"}
+			}
+		}`
+
+		It("creates the namespace for the organization with the given ID", func() {
+			command = exec.Command(pathCLI,
+				"namespace", "create",
+				"--skip-update-check",
+				"--token", token,
+				"--host", tempSettings.TestServer.URL(),
+				"--no-prompt",
+				"--org-id", "bb604b45-b6b0-4b81-ad80-796f15eddf87",
+				"foo-ns",
+			)
+
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status: http.StatusOK,
+				Request: `{
+            "query": "query($id: ID!) {\n\t\t\t\torganization(id: $id) {\n\t\t\t\t\tid\n\t\t\t\t\tname\n\t\t\t\t\tvcsType\n\t\t\t\t}\n\t\t\t}","variables":{"id":"bb604b45-b6b0-4b81-ad80-796f15eddf87"}}`,
+				Response: `{"organization": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87", "name": "test-org", "vcsType": "BITBUCKET"}}`})
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:   http.StatusOK,
+				Request:  expectedNsRequest,
+				Response: gqlNsResponse})
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Out).Should(gbytes.Say("Namespace `foo-ns` created."))
+			Eventually(session).Should(gexec.Exit(0))
+		})
+
+		It("fails when the slug and ID are different organizations", func() {
+			command = exec.Command(pathCLI,
+				"namespace", "create",
+				"--skip-update-check",
+				"--token", token,
+				"--host", tempSettings.TestServer.URL(),
+				"--no-prompt",
+				"--org-slug", "bitbucket/test-org",
+				"--org-id", "some-other-id",
+				"foo-ns",
+			)
+
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status: http.StatusOK,
+				Request: `{
+            "query": "query($organizationName: String!, $organizationVcs: VCSType!) {\n\t\t\t\torganization(\n\t\t\t\t\tname: $organizationName\n\t\t\t\t\tvcsType: $organizationVcs\n\t\t\t\t) {\n\t\t\t\t\tid\n\t\t\t\t}\n\t\t\t}","variables":{"organizationName":"test-org","organizationVcs":"BITBUCKET"}}`,
+				Response: `{"organization": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87"}}`})
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say("Error: --org-slug bitbucket/test-org and --org-id some-other-id are different organizations, the ID of bitbucket/test-org is bb604b45-b6b0-4b81-ad80-796f15eddf87"))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+
+		It("requires an organization", func() {
+			command = exec.Command(pathCLI,
+				"namespace", "create",
+				"--skip-update-check",
+				"--token", token,
+				"--host", tempSettings.TestServer.URL(),
+				"foo-ns",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say("Error: no organization was given"))
+			Eventually(session.Err).Should(gbytes.Say("circleci settings set default_org_id <id>"))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+
+		It("falls back to the default organization of the config file", func() {
+			tempSettings.Config.Write([]byte(`default_org_id: bb604b45-b6b0-4b81-ad80-796f15eddf87`))
+			command = commandWithHome(pathCLI, tempSettings.Home,
+				"namespace", "create",
+				"--skip-update-check",
+				"--token", token,
+				"--host", tempSettings.TestServer.URL(),
+				"--no-prompt",
+				"foo-ns",
+			)
+
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status: http.StatusOK,
+				Request: `{
+            "query": "query($id: ID!) {\n\t\t\t\torganization(id: $id) {\n\t\t\t\t\tid\n\t\t\t\t\tname\n\t\t\t\t\tvcsType\n\t\t\t\t}\n\t\t\t}","variables":{"id":"bb604b45-b6b0-4b81-ad80-796f15eddf87"}}`,
+				Response: `{"organization": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87", "name": "test-org", "vcsType": "BITBUCKET"}}`})
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:   http.StatusOK,
+				Request:  expectedNsRequest,
+				Response: gqlNsResponse})
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say("Using the default organization bitbucket/test-org \\(bb604b45-b6b0-4b81-ad80-796f15eddf87\\)."))
+			Eventually(session.Out).Should(gbytes.Say("Namespace `foo-ns` created."))
+			Eventually(session).Should(gexec.Exit(0))
+		})
+
+		It("prefers the <vcs-type> <org-name> arguments to the default organization", func() {
+			tempSettings.Config.Write([]byte(`default_org_id: some-other-id`))
+			command = commandWithHome(pathCLI, tempSettings.Home,
+				"namespace", "create",
+				"--skip-update-check",
+				"--token", token,
+				"--host", tempSettings.TestServer.URL(),
+				"--no-prompt",
+				"foo-ns", "BITBUCKET", "test-org",
+			)
+
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status: http.StatusOK,
+				Request: `{
+            "query": "query($organizationName: String!, $organizationVcs: VCSType!) {\n\t\t\t\torganization(\n\t\t\t\t\tname: $organizationName\n\t\t\t\t\tvcsType: $organizationVcs\n\t\t\t\t) {\n\t\t\t\t\tid\n\t\t\t\t}\n\t\t\t}","variables":{"organizationName":"test-org","organizationVcs":"BITBUCKET"}}`,
+				Response: `{"organization": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87"}}`})
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:   http.StatusOK,
+				Request:  expectedNsRequest,
+				Response: gqlNsResponse})
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Out).Should(gbytes.Say("Namespace `foo-ns` created."))
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Err.Contents())).ToNot(ContainSubstring("default organization"))
+		})
+	})
+
+	Describe("renaming a namespace", func() {
+		expectedGetNsRequest := `{
+			"query": "\n\t\t\t\tquery($name: String!) {\n\t\t\t\t\tregistryNamespace(\n\t\t\t\t\t\tname: $name\n\t\t\t\t\t){\n\t\t\t\t\t\tid\n\t\t\t\t\t}\n\t\t\t }",
+			"variables": {"name": "ns-0"}
+		}`
+		expectedRenameRequest := `{
+			"query": "\n\t\tmutation($namespaceId: UUID!, $newName: String!){\n\t\t\trenameNamespace(\n\t\t\t\tnamespaceId: $namespaceId,\n\t\t\t\tnewName: $newName\n\t\t\t){\n\t\t\t\tnamespace {\n\t\t\t\t\tid\n\t\t\t\t}\n\t\t\t\terrors {\n\t\t\t\t\tmessage\n\t\t\t\t\ttype\n\t\t\t\t}\n\t\t\t}\n\t\t}",
+			"variables": {"newName": "ns-1", "namespaceId": "bb604b45-b6b0-4b81-ad80-796f15eddf87"}
+		}`
+
+		appendRenameHandlers := func() {
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:   http.StatusOK,
+				Request:  expectedGetNsRequest,
+				Response: `{"registryNamespace": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87"}}`})
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:   http.StatusOK,
+				Request:  expectedRenameRequest,
+				Response: `{"renameNamespace": {"namespace": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87"}, "errors": []}}`})
+		}
+
+		It("asks for confirmation and prints the new orb reference prefix", func() {
+			command = exec.Command(pathCLI,
+				"namespace", "rename", "ns-0", "ns-1",
+				"--skip-update-check",
+				"--token", token,
+				"--host", tempSettings.TestServer.URL(),
+				"--integration-testing",
+			)
+			appendRenameHandlers()
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).Should(gbytes.Say("from ns-0/<orb> to ns-1/<orb>"))
+			Expect(session.Out).Should(gbytes.Say("Are you sure you wish to rename the namespace `ns-0` to `ns-1`?"))
+			Expect(session.Out).Should(gbytes.Say("Orbs in the namespace are now referenced as `ns-1/<orb>`."))
+		})
+
+		It("doesn't prompt with --no-prompt", func() {
+			command = exec.Command(pathCLI,
+				"namespace", "rename", "ns-0", "ns-1",
+				"--skip-update-check",
+				"--token", token,
+				"--host", tempSettings.TestServer.URL(),
+				"--no-prompt",
+			)
+			appendRenameHandlers()
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).ToNot(ContainSubstring("Are you sure"))
+			Expect(string(session.Out.Contents())).To(ContainSubstring("Orbs in the namespace are now referenced as `ns-1/<orb>`."))
+		})
+
+		It("validates the new name before renaming", func() {
+			command = exec.Command(pathCLI,
+				"namespace", "rename", "ns-0", "NS 1",
+				"--skip-update-check",
+				"--token", token,
+				"--host", tempSettings.TestServer.URL(),
+				"--no-prompt",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say("Error: invalid namespace name NS 1, a namespace name may only contain lowercase letters, numbers, - and _"))
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+
+	Describe("listing the namespaces of an organization", func() {
+		expectedOrgRequest := `{
+            "query": "query($id: ID!) {\n\t\t\t\torganization(id: $id) {\n\t\t\t\t\tid\n\t\t\t\t\tname\n\t\t\t\t\tvcsType\n\t\t\t\t}\n\t\t\t}","variables":{"id":"bb604b45-b6b0-4b81-ad80-796f15eddf87"}}`
+		expectedListRequest := func(after string) string {
+			return fmt.Sprintf(`{
+            "query": "\nquery organizationNamespaces ($id: ID!, $after: String!) {\n\torganization(id: $id) {\n\t\tid\n\t\tregistryNamespaces(first: 20, after: $after) {\n\t\t\tedges {\n\t\t\t\tcursor\n\t\t\t\tnode {\n\t\t\t\t\tid\n\t\t\t\t\tname\n\t\t\t\t\tcreatedAt\n\t\t\t\t}\n\t\t\t}\n\t\t\tpageInfo {\n\t\t\t\thasNextPage\n\t\t\t}\n\t\t}\n\t}\n}\n",
+            "variables": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87", "after": "%s"}
+          }`, after)
+		}
+
+		BeforeEach(func() {
+			command = exec.Command(pathCLI,
+				"namespace", "list",
+				"--skip-update-check",
+				"--token", token,
+				"--host", tempSettings.TestServer.URL(),
+				"--org-id", "bb604b45-b6b0-4b81-ad80-796f15eddf87",
+			)
+
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:   http.StatusOK,
+				Request:  expectedOrgRequest,
+				Response: `{"organization": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87", "name": "test-org", "vcsType": "GITHUB"}}`})
+		})
+
+		It("follows every page of namespaces", func() {
+			command.Args = append(command.Args, "--json")
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:  http.StatusOK,
+				Request: expectedListRequest(""),
+				Response: `{"organization": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87", "registryNamespaces": {
+					"edges": [{"cursor": "c1", "node": {"id": "ns-id-0", "name": "ns-0", "createdAt": "2021-03-01T10:00:00Z"}}],
+					"pageInfo": {"hasNextPage": true}}}}`})
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:  http.StatusOK,
+				Request: expectedListRequest("c1"),
+				Response: `{"organization": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87", "registryNamespaces": {
+					"edges": [{"cursor": "c2", "node": {"id": "ns-id-1", "name": "ns-1", "createdAt": "2021-04-01T10:00:00Z"}}],
+					"pageInfo": {"hasNextPage": false}}}}`})
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out.Contents()).Should(MatchJSON(`[
+				{"id": "ns-id-0", "name": "ns-0", "createdAt": "2021-03-01T10:00:00Z"},
+				{"id": "ns-id-1", "name": "ns-1", "createdAt": "2021-04-01T10:00:00Z"}
+			]`))
+		})
+
+		It("prints a table of the namespaces", func() {
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:  http.StatusOK,
+				Request: expectedListRequest(""),
+				Response: `{"organization": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87", "registryNamespaces": {
+					"edges": [{"cursor": "c1", "node": {"id": "ns-id-0", "name": "ns-0", "createdAt": "2021-03-01T10:00:00Z"}}],
+					"pageInfo": {"hasNextPage": false}}}}`})
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).Should(gbytes.Say("NAME"))
+			Expect(session.Out).Should(gbytes.Say(`ns-0\s+\|\s+ns-id-0\s+\|\s+2021-03-01T10:00:00Z`))
+		})
+
+		It("says when the organization has no namespaces", func() {
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:  http.StatusOK,
+				Request: expectedListRequest(""),
+				Response: `{"organization": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87", "registryNamespaces": {
+					"edges": [], "pageInfo": {"hasNextPage": false}}}}`})
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).Should(gbytes.Say("The organization github/test-org has no namespaces."))
+		})
+
+		It("prints an empty list as JSON", func() {
+			command.Args = append(command.Args, "--json")
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:  http.StatusOK,
+				Request: expectedListRequest(""),
+				Response: `{"organization": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87", "registryNamespaces": {
+					"edges": [], "pageInfo": {"hasNextPage": false}}}}`})
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal("[]\n"))
+		})
+
+		It("says when the caller lacks permission", func() {
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:        http.StatusOK,
+				Request:       expectedListRequest(""),
+				Response:      `null`,
+				ErrorResponse: `[{"message": "Not authorized to view the namespaces", "extensions": {"type": "AUTHORIZATION_FAILURE"}}]`})
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say("Error: you don't have permission to list the namespaces of the organization github/test-org, only its members can: Not authorized to view the namespaces"))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+	})
 })
@@ -2,7 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
 	"strings"
 
 	"github.com/CircleCI-Public/circleci-cli/git"
@@ -23,7 +28,56 @@ Unable detect which URL should be opened. This command is intended to be run fro
 a git repository with a remote named 'origin' that is hosted on Github or Bitbucket
 Error`
 
-func openProjectInBrowser() error {
+// browserEnvironment is what decides whether a browser can be launched.
+type browserEnvironment struct {
+	goos string
+	// procVersion is the contents of /proc/version, on Linux
+	procVersion string
+	display     string
+	hasXdgOpen  bool
+}
+
+func currentBrowserEnvironment() browserEnvironment {
+	env := browserEnvironment{
+		goos:    runtime.GOOS,
+		display: os.Getenv("DISPLAY"),
+	}
+	if env.goos == "linux" {
+		version, _ := ioutil.ReadFile("/proc/version")
+		env.procVersion = string(version)
+		_, err := exec.LookPath("xdg-open")
+		env.hasXdgOpen = err == nil
+	}
+	return env
+}
+
+// canLaunchBrowser reports whether a browser can be launched. On Linux it
+// can't be from WSL, without a display, such as on a headless CI machine, or
+// without xdg-open.
+func (env browserEnvironment) canLaunchBrowser() bool {
+	if env.goos != "linux" {
+		return true
+	}
+	if strings.Contains(strings.ToLower(env.procVersion), "microsoft") {
+		return false
+	}
+	return env.display != "" && env.hasXdgOpen
+}
+
+// openURL launches the browser. It is a variable so that tests don't.
+var openURL = browser.OpenURL
+
+// openInBrowser opens link in the browser, or prints it to out when printURL
+// is set or there is no browser to open it in, so that it can be copied.
+func openInBrowser(out io.Writer, link string, printURL bool, env browserEnvironment) error {
+	if !printURL && env.canLaunchBrowser() && openURL(link) == nil {
+		return nil
+	}
+	_, err := fmt.Fprintln(out, link)
+	return err
+}
+
+func openProjectInBrowser(printURL bool) error {
 
 	remote, err := git.InferProjectFromGitRemotes()
 
@@ -31,17 +85,25 @@ func openProjectInBrowser() error {
 		return errors.Wrap(err, errorMessage)
 	}
 
-	return browser.OpenURL(projectUrl(remote))
+	return openInBrowser(os.Stdout, projectUrl(remote), printURL, currentBrowserEnvironment())
 }
 
 func newOpenCommand() *cobra.Command {
+	var printURL bool
 
 	openCommand := &cobra.Command{
 		Use:   "open",
 		Short: "Open the current project in the browser.",
+		Long: `Open the current project in the browser.
+
+When a browser can't be launched, such as on a headless machine or in WSL, the
+URL of the project is printed instead.`,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			return openProjectInBrowser()
+			return openProjectInBrowser(printURL)
 		},
 	}
+
+	openCommand.Flags().BoolVar(&printURL, "print-url", false, "print the URL of the project instead of opening it")
+
 	return openCommand
 }
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"bytes"
+
 	"github.com/CircleCI-Public/circleci-cli/git"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/browser"
+	"github.com/pkg/errors"
 )
 
 var _ = Describe("open", func() {
@@ -22,4 +26,83 @@ var _ = Describe("open", func() {
 			VcsType:      git.Bitbucket,
 		})).To(Equal("https://app.circleci.com/pipelines/bitbucket/%25%5E&%2A%28%29%5B%5D/%2Fone%2Ftwo"))
 	})
+
+	Describe("launching a browser", func() {
+		desktop := browserEnvironment{goos: "linux", procVersion: "Linux version 5.10.0", display: ":0", hasXdgOpen: true}
+
+		It("can on a Linux desktop", func() {
+			Expect(desktop.canLaunchBrowser()).To(BeTrue())
+		})
+
+		It("can on other platforms", func() {
+			Expect(browserEnvironment{goos: "darwin"}.canLaunchBrowser()).To(BeTrue())
+		})
+
+		It("can't in WSL", func() {
+			wsl := desktop
+			wsl.procVersion = "Linux version 5.10.16.3-microsoft-standard-WSL2"
+			Expect(wsl.canLaunchBrowser()).To(BeFalse())
+		})
+
+		It("can't without a display", func() {
+			headless := desktop
+			headless.display = ""
+			Expect(headless.canLaunchBrowser()).To(BeFalse())
+		})
+
+		It("can't without xdg-open", func() {
+			noXdgOpen := desktop
+			noXdgOpen.hasXdgOpen = false
+			Expect(noXdgOpen.canLaunchBrowser()).To(BeFalse())
+		})
+	})
+
+	Describe("opening a URL", func() {
+		var (
+			out    *bytes.Buffer
+			opened []string
+			result error
+		)
+		url := "https://app.circleci.com/pipelines/github/bar/foo"
+		desktop := browserEnvironment{goos: "darwin"}
+
+		BeforeEach(func() {
+			out = &bytes.Buffer{}
+			opened = nil
+			result = nil
+			openURL = func(url string) error {
+				opened = append(opened, url)
+				return result
+			}
+		})
+
+		AfterEach(func() {
+			openURL = browser.OpenURL
+		})
+
+		It("opens it in the browser", func() {
+			Expect(openInBrowser(out, url, false, desktop)).To(Succeed())
+			Expect(opened).To(Equal([]string{url}))
+			Expect(out.String()).To(BeEmpty())
+		})
+
+		It("prints it with --print-url", func() {
+			Expect(openInBrowser(out, url, true, desktop)).To(Succeed())
+			Expect(opened).To(BeEmpty())
+			Expect(out.String()).To(Equal(url + "\n"))
+		})
+
+		It("prints it when there is no browser", func() {
+			Expect(openInBrowser(out, url, false, browserEnvironment{goos: "linux"})).To(Succeed())
+			Expect(opened).To(BeEmpty())
+			Expect(out.String()).To(Equal(url + "\n"))
+		})
+
+		It("prints it when the browser fails to launch", func() {
+			result = errors.New("exec: \"xdg-open\": executable file not found in $PATH")
+			Expect(openInBrowser(out, url, false, desktop)).To(Succeed())
+			Expect(opened).To(Equal([]string{url}))
+			Expect(out.String()).To(Equal(url + "\n"))
+		})
+	})
 })
@@ -3,6 +3,7 @@ package cmd
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -43,9 +44,29 @@ type orbOptions struct {
 
 	listUncertified bool
 	listJSON        bool
+	listJSONL       bool
 	listDetails     bool
+	infoJSON        bool
+	unlistJSON      bool
+	deleteJSON      bool
 	private         bool
 	sortBy          string
+	sortReverse     bool
+	// Validate an orb and show what would be published, without publishing it
+	dryRun bool
+	// Send the orb to the API without checking it against the orb schema first
+	skipLocalSchema bool
+	// What to print to stdout when an orb is valid, processed or source
+	validateOutput string
+	// Which form of the orb `orb process` prints, processed or source, and
+	// whether as yaml or json
+	processOutput string
+	processFormat string
+	// The version of the orb to show the source of, in place of <orb>@<version>
+	sourceVersion string
+	// Re-pack, and optionally validate, an orb each time its source changes
+	watch         bool
+	watchValidate bool
 	// Allows user to skip y/n confirm when creating an orb
 	noPrompt bool
 	// This lets us pass in our own interface for testing
@@ -100,25 +121,34 @@ func newOrbCommand(config *settings.Config) *cobra.Command {
 	}
 	listCommand.Annotations["<namespace>"] = orbAnnotations["<namespace>"] + " (Optional)"
 
-	listCommand.PersistentFlags().StringVar(&opts.sortBy, "sort", "", `one of "builds"|"projects"|"orgs"`)
+	listCommand.PersistentFlags().StringVar(&opts.sortBy, "sort", "", `sort the orbs once all of them are listed, by one of "name"|"created"|"usage"|"builds"|"projects"|"orgs", where usage is the same as builds`)
+	listCommand.PersistentFlags().BoolVar(&opts.sortReverse, "reverse", false, "reverse the order of --sort")
 	listCommand.PersistentFlags().BoolVarP(&opts.listUncertified, "uncertified", "u", false, "include uncertified orbs")
-	listCommand.PersistentFlags().BoolVar(&opts.listJSON, "json", false, "print output as json instead of human-readable")
+	listCommand.PersistentFlags().BoolVar(&opts.listJSON, "json", false, "print every orb, following all pages of results, as json instead of human-readable")
+	listCommand.PersistentFlags().BoolVar(&opts.listJSONL, "jsonl", false, "print each orb as a line of json as soon as its page of results arrives, rather than all of them at the end as with --json")
+	addFieldFlag(listCommand)
 	listCommand.PersistentFlags().BoolVarP(&opts.listDetails, "details", "d", false, "output all the commands, executors, and jobs, along with a tree of their parameters")
 	listCommand.PersistentFlags().BoolVarP(&opts.private, "private", "", false, "exclusively list private orbs within a namespace")
-	if err := listCommand.PersistentFlags().MarkHidden("json"); err != nil {
-		panic(err)
-	}
 
 	validateCommand := &cobra.Command{
 		Use:   "validate <path>",
 		Short: "Validate an orb.yml",
+		Long: strings.Join([]string{
+			"Validate one or more orbs. Each <path> may be a glob, for example: orbs/*/orb.yml",
+			"", // purposeful new-line
+			"When more than one orb is validated a summary is printed, and the command fails if any orb is invalid.",
+			"Each orb is checked against the orb schema locally before it's sent to the server, errors found by this check say so.",
+			"Use -- before paths that look like flags.",
+		}, "\n"),
 		RunE: func(_ *cobra.Command, _ []string) error {
 			return validateOrb(opts)
 		},
-		Args:        cobra.ExactArgs(1),
+		Args:        cobra.MinimumNArgs(1),
 		Annotations: make(map[string]string),
 	}
 	validateCommand.Annotations["<path>"] = orbAnnotations["<path>"]
+	validateCommand.Flags().BoolVar(&opts.skipLocalSchema, "skip-local-schema", false, "don't check the orb against the orb schema before sending it to the server")
+	validateCommand.Flags().StringVar(&opts.validateOutput, "output", "", "when the orb is valid, also print it to stdout, either processed, as the server expands it, or source, as it was sent to the server. The result is then printed to stderr")
 
 	processCommand := &cobra.Command{
 		Use:   "process <path>",
@@ -127,6 +157,8 @@ func newOrbCommand(config *settings.Config) *cobra.Command {
 			"Use `$ circleci orb process` to resolve an orb, and it's dependencies to see how it would be expanded when you publish it to the registry.",
 			"", // purposeful new-line
 			"This can be helpful for validating an orb and debugging the processed form before publishing.",
+			"", // purposeful new-line
+			"<path> may also be a directory of unpacked orb source, which is packed first, as it is for `$ circleci orb pack`.",
 		}, "\n"),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			opts.args = args
@@ -138,18 +170,34 @@ func newOrbCommand(config *settings.Config) *cobra.Command {
 		Args:        cobra.ExactArgs(1),
 		Annotations: make(map[string]string),
 	}
-	processCommand.Example = `  circleci orb process src/my-orb/@orb.yml`
+	processCommand.Example = `  circleci orb process src/my-orb/@orb.yml
+  circleci orb process src --output-format json`
 	processCommand.Annotations["<path>"] = orbAnnotations["<path>"]
+	processCommand.Flags().StringVar(&opts.processOutput, "output", "processed", "which form of the orb to print, either processed, with the orbs it uses expanded, or source, as it was sent to the server")
+	processCommand.Flags().StringVar(&opts.processFormat, "output-format", "yaml", "format of the printed orb, either yaml or json")
 
 	publishCommand := &cobra.Command{
 		Use:   "publish <path> <orb>",
 		Short: "Publish an orb to the registry",
 		Long: `Publish an orb to the registry.
-Please note that at this time all orbs published to the registry are world-readable.`,
+Please note that at this time all orbs published to the registry are world-readable.
+
+<path> can also be a directory of orb source, which is packed before it is published.
+
+<orb> must have a version, either a semantic version such as 1.2.3, or a dev
+version such as dev:alpha. A leading v, as in v1.2.3, is dropped. Dev versions
+can be overwritten by anyone in your organization, and expire after 90 days.
+
+With --dry-run the orb is only validated, and what would have been published is
+printed, so that it can be checked before the version is created.`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			return publishOrb(opts)
 		},
 		PreRunE: func(_ *cobra.Command, _ []string) error {
+			// Validating an orb doesn't need a token
+			if opts.dryRun {
+				return nil
+			}
 			return validateToken(opts.cfg)
 		},
 		Args:        cobra.ExactArgs(2),
@@ -157,6 +205,8 @@ Please note that at this time all orbs published to the registry are world-reada
 	}
 	publishCommand.Annotations["<orb>"] = orbAnnotations["<orb>"]
 	publishCommand.Annotations["<path>"] = orbAnnotations["<path>"]
+	publishCommand.Flags().BoolVar(&opts.dryRun, "dry-run", false, "validate the orb and print what would be published, without publishing it")
+	publishCommand.Flags().BoolVar(&opts.skipLocalSchema, "skip-local-schema", false, "don't check the orb against the orb schema before sending it to the server")
 
 	promoteCommand := &cobra.Command{
 		Use:   "promote <orb> <segment>",
@@ -183,6 +233,10 @@ Example: 'circleci orb publish promote foo/bar@dev:master major' => foo/bar@1.0.
 		Long: `Increment a released version of an orb.
 Please note that at this time all orbs incremented within the registry are world-readable.
 
+The orb must already have a released version to increment. With --dry-run the
+orb is only validated, and the version it would have been published as is
+printed.
+
 Example: 'circleci orb publish increment foo/orb.yml foo/bar minor' => foo/bar@1.1.0`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			return incrementOrb(opts)
@@ -196,27 +250,81 @@ Example: 'circleci orb publish increment foo/orb.yml foo/bar minor' => foo/bar@1
 	}
 	incrementCommand.Annotations["<path>"] = orbAnnotations["<path>"]
 	incrementCommand.Annotations["<segment>"] = `"major"|"minor"|"patch"`
+	incrementCommand.Flags().BoolVar(&opts.dryRun, "dry-run", false, "validate the orb and print the version it would be published as, without publishing it")
+	incrementCommand.Flags().BoolVar(&opts.skipLocalSchema, "skip-local-schema", false, "don't check the orb against the orb schema before sending it to the server")
 
 	publishCommand.AddCommand(promoteCommand)
 	publishCommand.AddCommand(incrementCommand)
 
 	unlistCmd := &cobra.Command{
-		Use:   "unlist <namespace>/<orb> <true|false>",
+		Use:   "unlist <namespace>/<orb> [true|false]",
 		Short: "Disable or enable an orb's listing in the registry",
 		Long: `Disable or enable an orb's listing in the registry.
 This only affects whether the orb is displayed in registry search results;
 the orb remains world-readable as long as referenced with a valid name.
 
-Example: Run 'circleci orb unlist foo/bar true' to disable the listing of the
-orb in the registry and 'circleci orb unlist foo/bar false' to re-enable the
-listing of the orb in the registry.`,
+Example: Run 'circleci orb unlist foo/bar' or 'circleci orb unlist foo/bar true'
+to disable the listing of the orb in the registry and
+'circleci orb unlist foo/bar false' to re-enable the listing of the orb in the
+registry.
+
+When only the orb is given, you are asked to confirm unlisting it unless
+--no-prompt is given.`,
 		RunE: func(_ *cobra.Command, _ []string) error {
+			if opts.integrationTesting {
+				opts.tty = createOrbTestUI{
+					confirm: true,
+				}
+			}
+
 			return setOrbListStatus(opts)
 		},
 		PreRunE: func(_ *cobra.Command, _ []string) error {
 			return validateToken(opts.cfg)
 		},
-		Args: cobra.ExactArgs(2),
+		Args: cobra.RangeArgs(1, 2),
+	}
+	unlistCmd.Flags().BoolVar(&opts.unlistJSON, "json", false, "print the resulting listing state as json")
+	addFieldFlag(unlistCmd)
+	unlistCmd.Flags().BoolVar(&opts.noPrompt, "no-prompt", false, "Disable prompt to bypass interactive UI.")
+	unlistCmd.Flags().BoolVar(&opts.integrationTesting, "integration-testing", false, "Enable test mode to bypass interactive UI.")
+	if err := unlistCmd.Flags().MarkHidden("integration-testing"); err != nil {
+		panic(err)
+	}
+
+	deleteVersionCmd := &cobra.Command{
+		Use:   "delete-version <namespace>/<orb>@dev:<label>",
+		Short: "Delete a dev version of an orb",
+		Long: `Delete a dev version of an orb, such as one that was published broken.
+
+Only dev versions can be deleted. Published versions such as 1.2.3 are
+immutable, so that configs that use them keep working; publish a fixed version
+instead, for example with 'circleci orb publish increment'.
+
+You are asked to confirm the deletion unless --force is given.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if opts.integrationTesting {
+				opts.tty = createOrbTestUI{
+					confirm: true,
+				}
+			}
+
+			return deleteOrbVersion(opts)
+		},
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return validateToken(opts.cfg)
+		},
+		Args:        cobra.ExactArgs(1),
+		Annotations: make(map[string]string),
+		Aliases:     []string{"retract"},
+	}
+	deleteVersionCmd.Annotations["<namespace>/<orb>@dev:<label>"] = "The dev version of the orb to delete, such as my-ns/foo-orb@dev:alpha"
+	deleteVersionCmd.Flags().BoolVar(&opts.noPrompt, "force", false, "delete the version without asking for confirmation")
+	deleteVersionCmd.Flags().BoolVar(&opts.deleteJSON, "json", false, "print the result as json")
+	addFieldFlag(deleteVersionCmd)
+	deleteVersionCmd.Flags().BoolVar(&opts.integrationTesting, "integration-testing", false, "Enable test mode to bypass interactive UI.")
+	if err := deleteVersionCmd.Flags().MarkHidden("integration-testing"); err != nil {
+		panic(err)
 	}
 
 	sourceCommand := &cobra.Command{
@@ -229,7 +337,11 @@ listing of the orb in the registry.`,
 		Annotations: make(map[string]string),
 	}
 	sourceCommand.Annotations["<orb>"] = orbAnnotations["<orb>"]
+	sourceCommand.Flags().StringVar(&opts.sourceVersion, "version", "", "the version of the orb to show the source of, in place of <orb>@<version>")
+	sourceCommand.ValidArgsFunction = completeOrbNames(config)
 	sourceCommand.Example = `  circleci orb source circleci/python@0.1.4 # grab the source at version 0.1.4
+  circleci orb source circleci/python --version 0.1.4 # the same
+  circleci orb source circleci/python # grab the source of the latest version
   circleci orb source my-ns/foo-orb@dev:latest # grab the source of dev release "latest"`
 
 	orbInfoCmd := &cobra.Command{
@@ -242,6 +354,9 @@ listing of the orb in the registry.`,
 		Annotations: make(map[string]string),
 	}
 	orbInfoCmd.Annotations["<orb>"] = orbAnnotations["<orb>"]
+	orbInfoCmd.Flags().BoolVar(&opts.infoJSON, "json", false, "print the meta-data as JSON, with the fields name, version, version_created_at, latest_version, created_at, last_updated_at, total_revisions, statistics, categories and dependencies")
+	addFieldFlag(orbInfoCmd)
+	orbInfoCmd.ValidArgsFunction = completeOrbNames(config)
 	orbInfoCmd.Example = `  circleci orb info circleci/python@0.1.4
   circleci orb info my-ns/foo-orb@dev:latest`
 
@@ -271,10 +386,16 @@ Please note that at this time all orbs created in the registry are world-readabl
 		Short: "Pack an Orb with local scripts.",
 		Long:  ``,
 		RunE: func(_ *cobra.Command, _ []string) error {
+			if opts.watch {
+				return watchOrbPack(opts)
+			}
 			return packOrbCommand(opts)
 		},
 		Args: cobra.ExactArgs(1),
 	}
+	orbPack.Flags().BoolVar(&opts.watch, "watch", false, "Keep running, and pack the orb again each time a file in <path> changes")
+	orbPack.Flags().BoolVar(&opts.watchValidate, "validate", false, "With --watch, also validate the orb each time it is packed")
+	orbPack.Flags().BoolVar(&opts.skipLocalSchema, "skip-local-schema", false, "With --watch --validate, don't check the orb against the orb schema before sending it to the server")
 
 	listCategoriesCommand := &cobra.Command{
 		Use:   "list-categories",
@@ -335,8 +456,9 @@ Please note that at this time all orbs created in the registry are world-readabl
 
 			// PersistentPreRunE overwrites the inherited persistent hook from rootCmd
 			// So we explicitly call it here to retain that behavior.
-			// As of writing this comment, that is only for daily update checks.
-			return rootCmdPreRun(rootOptions)
+			// As of writing this comment, that is for checking --host and daily
+			// update checks.
+			return rootCmdPreRun(cmd, rootOptions)
 		},
 	}
 
@@ -346,6 +468,7 @@ Please note that at this time all orbs created in the registry are world-readabl
 	orbCommand.AddCommand(processCommand)
 	orbCommand.AddCommand(publishCommand)
 	orbCommand.AddCommand(unlistCmd)
+	orbCommand.AddCommand(deleteVersionCmd)
 	orbCommand.AddCommand(sourceCommand)
 	orbCommand.AddCommand(orbInfoCmd)
 	orbCommand.AddCommand(orbPack)
@@ -505,7 +628,7 @@ func orbIsOpenSource(cl *graphql.Client, namespace string, orbName string) bool
 
 func formatListOrbsResult(list api.OrbsForListing, opts orbOptions) (string, error) {
 	if opts.listJSON {
-		orbJSON, err := json.MarshalIndent(list, "", "  ")
+		orbJSON, err := marshalJSON(list)
 		if err != nil {
 			return "", errors.Wrapf(err, "Failed to convert to JSON")
 		}
@@ -584,17 +707,17 @@ func logOrbCategories(orbCategoryCollection *api.OrbCategoriesForListing, opts o
 	return nil
 }
 
-var validSortFlag = map[string]bool{
-	"builds":   true,
-	"projects": true,
-	"orgs":     true}
-
 func validateSortFlag(sort string) error {
-	if _, valid := validSortFlag[sort]; valid {
-		return nil
+	for _, key := range api.OrbSortKeys {
+		if sort == key {
+			return nil
+		}
 	}
-	// TODO(zzak): we could probably reuse the map above to print the valid values
-	return fmt.Errorf("expected `%s` to be one of \"builds\", \"projects\", or \"orgs\"", sort)
+	quoted := make([]string, len(api.OrbSortKeys))
+	for i, key := range api.OrbSortKeys {
+		quoted[i] = fmt.Sprintf("%q", key)
+	}
+	return fmt.Errorf("expected `%s` to be one of %s, or %s", sort, strings.Join(quoted[:len(quoted)-1], ", "), quoted[len(quoted)-1])
 }
 
 func listOrbs(opts orbOptions) error {
@@ -602,23 +725,36 @@ func listOrbs(opts orbOptions) error {
 		if err := validateSortFlag(opts.sortBy); err != nil {
 			return err
 		}
+	} else if opts.sortReverse {
+		return errors.New("--reverse can only be used with --sort")
 	}
 
-	if len(opts.args) != 0 {
-		return listNamespaceOrbs(opts)
+	switch {
+	case opts.listJSONL && opts.listJSON:
+		return errors.New("--jsonl can't be used with --json")
+	case opts.listJSONL && opts.sortBy != "":
+		return errors.New("--jsonl can't be used with --sort, as the orbs are printed before all of them are known")
 	}
 
-	if opts.private {
+	if len(opts.args) == 0 && opts.private {
 		return errors.New("Namespace must be provided when listing private orbs")
 	}
 
+	if opts.listJSONL {
+		return streamOrbs(opts)
+	}
+
+	if len(opts.args) != 0 {
+		return listNamespaceOrbs(opts)
+	}
+
 	orbs, err := api.ListOrbs(opts.cl, opts.listUncertified)
 	if err != nil {
 		return errors.Wrapf(err, "Failed to list orbs")
 	}
 
 	if opts.sortBy != "" {
-		orbs.SortBy(opts.sortBy)
+		orbs.SortBy(opts.sortBy, opts.sortReverse)
 	}
 
 	return logOrbs(*orbs, opts)
@@ -633,70 +769,264 @@ func listNamespaceOrbs(opts orbOptions) error {
 	}
 
 	if opts.sortBy != "" {
-		orbs.SortBy(opts.sortBy)
+		orbs.SortBy(opts.sortBy, opts.sortReverse)
 	}
 
 	return logOrbs(*orbs, opts)
 }
 
+// streamOrbs prints each orb, of the namespace when one is given, as a line of
+// JSON as soon as it's listed.
+func streamOrbs(opts orbOptions) error {
+	encoder := json.NewEncoder(os.Stdout)
+	printOrb := func(orb api.OrbWithData) error {
+		return encoder.Encode(orb)
+	}
+
+	if len(opts.args) != 0 {
+		namespace := opts.args[0]
+		err := api.EachNamespaceOrb(opts.cl, namespace, opts.private, printOrb)
+		return errors.Wrapf(err, "Failed to list orbs in namespace `%s`", namespace)
+	}
+
+	return errors.Wrapf(api.EachOrb(opts.cl, opts.listUncertified, printOrb), "Failed to list orbs")
+}
+
 func validateOrb(opts orbOptions) error {
-	_, err := api.OrbQuery(opts.cl, opts.args[0])
+	paths, err := expandOrbPaths(opts.args)
+	if err != nil {
+		return err
+	}
 
+	switch {
+	case opts.validateOutput != "" && opts.validateOutput != "processed" && opts.validateOutput != "source":
+		return fmt.Errorf("unknown --output %s, expected processed or source", opts.validateOutput)
+	case opts.validateOutput != "" && len(paths) > 1:
+		return errors.New("--output can only be used when validating a single orb")
+	}
+
+	if len(paths) == 1 {
+		return validateOrbAtPath(opts, paths[0])
+	}
+
+	var failed []string
+	for _, path := range paths {
+		if err := validateOrbAtPath(opts, path); err != nil {
+			fmt.Fprintf(os.Stderr, "Orb at `%s` is invalid: %s\n", path, err)
+			failed = append(failed, path)
+		}
+	}
+
+	infof("\nValidated %d orbs:\n", len(paths))
+	for _, path := range paths {
+		result := "pass"
+		for _, f := range failed {
+			if f == path {
+				result = "FAIL"
+			}
+		}
+		infof("  %s  %s\n", result, path)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d orbs failed validation", len(failed), len(paths))
+	}
+	return nil
+}
+
+func validateOrbAtPath(opts orbOptions, path string) error {
+	source, err := api.LoadYaml(path)
+	if err != nil {
+		return err
+	}
+
+	if !opts.skipLocalSchema {
+		if err := validateOrbSchema(source); err != nil {
+			return err
+		}
+	}
+
+	response, err := api.OrbSourceQuery(opts.cl, source)
 	if err != nil {
 		return err
 	}
 
-	if opts.args[0] == "-" {
-		fmt.Println("Orb input is valid.")
+	if opts.validateOutput != "" {
+		// The orb is printed to stdout, so that it can be redirected to a file
+		// without the result
+		switch {
+		case quiet:
+		case path == "-":
+			fmt.Fprintln(os.Stderr, "Orb input is valid.")
+		default:
+			fmt.Fprintf(os.Stderr, "Orb at `%s` is valid.\n", path)
+		}
+		if opts.validateOutput == "processed" {
+			fmt.Println(response.OutputYaml)
+		} else {
+			fmt.Println(response.SourceYaml)
+		}
+		return nil
+	}
+
+	if path == "-" {
+		infoln("Orb input is valid.")
 	} else {
-		fmt.Printf("Orb at `%s` is valid.\n", opts.args[0])
+		infof("Orb at `%s` is valid.\n", path)
 	}
 
 	return nil
 }
 
+// expandOrbPaths expands any globs in the given paths. Paths without glob
+// characters, or globs that match nothing, are kept as-is so that a missing
+// file is reported when it's loaded.
+func expandOrbPaths(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		if arg == "-" || !strings.ContainsAny(arg, "*?[") {
+			paths = append(paths, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid glob %s", arg)
+		}
+		if len(matches) == 0 {
+			paths = append(paths, arg)
+			continue
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
 func processOrb(opts orbOptions) error {
-	response, err := api.OrbQuery(opts.cl, opts.args[0])
+	path := opts.args[0]
 
+	if opts.processOutput != "processed" && opts.processOutput != "source" {
+		return fmt.Errorf("unknown --output %s, expected processed or source", opts.processOutput)
+	}
+	if err := checkOutputFormat(opts.processFormat); err != nil {
+		return err
+	}
+
+	source, err := orbSource(path)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(response.OutputYaml)
-	return nil
+	response, err := api.OrbSourceQuery(opts.cl, source)
+	if err != nil {
+		return errors.Wrapf(err, "Unable to process the orb at %s", configSourceName(path))
+	}
+
+	if opts.processOutput == "source" {
+		return printProcessed(response.SourceYaml, opts.processFormat)
+	}
+	return printProcessed(response.OutputYaml, opts.processFormat)
 }
 
 func publishOrb(opts orbOptions) error {
 	path := opts.args[0]
-	ref := opts.args[1]
-	namespace, orb, version, err := references.SplitIntoOrbNamespaceAndVersion(ref)
+	namespace, orb, version, err := references.SplitIntoOrbNamespaceAndVersion(opts.args[1])
 
 	if err != nil {
 		return err
 	}
 
-	_, err = api.OrbPublishByName(opts.cl, path, orb, namespace, version)
+	version, err = references.NormalizeVersion(version)
 	if err != nil {
 		return err
 	}
+	ref := fmt.Sprintf("%s/%s@%s", namespace, orb, version)
 
-	fmt.Printf("Orb `%s` was published.\n", ref)
+	config, err := orbSource(path)
+	if err != nil {
+		if opts.dryRun {
+			return err
+		}
+		return errors.Wrapf(err, "Orb `%s` was not published", ref)
+	}
+
+	if !opts.skipLocalSchema {
+		if err := validateOrbSchema(config); err != nil {
+			return err
+		}
+	}
+
+	if opts.dryRun {
+		return publishOrbDryRun(opts, config, namespace, orb, version)
+	}
+
+	_, err = api.OrbPublishSourceByName(opts.cl, config, orb, namespace, version)
+	if err != nil {
+		return err
+	}
+
+	infof("Orb `%s` was published.\n", ref)
 
 	if references.IsDevVersion(version) {
-		fmt.Printf("Note that your dev label `%s` can be overwritten by anyone in your organization.\n", version)
-		fmt.Printf("Your dev orb will expire in 90 days unless a new version is published on the label `%s`.\n", version)
+		infof("Note that your dev label `%s` can be overwritten by anyone in your organization.\n", version)
+		infof("Your dev orb will expire in 90 days unless a new version is published on the label `%s`.\n", version)
+	}
+
+	if !quiet && orbIsOpenSource(opts.cl, namespace, orb) {
+		infoln("Please note that this is an open orb and is world-readable.")
 	}
 
-	if orbIsOpenSource(opts.cl, namespace, orb) {
-		fmt.Println("Please note that this is an open orb and is world-readable.")
+	return nil
+}
+
+// publishOrbDryRun validates the orb source in config and prints what would
+// be published, without publishing it.
+func publishOrbDryRun(opts orbOptions, config, namespace, orb, version string) error {
+	if _, err := api.OrbSourceQuery(opts.cl, config); err != nil {
+		return err
 	}
 
+	fmt.Printf("Dry run: orb `%s/%s@%s` is valid, but was not published.\n\n", namespace, orb, version)
+	fmt.Printf("Namespace: %s\n", namespace)
+	fmt.Printf("Orb:       %s\n", orb)
+	fmt.Printf("Version:   %s\n", version)
+	fmt.Printf("SHA-256:   %x\n", sha256.Sum256([]byte(config)))
+
 	return nil
 }
 
+// orbSource loads the orb at path, packing it first when path is a directory
+// of orb source instead of a packed orb file. The directory can either be the
+// one containing @orb.yml, or the root of a project with the orb in src, as
+// created by `orb init`.
+func orbSource(path string) (string, error) {
+	info, err := os.Stat(path)
+	if path == "-" || err != nil || !info.IsDir() {
+		return api.LoadYaml(path)
+	}
+
+	root := path
+	if _, err := os.Stat(filepath.Join(path, "@orb.yml")); os.IsNotExist(err) {
+		if _, err := os.Stat(filepath.Join(path, "src", "@orb.yml")); err == nil {
+			root = filepath.Join(path, "src")
+		}
+	}
+
+	packed, err := packOrb(root)
+	if err != nil {
+		return "", errors.Wrapf(err, "Unable to pack the orb source in %s", path)
+	}
+
+	return packed, nil
+}
+
+// orbListStatus is printed by `orb unlist --json`.
+type orbListStatus struct {
+	Orb    string `json:"orb"`
+	Listed bool   `json:"listed"`
+}
+
 func setOrbListStatus(opts orbOptions) error {
 	ref := opts.args[0]
-	unlistArg := opts.args[1]
 	var err error
 
 	namespace, orb, err := references.SplitIntoOrbAndNamespace(ref)
@@ -705,30 +1035,154 @@ func setOrbListStatus(opts orbOptions) error {
 		return err
 	}
 
-	unlist, err := strconv.ParseBool(unlistArg)
-	if err != nil {
-		return fmt.Errorf("expected \"true\" or \"false\", got \"%s\"", unlistArg)
+	unlist := true
+	if len(opts.args) > 1 {
+		unlist, err = strconv.ParseBool(opts.args[1])
+		if err != nil {
+			return fmt.Errorf("expected \"true\" or \"false\", got \"%s\"", opts.args[1])
+		}
+	}
+
+	// Giving true or false explicitly is how scripts change the listing, so
+	// only the one-argument form asks for confirmation
+	if !opts.noPrompt && len(opts.args) == 1 {
+		// Without a terminal to answer on, the confirmation would be read from
+		// whatever was piped in
+		if !opts.integrationTesting && stdinIsPiped() {
+			return fmt.Errorf("not unlisting orb `%s` without confirmation, as stdin isn't a terminal, use --no-prompt or 'circleci orb unlist %s true' to unlist it without asking", ref, ref)
+		}
+		confirm := fmt.Sprintf("Are you sure you wish to unlist the orb `%s` from the registry", ref)
+		if !opts.tty.askUserToConfirm(confirm) {
+			return fmt.Errorf("the listing of orb `%s` was not changed", ref)
+		}
 	}
 
 	listed, err := api.OrbSetOrbListStatus(opts.cl, namespace, orb, !unlist)
 	if err != nil {
+		if isAuthorizationFailure(err) {
+			return fmt.Errorf("you don't have permission to change the listing of orb `%s`, only admins of the organization that owns the `%s` namespace can: %s", ref, namespace, err)
+		}
 		return err
 	}
 
-	if listed != nil {
-		displayedStatus := "enabled"
-		if !*listed {
-			displayedStatus = "disabled"
-		}
-		fmt.Printf("The listing of orb `%s` is now %s.\n"+
-			"Note: changes may not be immediately reflected in the registry.\n", ref, displayedStatus)
-	} else {
+	if listed == nil {
 		return fmt.Errorf("unexpected error in setting the list status of orb `%s`", ref)
 	}
 
+	if opts.unlistJSON {
+		out, err := marshalJSON(orbListStatus{Orb: ref, Listed: *listed})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	displayedStatus := "enabled"
+	if !*listed {
+		displayedStatus = "disabled"
+	}
+	infof("The listing of orb `%s` is now %s.\n"+
+		"Note: changes may not be immediately reflected in the registry.\n", ref, displayedStatus)
+
 	return nil
 }
 
+// orbVersionDeletion is printed by `orb delete-version --json`.
+type orbVersionDeletion struct {
+	Orb     string `json:"orb"`
+	Deleted bool   `json:"deleted"`
+}
+
+func deleteOrbVersion(opts orbOptions) error {
+	ref := opts.args[0]
+
+	namespace, orb, version, err := references.SplitIntoOrbNamespaceAndVersion(ref)
+	if err != nil {
+		return err
+	}
+
+	if !references.IsDevVersion(version) {
+		return fmt.Errorf("`%s` is a published version, which can't be deleted: published versions of orbs are immutable, so that the configs that use them keep working. Only dev versions, such as `%s/%s@dev:alpha`, can be deleted. To replace a broken version, publish a fixed one, for example with `circleci orb publish increment`", ref, namespace, orb)
+	}
+
+	version, err = references.NormalizeVersion(version)
+	if err != nil {
+		return err
+	}
+
+	if !opts.noPrompt {
+		// Without a terminal to answer on, the confirmation would be read from
+		// whatever was piped in
+		if !opts.integrationTesting && stdinIsPiped() {
+			return fmt.Errorf("not deleting orb version `%s` without confirmation, as stdin isn't a terminal, use --force to delete it without asking", ref)
+		}
+		confirm := fmt.Sprintf("Are you sure you wish to delete the orb version `%s`? Configs that use it will fail until it is published again", ref)
+		if !opts.tty.askUserToConfirm(confirm) {
+			return fmt.Errorf("orb version `%s` was not deleted", ref)
+		}
+	}
+
+	err = api.OrbDeleteDevVersion(opts.cl, namespace, orb, version)
+	if err != nil {
+		if isAuthorizationFailure(err) {
+			return fmt.Errorf("you don't have permission to delete orb version `%s`, only admins of the organization that owns the `%s` namespace can: %s", ref, namespace, err)
+		}
+		if isUnknownField(err, "deleteOrbVersion") {
+			return fmt.Errorf("the CircleCI API at %s doesn't support deleting orb versions, so `%s` was not deleted", opts.cfg.Host, ref)
+		}
+		return err
+	}
+
+	if opts.deleteJSON {
+		out, err := marshalJSON(orbVersionDeletion{Orb: ref, Deleted: true})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	infof("Orb version `%s` was deleted.\n", ref)
+	return nil
+}
+
+// isUnknownField reports whether err is the error the API returns for a
+// query or mutation that it doesn't have, such as one that an older server
+// doesn't support yet.
+func isUnknownField(err error, field string) bool {
+	errs, ok := errors.Cause(err).(graphql.ResponseErrorsCollection)
+	if !ok {
+		return false
+	}
+	for _, e := range errs {
+		if strings.Contains(e.Message, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAuthorizationFailure reports whether err is the error the API returns
+// when the token isn't allowed to make a change, or to see something.
+func isAuthorizationFailure(err error) bool {
+	switch errs := errors.Cause(err).(type) {
+	case api.GQLErrorsCollection:
+		for _, e := range errs {
+			if e.Type == "AUTHORIZATION_FAILURE" {
+				return true
+			}
+		}
+	case graphql.ResponseErrorsCollection:
+		for _, e := range errs {
+			if e.Extensions.Type == "AUTHORIZATION_FAILURE" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 var validSegments = map[string]bool{
 	"major": true,
 	"minor": true,
@@ -754,16 +1208,40 @@ func incrementOrb(opts orbOptions) error {
 		return err
 	}
 
-	response, err := api.OrbIncrementVersion(opts.cl, opts.args[0], namespace, orb, segment)
+	// The orb is loaded and checked the same way as with `orb publish`, so that
+	// directories can be incremented too
+	config, err := orbSource(opts.args[0])
+	if err != nil {
+		if opts.dryRun {
+			return err
+		}
+		return errors.Wrapf(err, "Orb `%s` was not incremented", ref)
+	}
+
+	if !opts.skipLocalSchema {
+		if err := validateOrbSchema(config); err != nil {
+			return err
+		}
+	}
+
+	version, err := api.OrbNextVersion(opts.cl, namespace, orb, segment)
+	if err != nil {
+		return err
+	}
+
+	if opts.dryRun {
+		return publishOrbDryRun(opts, config, namespace, orb, version)
+	}
 
+	_, err = api.OrbPublishSourceByName(opts.cl, config, orb, namespace, version)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Orb `%s` has been incremented to `%s/%s@%s`.\n", ref, namespace, orb, response.HighestVersion)
+	infof("Orb `%s` has been incremented to `%s/%s@%s`.\n", ref, namespace, orb, version)
 
-	if orbIsOpenSource(opts.cl, namespace, orb) {
-		fmt.Println("Please note that this is an open orb and is world-readable.")
+	if !quiet && orbIsOpenSource(opts.cl, namespace, orb) {
+		infoln("Please note that this is an open orb and is world-readable.")
 	}
 
 	return nil
@@ -782,6 +1260,10 @@ func promoteOrb(opts orbOptions) error {
 		return err
 	}
 
+	version, err = references.NormalizeVersion(version)
+	if err != nil {
+		return err
+	}
 	if !references.IsDevVersion(version) {
 		return fmt.Errorf("The version '%s' must be a dev version (the string should begin `dev:`)", version)
 	}
@@ -791,10 +1273,10 @@ func promoteOrb(opts orbOptions) error {
 		return err
 	}
 
-	fmt.Printf("Orb `%s` was promoted to `%s/%s@%s`.\n", ref, namespace, orb, response.HighestVersion)
+	infof("Orb `%s` was promoted to `%s/%s@%s`.\n", ref, namespace, orb, response.HighestVersion)
 
-	if orbIsOpenSource(opts.cl, namespace, orb) {
-		fmt.Println("Please note that this is an open orb and is world-readable.")
+	if !quiet && orbIsOpenSource(opts.cl, namespace, orb) {
+		infoln("Please note that this is an open orb and is world-readable.")
 	}
 	return nil
 }
@@ -832,9 +1314,9 @@ If you change your mind about the name, you will have to create a new orb with t
 			confirmationString = "This orb will not be listed on the registry and is usable only by org users."
 		}
 
-		fmt.Printf("Orb `%s` created.\n", opts.args[0])
-		fmt.Println(confirmationString)
-		fmt.Printf("You can now register versions of `%s` using `circleci orb publish`.\n", opts.args[0])
+		infof("Orb `%s` created.\n", opts.args[0])
+		infoln(confirmationString)
+		infof("You can now register versions of `%s` using `circleci orb publish`.\n", opts.args[0])
 	}
 
 	return nil
@@ -843,6 +1325,18 @@ If you change your mind about the name, you will have to create a new orb with t
 func showSource(opts orbOptions) error {
 	ref := opts.args[0]
 
+	if opts.sourceVersion != "" {
+		if strings.Contains(ref, "@") {
+			return fmt.Errorf("--version %s can't be used with %s, which already has a version", opts.sourceVersion, ref)
+		}
+		ref = fmt.Sprintf("%s@%s", ref, opts.sourceVersion)
+	}
+
+	ref, err := references.NormalizeOrbRef(ref)
+	if err != nil {
+		return err
+	}
+
 	source, err := api.OrbSource(opts.cl, ref)
 	if err != nil {
 		return errors.Wrapf(err, "Failed to get source for '%s'", ref)
@@ -852,13 +1346,20 @@ func showSource(opts orbOptions) error {
 }
 
 func orbInfo(opts orbOptions) error {
-	ref := opts.args[0]
+	ref, err := references.NormalizeOrbRef(opts.args[0])
+	if err != nil {
+		return err
+	}
 
 	info, err := api.OrbInfo(opts.cl, ref)
 	if err != nil {
 		return errors.Wrapf(err, "Failed to get info for '%s'", ref)
 	}
 
+	if opts.infoJSON {
+		return printOrbInfoJSON(info)
+	}
+
 	fmt.Println("")
 
 	if len(info.Orb.Versions) > 0 {
@@ -901,6 +1402,94 @@ https://circleci.com/developer/orbs/orb/%s
 	return nil
 }
 
+// orbInfoJSON is the output of `orb info --json`. Tools depend on it, so
+// fields may be added but are never renamed or removed. Dates are RFC 3339
+// timestamps, and are empty for an orb without published versions.
+type orbInfoJSON struct {
+	// Name is the orb's name, such as circleci/python
+	Name string `json:"name"`
+	// Version is the version of the orb that was asked for, which is the
+	// latest unless one was given
+	Version          string `json:"version"`
+	VersionCreatedAt string `json:"version_created_at"`
+	LatestVersion    string `json:"latest_version"`
+	CreatedAt        string `json:"created_at"`
+	LastUpdatedAt    string `json:"last_updated_at"`
+	TotalRevisions   int    `json:"total_revisions"`
+	// Statistics are for the last 30 days
+	Statistics struct {
+		Builds        int `json:"builds"`
+		Projects      int `json:"projects"`
+		Organizations int `json:"organizations"`
+	} `json:"statistics"`
+	Categories []string `json:"categories"`
+	// Dependencies are the orbs imported by this version of the orb
+	Dependencies []orbDependency `json:"dependencies"`
+}
+
+// An orbDependency is an orb imported by another, under Name in its orbs.
+// Orb is the reference it was imported with, such as circleci/node@5.0.0, or
+// empty for a dependency that is defined inline.
+type orbDependency struct {
+	Name string `json:"name"`
+	Orb  string `json:"orb"`
+}
+
+func printOrbInfoJSON(info *api.OrbVersion) error {
+	output := orbInfoJSON{
+		Name:             info.Orb.Name,
+		Version:          info.Version,
+		VersionCreatedAt: info.CreatedAt,
+		CreatedAt:        info.Orb.CreatedAt,
+		TotalRevisions:   len(info.Orb.Versions),
+		Categories:       []string{},
+	}
+	if len(info.Orb.Versions) > 0 {
+		output.LatestVersion = info.Orb.HighestVersion
+		output.LastUpdatedAt = info.Orb.Versions[0].CreatedAt
+	}
+	output.Statistics.Builds = info.Orb.Statistics.Last30DaysBuildCount
+	output.Statistics.Projects = info.Orb.Statistics.Last30DaysProjectCount
+	output.Statistics.Organizations = info.Orb.Statistics.Last30DaysOrganizationCount
+	for _, category := range info.Orb.Categories {
+		output.Categories = append(output.Categories, category.Name)
+	}
+
+	dependencies, err := orbDependencies(info.Source)
+	if err != nil {
+		return errors.Wrapf(err, "Unable to read the orbs imported by %s@%s", info.Orb.Name, info.Version)
+	}
+	output.Dependencies = dependencies
+
+	infoJSON, err := marshalJSON(output)
+	if err != nil {
+		return errors.Wrap(err, "Failed to convert to JSON")
+	}
+	fmt.Println(string(infoJSON))
+
+	return nil
+}
+
+// orbDependencies returns the orbs imported in the orbs section of source,
+// sorted by the name they are imported as.
+func orbDependencies(source string) ([]orbDependency, error) {
+	var orb struct {
+		Orbs map[string]interface{} `yaml:"orbs"`
+	}
+	if err := yaml.Unmarshal([]byte(source), &orb); err != nil {
+		return nil, err
+	}
+
+	dependencies := []orbDependency{}
+	for name, imported := range orb.Orbs {
+		ref, _ := imported.(string)
+		dependencies = append(dependencies, orbDependency{Name: name, Orb: ref})
+	}
+	sort.Slice(dependencies, func(i, j int) bool { return dependencies[i].Name < dependencies[j].Name })
+
+	return dependencies, nil
+}
+
 func listOrbCategories(opts orbOptions) error {
 	orbCategories, err := api.ListOrbCategories(opts.cl)
 	if err != nil {
@@ -1401,7 +1990,7 @@ func initOrb(opts orbOptions) error {
 		return err
 	}
 
-	fmt.Printf("An initial commit has been created - please run \033[1;34m'git push origin %v'\033[0m to publish your first commit!\n", gitBranch)
+	fmt.Printf("An initial commit has been created - please run %s to publish your first commit!\n", styled(styleBoldBlue, fmt.Sprintf("'git push origin %v'", gitBranch)))
 	yprompt = &survey.Confirm{
 		Message: "I have pushed to my git repository using the above command",
 	}
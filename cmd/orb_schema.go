@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// validateOrbSchema checks the orb source against the orb schema bundled
+// with the CLI, so that obvious mistakes are caught before it's sent to the
+// API.
+func validateOrbSchema(source string) error {
+	schema, err := bundledSchema("orb-schema.json", "Orb")
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(source), &doc); err != nil {
+		return localSchemaError(errors.Wrap(err, "Orb is not valid YAML"))
+	}
+	if len(doc.Content) == 0 {
+		return localSchemaError(errors.New("Orb is empty"))
+	}
+
+	if err := schema.check(doc.Content[0]); err != nil {
+		return localSchemaError(err)
+	}
+	return nil
+}
+
+// localSchemaError marks err as found by the local schema checks, rather
+// than by the API.
+func localSchemaError(err error) error {
+	return errors.Wrap(err, "Orb failed local schema validation (skip it with --skip-local-schema)")
+}
@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"gotest.tools/v3/golden"
 
@@ -90,6 +93,7 @@ See a full explanation and documentation on orbs here: https://circleci.com/docs
 				command = exec.Command(pathCLI,
 					"orb", "validate",
 					"--skip-update-check",
+					"--skip-local-schema",
 					"--token", token,
 					"--host", tempSettings.TestServer.URL(),
 					"-",
@@ -160,6 +164,7 @@ See a full explanation and documentation on orbs here: https://circleci.com/docs
 				command = exec.Command(pathCLI,
 					"orb", "validate", orb.Path,
 					"--skip-update-check",
+					"--skip-local-schema",
 					"--token", token,
 					"--host", tempSettings.TestServer.URL(),
 				)
@@ -204,6 +209,160 @@ See a full explanation and documentation on orbs here: https://circleci.com/docs
 			})
 		})
 
+		Describe("when validating against the local orb schema", func() {
+			BeforeEach(func() {
+				orb = clitest.OpenTmpFile(tempSettings.Home, "orb.yml")
+
+				token = "testtoken"
+				command = exec.Command(pathCLI,
+					"orb", "validate", orb.Path,
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+				)
+			})
+
+			AfterEach(func() {
+				tempSettings.Close()
+				orb.Close()
+			})
+
+			It("sends an orb that matches the schema to the server", func() {
+				orb.Write([]byte("version: 2.1\ndescription: Greets\n"))
+
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status: http.StatusOK,
+					Request: `{
+						"query": "\n\t\tquery ValidateOrb ($config: String!) {\n\t\t\torbConfig(orbYaml: $config) {\n\t\t\t\tvalid,\n\t\t\t\terrors { message },\n\t\t\t\tsourceYaml,\n\t\t\t\toutputYaml\n\t\t\t}\n\t\t}",
+						"variables": {
+							"config": "version: 2.1\ndescription: Greets\n"
+						}
+					}`,
+					Response: `{"orbConfig": {"sourceYaml": "version: 2.1", "valid": true, "errors": []}}`})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Out).Should(gbytes.Say("Orb at `.*orb.yml` is valid."))
+				Eventually(session).Should(gexec.Exit(0))
+			})
+
+			It("sends an orb with a top-level key that holds anchors to the server", func() {
+				orb.Write([]byte("version: 2.1\nreferences:\n  image: &image cimg/base:stable\n"))
+
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status: http.StatusOK,
+					Request: `{
+						"query": "\n\t\tquery ValidateOrb ($config: String!) {\n\t\t\torbConfig(orbYaml: $config) {\n\t\t\t\tvalid,\n\t\t\t\terrors { message },\n\t\t\t\tsourceYaml,\n\t\t\t\toutputYaml\n\t\t\t}\n\t\t}",
+						"variables": {
+							"config": "version: 2.1\nreferences:\n  image: &image cimg/base:stable\n"
+						}
+					}`,
+					Response: `{"orbConfig": {"sourceYaml": "version: 2.1", "valid": true, "errors": []}}`})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Out).Should(gbytes.Say("Orb at `.*orb.yml` is valid."))
+				Eventually(session).Should(gexec.Exit(0))
+			})
+
+			It("reports an unknown top-level key without calling the server", func() {
+				orb.Write([]byte("version: 2.1\nworkflows: {}\n"))
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: Orb failed local schema validation \\(skip it with --skip-local-schema\\): Unexpected top-level key 'workflows' on line 2"))
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("reports an unsupported version without calling the server", func() {
+				orb.Write([]byte("version: 2\n"))
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: Orb failed local schema validation \\(skip it with --skip-local-schema\\): version on line 1: value must be \"2.1\""))
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("reports a section that isn't a map", func() {
+				orb.Write([]byte("version: 2.1\ncommands:\n  - greet\n"))
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("commands on line 3: expected object or null, but got array"))
+				Eventually(session).Should(clitest.ShouldFail())
+			})
+		})
+
+		Describe("when validating multiple orbs with a glob", func() {
+			var other *clitest.TmpFile
+
+			BeforeEach(func() {
+				orb.Write([]byte(`valid orb`))
+				other = clitest.OpenTmpFile(tempSettings.Home, filepath.Join("otherorb", "orb.yml"))
+				other.Write([]byte(`invalid orb`))
+
+				command = exec.Command(pathCLI,
+					"orb", "validate",
+					"--skip-update-check",
+					"--skip-local-schema",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					filepath.Join(tempSettings.Home, "*", "orb.yml"),
+				)
+			})
+
+			AfterEach(func() {
+				other.Close()
+			})
+
+			It("validates each orb and summarizes the results", func() {
+				request := func(config string) string {
+					r := graphql.NewRequest(`
+		query ValidateOrb ($config: String!) {
+			orbConfig(orbYaml: $config) {
+				valid,
+				errors { message },
+				sourceYaml,
+				outputYaml
+			}
+		}`)
+					r.Variables["config"] = config
+					req, err := r.Encode()
+					Expect(err).ShouldNot(HaveOccurred())
+					return req.String()
+				}
+
+				// Glob matches are sorted, so myorb is validated before otherorb
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  request("valid orb"),
+					Response: `{"orbConfig": {"sourceYaml": "valid orb", "valid": true, "errors": []}}`,
+				}, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  request("invalid orb"),
+					Response: `{"orbConfig": {"sourceYaml": "invalid orb", "valid": false, "errors": [{"message": "invalid_orb"}]}}`,
+				})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(session.Out).To(gbytes.Say("Orb at `.*myorb/orb.yml` is valid."))
+				Expect(session.Out).To(gbytes.Say("Validated 2 orbs:"))
+				Expect(session.Out).To(gbytes.Say("pass  .*myorb/orb.yml"))
+				Expect(session.Out).To(gbytes.Say("FAIL  .*otherorb/orb.yml"))
+				Expect(session.Err).To(gbytes.Say("Orb at `.*otherorb/orb.yml` is invalid: invalid_orb"))
+				Expect(session.Err).To(gbytes.Say("Error: 1 of 2 orbs failed validation"))
+				Expect(string(session.Out.Contents())).ToNot(ContainSubstring("is invalid"))
+			})
+		})
+
 		Context("with 'some orb'", func() {
 			BeforeEach(func() {
 				orb.Write([]byte(`some orb`))
@@ -214,6 +373,7 @@ See a full explanation and documentation on orbs here: https://circleci.com/docs
 					command = exec.Command(pathCLI,
 						"orb", "validate", orb.Path,
 						"--skip-update-check",
+						"--skip-local-schema",
 						"--token", token,
 						"--host", tempSettings.TestServer.URL(),
 					)
@@ -251,6 +411,36 @@ See a full explanation and documentation on orbs here: https://circleci.com/docs
 					Eventually(session).Should(gexec.Exit(0))
 				})
 
+				It("prints the processed orb to stdout with --output processed", func() {
+					command.Args = append(command.Args, "--output", "processed")
+
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status: http.StatusOK,
+						Request: ` {
+					"query": "\n\t\tquery ValidateOrb ($config: String!) {\n\t\t\torbConfig(orbYaml: $config) {\n\t\t\t\tvalid,\n\t\t\t\terrors { message },\n\t\t\t\tsourceYaml,\n\t\t\t\toutputYaml\n\t\t\t}\n\t\t}",
+					"variables": {
+						"config": "some orb"
+					}
+				}`,
+						Response: `{"orbConfig": {"sourceYaml": "some orb", "outputYaml": "processed orb", "valid": true, "errors": []}}`,
+					})
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session).Should(gexec.Exit(0))
+					Expect(string(session.Out.Contents())).To(Equal("processed orb\n"))
+					Expect(session.Err).To(gbytes.Say("Orb at `.*orb.yml` is valid."))
+				})
+
+				It("refuses an unknown --output", func() {
+					command.Args = append(command.Args, "--output", "expanded")
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: unknown --output expanded, expected processed or source"))
+					Eventually(session).Should(clitest.ShouldFail())
+				})
+
 				It("prints errors if invalid", func() {
 					By("setting up a mock server")
 
@@ -359,10 +549,65 @@ See a full explanation and documentation on orbs here: https://circleci.com/docs
 					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
 
 					Expect(err).ShouldNot(HaveOccurred())
-					Eventually(session.Err).Should(gbytes.Say("Error: error1\nerror2"))
+					Eventually(session.Err).Should(gbytes.Say("Error: Unable to process the orb at .*: error1\nerror2"))
 					Eventually(session).ShouldNot(gexec.Exit(0))
 
 				})
+
+				It("prints the source or json when asked", func() {
+					gqlResponse := `{
+							"orbConfig": {
+								"sourceYaml": "some orb",
+								"outputYaml": "jobs:\n  build: {}\n",
+								"valid": true,
+								"errors": []
+							}
+						}`
+
+					expectedRequestJson := ` {
+					"query": "\n\t\tquery ValidateOrb ($config: String!) {\n\t\t\torbConfig(orbYaml: $config) {\n\t\t\t\tvalid,\n\t\t\t\terrors { message },\n\t\t\t\tsourceYaml,\n\t\t\t\toutputYaml\n\t\t\t}\n\t\t}",
+					"variables": {
+					  "config": "some orb"
+					}
+				  }`
+
+					for i := 0; i < 3; i++ {
+						tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+							Status:   http.StatusOK,
+							Request:  expectedRequestJson,
+							Response: gqlResponse,
+						})
+					}
+
+					session, err := gexec.Start(exec.Command(pathCLI, append(command.Args[1:], "--output-format", "json")...), GinkgoWriter, GinkgoWriter)
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session).Should(gexec.Exit(0))
+					Expect(session.Out.Contents()).To(MatchJSON(`{"jobs": {"build": {}}}`))
+
+					session, err = gexec.Start(exec.Command(pathCLI, append(command.Args[1:], "--output", "source")...), GinkgoWriter, GinkgoWriter)
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session).Should(gexec.Exit(0))
+					Expect(string(session.Out.Contents())).To(Equal("some orb"))
+
+					By("writing it to a file with --output-file, which is separate from --output")
+					outputFile := filepath.Join(tempSettings.Home, "processed.yml")
+					session, err = gexec.Start(exec.Command(pathCLI, append(command.Args[1:], "--output", "source", "--output-file", outputFile)...), GinkgoWriter, GinkgoWriter)
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session).Should(gexec.Exit(0))
+					Expect(ioutil.ReadFile(outputFile)).To(BeEquivalentTo("some orb"))
+				})
+
+				It("refuses an unknown --output or --output-format", func() {
+					session, err := gexec.Start(exec.Command(pathCLI, append(command.Args[1:], "--output", "expanded")...), GinkgoWriter, GinkgoWriter)
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: unknown --output expanded, expected processed or source"))
+					Eventually(session).Should(clitest.ShouldFail())
+
+					session, err = gexec.Start(exec.Command(pathCLI, append(command.Args[1:], "--output-format", "toml")...), GinkgoWriter, GinkgoWriter)
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: unsupported output format 'toml', expected yaml or json"))
+					Eventually(session).Should(clitest.ShouldFail())
+				})
 			})
 
 			Describe("when releasing a semantic version", func() {
@@ -370,6 +615,7 @@ See a full explanation and documentation on orbs here: https://circleci.com/docs
 					command = exec.Command(pathCLI,
 						"orb", "publish",
 						"--skip-update-check",
+						"--skip-local-schema",
 						"--token", token,
 						"--host", tempSettings.TestServer.URL(),
 						orb.Path,
@@ -507,16 +753,207 @@ See a full explanation and documentation on orbs here: https://circleci.com/docs
 						Response: gqlPublishResponse})
 					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
 						Status:   http.StatusOK,
-						Request:  expectedOrbIDRequest,
-						Response: gqlOrbIDResponse})
+						Request:  expectedOrbIDRequest,
+						Response: gqlOrbIDResponse})
+
+					By("running the command")
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Out).Should(gbytes.Say("Orb `my/orb@0.0.1` was published."))
+					Eventually(session.Out).ShouldNot(gbytes.Say("Please note that this is an open orb and is world-readable."))
+					Eventually(session).Should(gexec.Exit(0))
+				})
+			})
+
+			Describe("when publishing a directory of orb source", func() {
+				var source string
+
+				BeforeEach(func() {
+					source = filepath.Join(tempSettings.Home, "my-orb")
+					clitest.OpenTmpFile(source, filepath.Join("src", "@orb.yml")).Write([]byte("version: 2.1\n"))
+					clitest.OpenTmpFile(source, filepath.Join("src", "commands", "greet.yml")).Write([]byte("steps:\n  - run: echo hello\n"))
+
+					command = exec.Command(pathCLI,
+						"orb", "publish",
+						"--skip-update-check",
+						"--token", token,
+						"--host", tempSettings.TestServer.URL(),
+						source,
+						"my/orb@0.0.1",
+					)
+				})
+
+				It("packs the orb before publishing it", func() {
+					By("setting up a mock server")
+
+					gqlPublishResponse := `{
+					"publishOrb": {
+						"errors": [],
+						"orb": {
+							"version": "0.0.1"
+						}
+					}
+				}`
+
+					expectedPublishRequest := `{
+						"query": "\n\t\tmutation($config: String!, $orbName: String, $namespaceName: String, $version: String!) {\n\t\t\tpublishOrb(\n\t\t\t\torbName: $orbName,\n\t\t\t\tnamespaceName: $namespaceName,\n\t\t\t\torbYaml: $config,\n\t\t\t\tversion: $version\n\t\t\t) {\n\t\t\t\torb {\n\t\t\t\t\tversion\n\t\t\t\t}\n\t\t\t\terrors { message }\n\t\t\t}\n\t\t}\n\t",
+						"variables": {
+						  "config": "version: 2.1\ncommands:\n    greet:\n        steps:\n            - run: echo hello\n",
+						  "namespaceName": "my",
+						  "orbName": "orb",
+						  "version": "0.0.1"
+						}
+					  }`
+
+					gqlOrbIDResponse := `{
+						"orb": {"id": "orbid1", "isPrivate": false},
+						"registryNamespace": {"id": "nsid1"}
+					}`
+
+					expectedOrbIDRequest := `{
+						"query": "\n\tquery ($name: String!, $namespace: String) {\n\t\torb(name: $name) {\n\t\t  id\n\t\t  isPrivate\n\t\t}\n\t\tregistryNamespace(name: $namespace) {\n\t\t\tid\n\t\t  }\n\t  }\n\t  ",
+						"variables": {
+							"name": "my/orb",
+							"namespace": "my"
+						}
+					}`
+
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  expectedPublishRequest,
+						Response: gqlPublishResponse})
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  expectedOrbIDRequest,
+						Response: gqlOrbIDResponse})
+
+					By("running the command")
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Out).Should(gbytes.Say("Orb `my/orb@0.0.1` was published."))
+					Eventually(session).Should(gexec.Exit(0))
+				})
+
+				It("reports pack errors without publishing", func() {
+					Expect(os.Remove(filepath.Join(source, "src", "@orb.yml"))).To(Succeed())
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: Orb `my/orb@0.0.1` was not published: Unable to pack the orb source in %s", source))
+					Eventually(session).ShouldNot(gexec.Exit(0))
+					Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+				})
+
+				It("reports pack errors with --dry-run", func() {
+					Expect(os.Remove(filepath.Join(source, "src", "@orb.yml"))).To(Succeed())
+					command.Args = append(command.Args, "--dry-run")
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: Unable to pack the orb source in %s", source))
+					Eventually(session).ShouldNot(gexec.Exit(0))
+					Expect(string(session.Err.Contents())).ToNot(ContainSubstring("not published"))
+				})
+			})
+
+			Describe("when publishing an invalid version", func() {
+				BeforeEach(func() {
+					command = exec.Command(pathCLI,
+						"orb", "publish",
+						"--skip-update-check",
+						"--token", token,
+						"--host", tempSettings.TestServer.URL(),
+						orb.Path,
+						"my/orb@1.2",
+					)
+				})
+
+				It("reports the version without calling the API", func() {
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: Invalid orb version '1.2': Expected a semantic version such as '1.2.3', or a dev version such as 'dev:alpha'"))
+					Eventually(session).ShouldNot(gexec.Exit(0))
+					Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+				})
+			})
+
+			Describe("when publishing with --dry-run", func() {
+				var expectedValidateRequest string
+
+				BeforeEach(func() {
+					command = exec.Command(pathCLI,
+						"orb", "publish",
+						"--skip-update-check",
+						"--skip-local-schema",
+						"--token", token,
+						"--host", tempSettings.TestServer.URL(),
+						"--dry-run",
+						orb.Path,
+						"my/orb@0.0.1",
+					)
+
+					expectedValidateRequest = `{
+						"query": "\n\t\tquery ValidateOrb ($config: String!) {\n\t\t\torbConfig(orbYaml: $config) {\n\t\t\t\tvalid,\n\t\t\t\terrors { message },\n\t\t\t\tsourceYaml,\n\t\t\t\toutputYaml\n\t\t\t}\n\t\t}",
+						"variables": {
+							"config": "some orb"
+						}
+					}`
+				})
+
+				It("validates the orb and prints what would be published", func() {
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  expectedValidateRequest,
+						Response: `{"orbConfig": {"sourceYaml": "{}", "valid": true, "errors": []}}`})
 
-					By("running the command")
 					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
 
 					Expect(err).ShouldNot(HaveOccurred())
-					Eventually(session.Out).Should(gbytes.Say("Orb `my/orb@0.0.1` was published."))
-					Eventually(session.Out).ShouldNot(gbytes.Say("Please note that this is an open orb and is world-readable."))
 					Eventually(session).Should(gexec.Exit(0))
+					Expect(string(session.Out.Contents())).To(Equal("Dry run: orb `my/orb@0.0.1` is valid, but was not published.\n\n" +
+						"Namespace: my\n" +
+						"Orb:       orb\n" +
+						"Version:   0.0.1\n" +
+						"SHA-256:   6c61678ac727bca3bfbcc0b62a89aa03c06e159ca1627c5e99d2a53097d44aec\n"))
+					Expect(tempSettings.TestServer.ReceivedRequests()).To(HaveLen(1))
+				})
+
+				It("fails when the orb is invalid", func() {
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  expectedValidateRequest,
+						Response: `{"orbConfig": {"sourceYaml": "{}", "valid": false, "errors": [{"message": "invalid orb"}]}}`})
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: invalid orb"))
+					Eventually(session).Should(clitest.ShouldFail())
+					Expect(tempSettings.TestServer.ReceivedRequests()).To(HaveLen(1))
+				})
+
+				It("checks the orb against the local schema before calling the server", func() {
+					command = exec.Command(pathCLI,
+						"orb", "publish",
+						"--skip-update-check",
+						"--token", token,
+						"--host", tempSettings.TestServer.URL(),
+						"--dry-run",
+						orb.Path,
+						"my/orb@0.0.1",
+					)
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: Orb failed local schema validation \\(skip it with --skip-local-schema\\): Orb on line 1: expected object, but got string"))
+					Eventually(session).Should(clitest.ShouldFail())
+					Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
 				})
 			})
 
@@ -525,6 +962,7 @@ See a full explanation and documentation on orbs here: https://circleci.com/docs
 					command = exec.Command(pathCLI,
 						"orb", "publish",
 						"--skip-update-check",
+						"--skip-local-schema",
 						"--token", token,
 						"--host", tempSettings.TestServer.URL(),
 						orb.Path,
@@ -680,6 +1118,7 @@ See a full explanation and documentation on orbs here: https://circleci.com/docs
 					command = exec.Command(pathCLI,
 						"orb", "publish", "increment",
 						"--skip-update-check",
+						"--skip-local-schema",
 						"--token", token,
 						"--host", tempSettings.TestServer.URL(),
 						orb.Path,
@@ -885,6 +1324,115 @@ See a full explanation and documentation on orbs here: https://circleci.com/docs
 					Eventually(session.Out).ShouldNot(gbytes.Say("Please note that this is an open orb and is world-readable."))
 					Eventually(session).Should(gexec.Exit(0))
 				})
+
+				It("fails when the orb has no version to increment", func() {
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status: http.StatusOK,
+						Request: `{
+							"query": "query($name: String!) {\n\t\t\t    orb(name: $name) {\n\t\t\t      versions(count: 1) {\n\t\t\t\t    version\n\t\t\t      }\n\t\t\t    }\n\t\t      }",
+							"variables": {"name": "my/orb"}
+						}`,
+						Response: `{"orb": {"versions": []}}`})
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: Orb my/orb has no published versions to increment, publish its first version with `circleci orb publish <path> my/orb@<version>`"))
+					Eventually(session).ShouldNot(gexec.Exit(0))
+					Expect(tempSettings.TestServer.ReceivedRequests()).To(HaveLen(1))
+				})
+
+				It("prints the version it would publish with --dry-run", func() {
+					command.Args = append(command.Args, "--dry-run")
+
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status: http.StatusOK,
+						Request: `{
+							"query": "query($name: String!) {\n\t\t\t    orb(name: $name) {\n\t\t\t      versions(count: 1) {\n\t\t\t\t    version\n\t\t\t      }\n\t\t\t    }\n\t\t      }",
+							"variables": {"name": "my/orb"}
+						}`,
+						Response: `{"orb": {"versions": [{"version": "0.0.1"}]}}`})
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status: http.StatusOK,
+						Request: `{
+							"query": "\n\t\tquery ValidateOrb ($config: String!) {\n\t\t\torbConfig(orbYaml: $config) {\n\t\t\t\tvalid,\n\t\t\t\terrors { message },\n\t\t\t\tsourceYaml,\n\t\t\t\toutputYaml\n\t\t\t}\n\t\t}",
+							"variables": {"config": "some orb"}
+						}`,
+						Response: `{"orbConfig": {"sourceYaml": "{}", "valid": true, "errors": []}}`})
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session).Should(gexec.Exit(0))
+					Expect(session.Out).To(gbytes.Say("Dry run: orb `my/orb@0.1.0` is valid, but was not published."))
+					Expect(tempSettings.TestServer.ReceivedRequests()).To(HaveLen(2))
+				})
+
+				It("checks the orb against the local schema before calling the server", func() {
+					command = exec.Command(pathCLI,
+						"orb", "publish", "increment",
+						"--skip-update-check",
+						"--token", token,
+						"--host", tempSettings.TestServer.URL(),
+						orb.Path,
+						"my/orb", "minor",
+					)
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: Orb failed local schema validation \\(skip it with --skip-local-schema\\): Orb on line 1: expected object, but got string"))
+					Eventually(session).Should(clitest.ShouldFail())
+					Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+				})
+
+				It("packs a directory of orb source before publishing it", func() {
+					source := filepath.Join(tempSettings.Home, "my-orb")
+					clitest.OpenTmpFile(source, filepath.Join("src", "@orb.yml")).Write([]byte("version: 2.1\n"))
+					clitest.OpenTmpFile(source, filepath.Join("src", "commands", "greet.yml")).Write([]byte("steps:\n  - run: echo hello\n"))
+
+					command = exec.Command(pathCLI,
+						"orb", "publish", "increment",
+						"--skip-update-check",
+						"--token", token,
+						"--host", tempSettings.TestServer.URL(),
+						source,
+						"my/orb", "minor",
+					)
+
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status: http.StatusOK,
+						Request: `{
+							"query": "query($name: String!) {\n\t\t\t    orb(name: $name) {\n\t\t\t      versions(count: 1) {\n\t\t\t\t    version\n\t\t\t      }\n\t\t\t    }\n\t\t      }",
+							"variables": {"name": "my/orb"}
+						}`,
+						Response: `{"orb": {"versions": [{"version": "0.0.1"}]}}`})
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status: http.StatusOK,
+						Request: `{
+							"query": "\n\t\tmutation($config: String!, $orbName: String, $namespaceName: String, $version: String!) {\n\t\t\tpublishOrb(\n\t\t\t\torbName: $orbName,\n\t\t\t\tnamespaceName: $namespaceName,\n\t\t\t\torbYaml: $config,\n\t\t\t\tversion: $version\n\t\t\t) {\n\t\t\t\torb {\n\t\t\t\t\tversion\n\t\t\t\t}\n\t\t\t\terrors { message }\n\t\t\t}\n\t\t}\n\t",
+							"variables": {
+								"config": "version: 2.1\ncommands:\n    greet:\n        steps:\n            - run: echo hello\n",
+								"namespaceName": "my",
+								"orbName": "orb",
+								"version": "0.1.0"
+							}
+						}`,
+						Response: `{"publishOrb": {"errors": [], "orb": {"version": "0.1.0"}}}`})
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status: http.StatusOK,
+						Request: `{
+							"query": "\n\tquery ($name: String!, $namespace: String) {\n\t\torb(name: $name) {\n\t\t  id\n\t\t  isPrivate\n\t\t}\n\t\tregistryNamespace(name: $namespace) {\n\t\t\tid\n\t\t  }\n\t  }\n\t  ",
+							"variables": {"name": "my/orb", "namespace": "my"}
+						}`,
+						Response: `{"orb": {"id": "orbid1", "isPrivate": false}, "registryNamespace": {"id": "nsid1"}}`})
+
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Out).Should(gbytes.Say("Orb `my/orb` has been incremented to `my/orb@0.1.0`."))
+					Eventually(session).Should(gexec.Exit(0))
+				})
 			})
 
 			Describe("when promoting a development version", func() {
@@ -1438,6 +1986,7 @@ You can now register versions of %s using %s.`,
 						command = exec.Command(pathCLI,
 							"orb", "unlist",
 							"--skip-update-check",
+							"--no-prompt",
 							"--token", token,
 							"--host", tempSettings.TestServer.URL(),
 							"bar-ns/foo-orb",
@@ -1501,6 +2050,7 @@ You can now register versions of %s using %s.`,
 						command = exec.Command(pathCLI,
 							"orb", "unlist",
 							"--skip-update-check",
+							"--no-prompt",
 							"--token", token,
 							"--host", tempSettings.TestServer.URL(),
 							"bar-ns/foo-orb",
@@ -1567,6 +2117,7 @@ You can now register versions of %s using %s.`,
 						command = exec.Command(pathCLI,
 							"orb", "unlist",
 							"--skip-update-check",
+							"--no-prompt",
 							"--token", token,
 							"--host", tempSettings.TestServer.URL(),
 							"bar-ns/foo-orb",
@@ -1607,6 +2158,7 @@ You can now register versions of %s using %s.`,
 						command = exec.Command(pathCLI,
 							"orb", "unlist",
 							"--skip-update-check",
+							"--no-prompt",
 							"--token", token,
 							"--host", tempSettings.TestServer.URL(),
 							"bar-ns/foo-orb",
@@ -1649,6 +2201,7 @@ You can now register versions of %s using %s.`,
 						command = exec.Command(pathCLI,
 							"orb", "unlist",
 							"--skip-update-check",
+							"--no-prompt",
 							"--token", token,
 							"--host", tempSettings.TestServer.URL(),
 							"bar-ns/foo-orb",
@@ -1710,11 +2263,145 @@ You can now register versions of %s using %s.`,
 					Entry("unlisting an orb", false),
 				)
 			})
+			Context("with only the orb", func() {
+				var (
+					expectedOrbIDRequest string
+					expectedOrbRequest   string
+				)
+
+				BeforeEach(func() {
+					expectedOrbIDRequest = `{
+						"query": "\n\tquery ($name: String!, $namespace: String) {\n\t\torb(name: $name) {\n\t\t  id\n\t\t}\n\t\tregistryNamespace(name: $namespace) {\n\t\t  id\n\t\t}\n\t  }\n\t  ",
+						"variables": {
+							"name": "bar-ns/foo-orb",
+							"namespace": "bar-ns"
+						}
+					}`
+
+					expectedOrbRequest = `{
+						"query": "\n\t\tmutation($orbId: UUID!, $list: Boolean!) {\n\t\t\tsetOrbListStatus(\n\t\t\t\torbId: $orbId,\n\t\t\t\tlist: $list\n\t\t\t) {\n\t\t\t\tlisted\n\t\t\t\terrors { \n\t\t\t\t\tmessage\n\t\t\t\t\ttype \n\t\t\t\t}\n\t\t\t}\n\t\t}\n\t",
+						"variables": {
+							"list": false,
+							"orbId": "bb604b45-b6b0-4b81-ad80-796f15eddf87"
+						}
+					}`
+
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  expectedOrbIDRequest,
+						Response: `{"orb": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87"}}`})
+				})
+
+				It("asks for confirmation and unlists the orb", func() {
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  expectedOrbRequest,
+						Response: `{"setOrbListStatus": {"listed": false, "errors": []}}`})
+
+					command = exec.Command(pathCLI,
+						"orb", "unlist",
+						"--skip-update-check",
+						"--token", token,
+						"--host", tempSettings.TestServer.URL(),
+						"--integration-testing",
+						"bar-ns/foo-orb",
+					)
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Out).Should(gbytes.Say("Are you sure you wish to unlist the orb `bar-ns/foo-orb` from the registry"))
+					Eventually(session.Out).Should(gbytes.Say("The listing of orb `bar-ns/foo-orb` is now disabled."))
+					Eventually(session).Should(gexec.Exit(0))
+				})
+
+				It("refuses to unlist the orb without confirmation when stdin isn't a terminal", func() {
+					command = exec.Command(pathCLI,
+						"orb", "unlist",
+						"--skip-update-check",
+						"--token", token,
+						"--host", tempSettings.TestServer.URL(),
+						"bar-ns/foo-orb",
+					)
+					command.Stdin = strings.NewReader("y\n")
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: not unlisting orb `bar-ns/foo-orb` without confirmation, as stdin isn't a terminal"))
+					Eventually(session).Should(clitest.ShouldFail())
+					Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+				})
+
+				It("doesn't ask for confirmation when true is given explicitly", func() {
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  expectedOrbRequest,
+						Response: `{"setOrbListStatus": {"listed": false, "errors": []}}`})
+
+					command = exec.Command(pathCLI,
+						"orb", "unlist",
+						"--skip-update-check",
+						"--token", token,
+						"--host", tempSettings.TestServer.URL(),
+						"bar-ns/foo-orb", "true",
+					)
+					command.Stdin = strings.NewReader("")
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session).Should(gexec.Exit(0))
+					Expect(string(session.Out.Contents())).ToNot(ContainSubstring("Are you sure"))
+					Expect(session.Out).To(gbytes.Say("The listing of orb `bar-ns/foo-orb` is now disabled."))
+				})
+
+				It("prints the resulting listing state as json", func() {
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  expectedOrbRequest,
+						Response: `{"setOrbListStatus": {"listed": false, "errors": []}}`})
+
+					command = exec.Command(pathCLI,
+						"orb", "unlist",
+						"--skip-update-check",
+						"--token", token,
+						"--host", tempSettings.TestServer.URL(),
+						"--no-prompt",
+						"--json",
+						"bar-ns/foo-orb",
+					)
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session).Should(gexec.Exit(0))
+					Expect(session.Out.Contents()).To(MatchJSON(`{"orb": "bar-ns/foo-orb", "listed": false}`))
+				})
+
+				It("explains that the token lacks permission", func() {
+					tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  expectedOrbRequest,
+						Response: `{"setOrbListStatus": {"listed": null, "errors": [{"message": "AUTHORIZATION_FAILURE", "type": "AUTHORIZATION_FAILURE"}]}}`})
+
+					command = exec.Command(pathCLI,
+						"orb", "unlist",
+						"--skip-update-check",
+						"--token", token,
+						"--host", tempSettings.TestServer.URL(),
+						"--no-prompt",
+						"bar-ns/foo-orb",
+					)
+					session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+					Expect(err).ShouldNot(HaveOccurred())
+					Eventually(session.Err).Should(gbytes.Say("Error: you don't have permission to change the listing of orb `bar-ns/foo-orb`, only admins of the organization that owns the `bar-ns` namespace can: AUTHORIZATION_FAILURE"))
+					Eventually(session).Should(clitest.ShouldFail())
+				})
+			})
 			Context("incorrect number of arguments supplied", func() {
 				DescribeTable("when setting the listed status of an orb",
 					func(args ...string) {
 						argList := []string{"orb", "unlist",
 							"--skip-update-check",
+							"--no-prompt",
 							"--token", token,
 							"--host", tempSettings.TestServer.URL()}
 						newArgList := append(argList, args...)
@@ -1724,34 +2411,181 @@ You can now register versions of %s using %s.`,
 						session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
 
 						Expect(err).ShouldNot(HaveOccurred())
-						Eventually(session.Err).Should(gbytes.Say("Error: accepts 2 arg\\(s\\), received %d", len(args)))
+						Eventually(session.Err).Should(gbytes.Say("Error: accepts between 1 and 2 arg\\(s\\), received %d", len(args)))
 						Eventually(session).ShouldNot(gexec.Exit(0))
 					},
 					Entry("0 args"),
-					Entry("1 arg", "bar-ns/foo-orb"),
 					Entry("3 args", "bar-ns/foo-orb", "true", "true"),
 				)
 			})
-			Context("invalid arguments supplied", func() {
-				DescribeTable("when setting the listed status of an orb",
-					func(expectedError string, args ...string) {
-						argList := []string{"orb", "unlist",
-							"--skip-update-check",
-							"--token", token,
-							"--host", tempSettings.TestServer.URL()}
-						newArgList := append(argList, args...)
-						command = exec.Command(pathCLI,
-							newArgList...,
-						)
-						session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Context("invalid arguments supplied", func() {
+				DescribeTable("when setting the listed status of an orb",
+					func(expectedError string, args ...string) {
+						argList := []string{"orb", "unlist",
+							"--skip-update-check",
+							"--no-prompt",
+							"--token", token,
+							"--host", tempSettings.TestServer.URL()}
+						newArgList := append(argList, args...)
+						command = exec.Command(pathCLI,
+							newArgList...,
+						)
+						session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+						Expect(err).ShouldNot(HaveOccurred())
+						Eventually(session.Err).Should(gbytes.Say(expectedError))
+						Eventually(session).ShouldNot(gexec.Exit(0))
+					},
+					Entry("invalid orb name", "Error: Invalid orb foo-orb. Expected a namespace and orb in the form 'namespace/orb'", "foo-orb", "true"),
+					Entry("non-boolean value", "Error: expected \"true\" or \"false\", got \"falsey\"", "bar-ns/foo-orb", "falsey"),
+				)
+			})
+		})
+
+		Describe("when deleting a dev version of an orb", func() {
+			expectedOrbIDRequest := `{
+				"query": "\n\tquery ($name: String!, $namespace: String) {\n\t\torb(name: $name) {\n\t\t  id\n\t\t}\n\t\tregistryNamespace(name: $namespace) {\n\t\t  id\n\t\t}\n\t  }\n\t  ",
+				"variables": {
+					"name": "bar-ns/foo-orb",
+					"namespace": "bar-ns"
+				}
+			}`
+
+			expectedDeleteRequest := `{
+				"query": "\n\t\tmutation($orbId: UUID!, $version: String!) {\n\t\t\tdeleteOrbVersion(\n\t\t\t\torbId: $orbId,\n\t\t\t\tversion: $version\n\t\t\t) {\n\t\t\t\tdeleted\n\t\t\t\terrors {\n\t\t\t\t\tmessage\n\t\t\t\t\ttype\n\t\t\t\t}\n\t\t\t}\n\t\t}\n\t",
+				"variables": {
+					"orbId": "bb604b45-b6b0-4b81-ad80-796f15eddf87",
+					"version": "dev:broken"
+				}
+			}`
+
+			appendOrbIDHandler := func() {
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  expectedOrbIDRequest,
+					Response: `{"orb": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87"}}`})
+			}
+
+			It("asks for confirmation before deleting the version", func() {
+				command = exec.Command(pathCLI,
+					"orb", "delete-version",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--integration-testing",
+					"bar-ns/foo-orb@dev:broken",
+				)
+				appendOrbIDHandler()
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  expectedDeleteRequest,
+					Response: `{"deleteOrbVersion": {"deleted": true, "errors": []}}`})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out).Should(gbytes.Say("Are you sure you wish to delete the orb version `bar-ns/foo-orb@dev:broken`?"))
+				Expect(session.Out).Should(gbytes.Say("Orb version `bar-ns/foo-orb@dev:broken` was deleted."))
+			})
+
+			It("refuses to delete the version without confirmation when stdin isn't a terminal", func() {
+				command = exec.Command(pathCLI,
+					"orb", "delete-version",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"bar-ns/foo-orb@dev:broken",
+				)
+				command.Stdin = strings.NewReader("y\n")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: not deleting orb version `bar-ns/foo-orb@dev:broken` without confirmation, as stdin isn't a terminal, use --force to delete it without asking"))
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("reports the result as JSON with --force and --json", func() {
+				command = exec.Command(pathCLI,
+					"orb", "delete-version",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--force",
+					"--json",
+					"bar-ns/foo-orb@dev:broken",
+				)
+				appendOrbIDHandler()
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  expectedDeleteRequest,
+					Response: `{"deleteOrbVersion": {"deleted": true, "errors": []}}`})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out.Contents()).Should(MatchJSON(`{"orb": "bar-ns/foo-orb@dev:broken", "deleted": true}`))
+			})
+
+			It("refuses to delete a published version", func() {
+				command = exec.Command(pathCLI,
+					"orb", "delete-version",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--force",
+					"bar-ns/foo-orb@1.2.3",
+				)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: `bar-ns/foo-orb@1.2.3` is a published version, which can't be deleted: published versions of orbs are immutable"))
+				Eventually(session.Err).Should(gbytes.Say("Only dev versions, such as `bar-ns/foo-orb@dev:alpha`, can be deleted."))
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("explains when the user isn't allowed to delete the version", func() {
+				command = exec.Command(pathCLI,
+					"orb", "delete-version",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--force",
+					"bar-ns/foo-orb@dev:broken",
+				)
+				appendOrbIDHandler()
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  expectedDeleteRequest,
+					Response: `{"deleteOrbVersion": {"deleted": false, "errors": [{"message": "AUTHORIZATION_FAILURE", "type": "AUTHORIZATION_FAILURE"}]}}`})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: you don't have permission to delete orb version `bar-ns/foo-orb@dev:broken`, only admins of the organization that owns the `bar-ns` namespace can"))
+				Eventually(session).Should(clitest.ShouldFail())
+			})
 
-						Expect(err).ShouldNot(HaveOccurred())
-						Eventually(session.Err).Should(gbytes.Say(expectedError))
-						Eventually(session).ShouldNot(gexec.Exit(0))
-					},
-					Entry("invalid orb name", "Error: Invalid orb foo-orb. Expected a namespace and orb in the form 'namespace/orb'", "foo-orb", "true"),
-					Entry("non-boolean value", "Error: expected \"true\" or \"false\", got \"falsey\"", "bar-ns/foo-orb", "falsey"),
+			It("explains when the API doesn't support deleting versions", func() {
+				command = exec.Command(pathCLI,
+					"orb", "delete-version",
+					"--skip-update-check",
+					"--token", token,
+					"--host", tempSettings.TestServer.URL(),
+					"--force",
+					"bar-ns/foo-orb@dev:broken",
 				)
+				appendOrbIDHandler()
+				tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+					Status:        http.StatusOK,
+					Request:       expectedDeleteRequest,
+					Response:      `null`,
+					ErrorResponse: `[{"message": "Cannot query field 'deleteOrbVersion' on type 'Mutation'."}]`})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: the CircleCI API at .* doesn't support deleting orb versions, so `bar-ns/foo-orb@dev:broken` was not deleted"))
+				Eventually(session).Should(clitest.ShouldFail())
 			})
 		})
 
@@ -1775,6 +2609,7 @@ query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
 		cursor
 	  node {
 	    name
+	    createdAt
 	    statistics {
 		last30DaysBuildCount,
 		last30DaysProjectCount,
@@ -1846,6 +2681,7 @@ query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
 		cursor
 	  node {
 	    name
+	    createdAt
 	    statistics {
 		last30DaysBuildCount,
 		last30DaysProjectCount,
@@ -1963,6 +2799,57 @@ Search, filter, and view sources for all Orbs online at https://circleci.com/dev
 `))
 			})
 
+			It("should sort by when they were created, newest first", func() {
+				command = exec.Command(pathCLI,
+					"orb", "list",
+					"--sort", "created",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+				)
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(ContainSubstring("\nthird (0.9.0)\nfirst (0.7.0)\nsecond (0.8.0)\n"))
+			})
+
+			It("should sort by name in reverse", func() {
+				command = exec.Command(pathCLI,
+					"orb", "list",
+					"--sort", "name",
+					"--reverse",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+				)
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(ContainSubstring("\nthird (0.9.0)\nsecond (0.8.0)\nfirst (0.7.0)\n"))
+			})
+
+			It("should order the orbs printed with --json", func() {
+				command = exec.Command(pathCLI,
+					"orb", "list",
+					"--sort", "usage",
+					"--reverse",
+					"--json",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+				)
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+
+				var listed struct {
+					Orbs []struct {
+						Name string `json:"name"`
+					} `json:"orbs"`
+				}
+				Expect(json.Unmarshal(session.Out.Contents(), &listed)).To(Succeed())
+				Expect(listed.Orbs).To(HaveLen(3))
+				Expect([]string{listed.Orbs[0].Name, listed.Orbs[1].Name, listed.Orbs[2].Name}).To(Equal([]string{"first", "third", "second"}))
+				Expect(string(session.Out.Contents())).ToNot(ContainSubstring("createdAt"))
+			})
+
 		})
 
 		Describe("when using --sort with invalid option", func() {
@@ -1979,7 +2866,20 @@ Search, filter, and view sources for all Orbs online at https://circleci.com/dev
 				Eventually(session).Should(clitest.ShouldFail())
 
 				stderr := session.Wait().Err.Contents()
-				Expect(string(stderr)).To(Equal("Error: expected `idontknow` to be one of \"builds\", \"projects\", or \"orgs\"\n"))
+				Expect(string(stderr)).To(Equal("Error: expected `idontknow` to be one of \"name\", \"created\", \"usage\", \"builds\", \"projects\", or \"orgs\"\n"))
+			})
+
+			It("should refuse --reverse without --sort", func() {
+				command = exec.Command(pathCLI,
+					"orb", "list",
+					"--reverse",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+				)
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: --reverse can only be used with --sort"))
+				Eventually(session).Should(clitest.ShouldFail())
 			})
 		})
 
@@ -2003,6 +2903,7 @@ query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
 		cursor
 	  node {
 	    name
+	    createdAt
 	    statistics {
 		last30DaysBuildCount,
 		last30DaysProjectCount,
@@ -2069,6 +2970,87 @@ query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
 				Expect(completeOutput).Should(MatchJSON(expectedOutput))
 				Expect(tempSettings.TestServer.ReceivedRequests()).Should(HaveLen(2))
 			})
+
+			It("prints each orb as a line of json with --jsonl", func() {
+				command = exec.Command(pathCLI,
+					"orb", "list",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+					"--jsonl",
+				)
+
+				query := `
+query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
+  orbs(first: 20, after: $after, certifiedOnly: $certifiedOnly) {
+	totalCount,
+    edges {
+		cursor
+	  node {
+	    name
+	    createdAt
+	    statistics {
+		last30DaysBuildCount,
+		last30DaysProjectCount,
+		last30DaysOrganizationCount
+	    }
+		  versions(count: 1) {
+			version,
+			source
+		  }
+		}
+	}
+    pageInfo {
+      hasNextPage
+    }
+  }
+}
+`
+				for _, page := range []struct{ after, response string }{
+					{"", "gql_orb_list/first_response.json"},
+					{"test/test", "gql_orb_list/second_response.json"},
+				} {
+					request := graphql.NewRequest(query)
+					request.Variables["after"] = page.after
+					request.Variables["certifiedOnly"] = true
+					encoded, err := request.Encode()
+					Expect(err).ShouldNot(HaveOccurred())
+
+					tempSettings.AppendPostHandler("", clitest.MockRequestResponse{
+						Status:   http.StatusOK,
+						Request:  encoded.String(),
+						Response: string(golden.Get(GinkgoT(), filepath.FromSlash(page.response))),
+					})
+				}
+
+				var expected struct {
+					Orbs []json.RawMessage `json:"orbs"`
+				}
+				Expect(json.Unmarshal(golden.Get(GinkgoT(), filepath.FromSlash("gql_orb_list/pretty_json_output.json")), &expected)).To(Succeed())
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+
+				lines := strings.Split(strings.TrimSuffix(string(session.Out.Contents()), "\n"), "\n")
+				Expect(lines).To(HaveLen(len(expected.Orbs)))
+				for i, line := range lines {
+					Expect(line).To(MatchJSON(expected.Orbs[i]))
+				}
+			})
+
+			It("refuses --jsonl with --sort", func() {
+				command = exec.Command(pathCLI,
+					"orb", "list",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+					"--jsonl", "--sort", "builds",
+				)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: --jsonl can't be used with --sort"))
+				Eventually(session).Should(clitest.ShouldFail())
+			})
 		})
 
 		Describe("when listing all orbs with --uncertified", func() {
@@ -2089,6 +3071,7 @@ query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
 		cursor
 	  node {
 	    name
+	    createdAt
 	    statistics {
 		last30DaysBuildCount,
 		last30DaysProjectCount,
@@ -2201,6 +3184,7 @@ query ListOrbs ($after: String!, $certifiedOnly: Boolean!) {
 		cursor
 	  node {
 	    name
+	    createdAt
 	    statistics {
 		last30DaysBuildCount,
 		last30DaysProjectCount,
@@ -2318,6 +3302,7 @@ query namespaceOrbs ($namespace: String, $after: String!, $view: OrbListViewType
 						version
 					}
 					name
+					createdAt
 	                                statistics {
 		                           last30DaysBuildCount,
 		                           last30DaysProjectCount,
@@ -2434,6 +3419,7 @@ query namespaceOrbs ($namespace: String, $after: String!, $view: OrbListViewType
 						version
 					}
 					name
+					createdAt
 	                                statistics {
 		                           last30DaysBuildCount,
 		                           last30DaysProjectCount,
@@ -2496,6 +3482,7 @@ query namespaceOrbs ($namespace: String, $after: String!, $view: OrbListViewType
 						version
 					}
 					name
+					createdAt
 	                                statistics {
 		                           last30DaysBuildCount,
 		                           last30DaysProjectCount,
@@ -2597,7 +3584,7 @@ https://circleci.com/account/api`))
 					"orb", "create", "bar-ns/foo-orb",
 					"--skip-update-check",
 					"--token", "",
-					"--host", "foo.bar",
+					"--host", "https://foo.bar",
 				)
 
 				By("running the command")
@@ -2606,7 +3593,7 @@ https://circleci.com/account/api`))
 				Expect(err).ShouldNot(HaveOccurred())
 				Eventually(session.Err).Should(gbytes.Say(`Error: please set a token with 'circleci setup'
 You can create a new personal API token here:
-foo.bar/account/api`))
+https://foo.bar/account/api`))
 				Eventually(session).Should(clitest.ShouldFail())
 			})
 		})
@@ -2697,6 +3684,61 @@ foo.bar/account/api`))
 
 				Eventually(session).Should(clitest.ShouldFail())
 			})
+
+			It("fetches the version given with --version", func() {
+				command = exec.Command(pathCLI,
+					"orb", "source",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+					"my/orb",
+					"--version", "1.2.3",
+				)
+
+				request := graphql.NewRequest(`query($orbVersionRef: String!) {
+			    orbVersion(orbVersionRef: $orbVersionRef) {
+			        id
+                                version
+                                orb { id }
+                                source
+			    }
+		      }`)
+				request.Variables["orbVersionRef"] = "my/orb@1.2.3"
+				encoded, err := request.Encode()
+				Expect(err).ShouldNot(HaveOccurred())
+
+				tempSettings.AppendPostHandler("", clitest.MockRequestResponse{
+					Status:  http.StatusOK,
+					Request: encoded.String(),
+					Response: `{"orbVersion": {
+						"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87",
+						"version": "1.2.3",
+						"orb": {"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87"},
+						"source": "older orb"
+					}}`})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(string(session.Out.Contents())).To(Equal("older orb\n"))
+			})
+
+			It("doesn't accept --version with a versioned reference", func() {
+				command = exec.Command(pathCLI,
+					"orb", "source",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+					"my/orb@dev:foo",
+					"--version", "1.2.3",
+				)
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Err).Should(gbytes.Say("Error: --version 1.2.3 can't be used with my/orb@dev:foo, which already has a version"))
+				Eventually(session).Should(clitest.ShouldFail())
+				Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+			})
 		})
 
 		Describe("when fetching an orb's meta-data", func() {
@@ -2820,6 +3862,52 @@ https://circleci.com/developer/orbs/orb/my/orb
 				Eventually(session).Should(gexec.Exit(0))
 			})
 
+			It("looks up a partial version as given", func() {
+				command = exec.Command(pathCLI,
+					"orb", "info",
+					"--skip-update-check",
+					"--host", tempSettings.TestServer.URL(),
+					"my/orb@5",
+				)
+
+				request = graphql.NewRequest(query)
+				request.Variables["orbVersionRef"] = "my/orb@5"
+				expected, err = request.Encode()
+				Expect(err).ShouldNot(HaveOccurred())
+
+				response := `{
+							"orbVersion": {
+								"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87",
+								"version": "5.2.0",
+								"orb": {
+									"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87",
+									"createdAt": "2018-09-24T08:53:37.086Z",
+									"name": "my/orb",
+									"versions": [
+										{
+											"version": "5.2.0",
+											"createdAt": "2018-10-11T22:12:19.477Z"
+										}
+									]
+								},
+								"source": "description: zomg",
+								"createdAt": "2018-09-24T08:53:37.086Z"
+							}
+						}`
+
+				tempSettings.AppendPostHandler("", clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  expected.String(),
+					Response: response,
+				})
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session.Out).Should(gbytes.Say("Latest: my/orb@5.2.0"))
+				Eventually(session).Should(gexec.Exit(0))
+			})
+
 			It("reports usage statistics", func() {
 				response := `{
 							"orbVersion": {
@@ -2945,6 +4033,62 @@ https://circleci.com/developer/orbs/orb/my/orb
 
 				Eventually(session).Should(clitest.ShouldFail())
 			})
+
+			It("prints the meta-data as JSON with --json", func() {
+				command.Args = append(command.Args, "--json")
+
+				response := `{
+							"orbVersion": {
+								"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87",
+								"version": "dev:foo",
+								"orb": {
+									"id": "bb604b45-b6b0-4b81-ad80-796f15eddf87",
+									"createdAt": "2018-09-24T08:53:37.086Z",
+									"name": "my/orb",
+									"categories": [{"id": "cc604b45-b6b0-4b81-ad80-796f15eddf87", "name": "Testing"}],
+									"statistics": {
+										"last30DaysBuildCount": 555,
+										"last30DaysProjectCount": 777,
+										"last30DaysOrganizationCount": 999
+									},
+									"versions": [
+										{"version": "0.0.2", "createdAt": "2018-10-12T22:12:19.477Z"},
+										{"version": "0.0.1", "createdAt": "2018-10-11T22:12:19.477Z"}
+									]
+								},
+								"source": "orbs:\n  node: circleci/node@5.0.0\n  inline:\n    commands: {}\n  aws-cli: circleci/aws-cli@3.1\ncommands: {foo: {}}",
+								"createdAt": "2018-10-01T08:53:37.086Z"
+							}
+						}`
+
+				tempSettings.AppendPostHandler("", clitest.MockRequestResponse{
+					Status:   http.StatusOK,
+					Request:  expected.String(),
+					Response: response,
+				})
+
+				By("running the command")
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+
+				Expect(err).ShouldNot(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out.Contents()).To(MatchJSON(`{
+					"name": "my/orb",
+					"version": "dev:foo",
+					"version_created_at": "2018-10-01T08:53:37.086Z",
+					"latest_version": "0.0.2",
+					"created_at": "2018-09-24T08:53:37.086Z",
+					"last_updated_at": "2018-10-12T22:12:19.477Z",
+					"total_revisions": 2,
+					"statistics": {"builds": 555, "projects": 777, "organizations": 999},
+					"categories": ["Testing"],
+					"dependencies": [
+						{"name": "aws-cli", "orb": "circleci/aws-cli@3.1"},
+						{"name": "inline", "orb": ""},
+						{"name": "node", "orb": "circleci/node@5.0.0"}
+					]
+				}`))
+			})
 		})
 
 		Describe("list orb categories", func() {
@@ -3341,5 +4485,58 @@ Windows Server 2010
 			Eventually(session).Should(gexec.Exit(0))
 		})
 
+		It("Packs the orb again when a file changes with --watch", func() {
+			command = exec.Command(pathCLI,
+				"orb", "pack",
+				"--skip-update-check",
+				"--watch",
+				tempSettings.Home,
+			)
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session.Out).Should(gbytes.Say(`command: echo Hello, world!`))
+			Eventually(session.Err).Should(gbytes.Say(`Watching .* for changes`))
+
+			// Editor swap files are ignored, so nothing is packed for this one
+			Expect(ioutil.WriteFile(filepath.Join(tempSettings.Home, "scripts", ".script.sh.swp"), []byte("swap"), 0600)).To(Succeed())
+			Expect(ioutil.WriteFile(script.Path, []byte(`echo Hello, again!`), 0600)).To(Succeed())
+			Eventually(session.Err).Should(gbytes.Say(`\[\d\d:\d\d:\d\d\] Packed the orb.`))
+			Eventually(session.Out).Should(gbytes.Say(`command: echo Hello, again!`))
+
+			// A broken include is reported without stopping the watch
+			Expect(ioutil.WriteFile(orb.Path, []byte(`steps:
+    - run:
+        command: <<include(scripts/missing.sh)>>
+`), 0600)).To(Succeed())
+			Eventually(session.Err).Should(gbytes.Say(`Pack failed: `))
+			Consistently(session).ShouldNot(gexec.Exit())
+
+			session.Interrupt()
+			Eventually(session.Err).Should(gbytes.Say("Stopped watching."))
+			Eventually(session).Should(gexec.Exit(0))
+		})
+
+		It("Checks the orb against the local schema with --watch --validate", func() {
+			command = exec.Command(pathCLI,
+				"orb", "pack",
+				"--skip-update-check",
+				"--token", "testtoken",
+				"--host", tempSettings.TestServer.URL(),
+				"--watch",
+				"--validate",
+				tempSettings.Home,
+			)
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			// The @orb.yml is empty, so the packed orb has no version
+			Eventually(session.Err).Should(gbytes.Say(`Packed, but the orb is invalid: Orb failed local schema validation \(skip it with --skip-local-schema\): Orb on line 1: missing properties: 'version'`))
+			Eventually(session.Err).Should(gbytes.Say(`Watching .* for changes`))
+			Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+
+			session.Interrupt()
+			Eventually(session).Should(gexec.Exit(0))
+		})
 	})
 })
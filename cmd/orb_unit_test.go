@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("deleting an orb version", func() {
+	It("fails without deleting the version when the user declines", func() {
+		// No client is given, so any call to the API would panic
+		err := deleteOrbVersion(orbOptions{
+			args:               []string{"bar-ns/foo-orb@dev:broken"},
+			tty:                createOrbTestUI{confirm: false},
+			integrationTesting: true,
+		})
+		Expect(err).To(MatchError("orb version `bar-ns/foo-orb@dev:broken` was not deleted"))
+	})
+})
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-cli/api"
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// watchDebounce is how long to wait after a change before packing the orb,
+// so that saving several files at once only packs it once.
+var watchDebounce = 300 * time.Millisecond
+
+// watchOrbPack packs the orb at opts.args[0] each time one of its files
+// changes, until interrupted. The packed orb is printed to stdout, and a
+// timestamped status for each pack to stderr. Failures are reported without
+// stopping the watch, so that they can be fixed and the orb packed again.
+func watchOrbPack(opts orbOptions) error {
+	root := opts.args[0]
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "Unable to watch for changes")
+	}
+	defer watcher.Close()
+
+	if err := watchDirectories(watcher, root); err != nil {
+		return err
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	repackOrb(opts, root)
+	fmt.Fprintf(os.Stderr, "Watching %s for changes, press Ctrl-C to stop.\n", root)
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if ignoredByWatch(event.Name) {
+				continue
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watchDirectories(watcher, event.Name); err != nil {
+						fmt.Fprintf(os.Stderr, "%s\n", err)
+					}
+				}
+			}
+			debounce = time.After(watchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "[%s] Error watching for changes: %s\n", watchTimestamp(), err)
+
+		case <-debounce:
+			debounce = nil
+			repackOrb(opts, root)
+
+		case <-interrupt:
+			fmt.Fprintln(os.Stderr, "Stopped watching.")
+			return nil
+		}
+	}
+}
+
+// repackOrb packs, and with --validate validates, the orb at root, printing
+// the result. Errors are printed rather than returned.
+func repackOrb(opts orbOptions, root string) {
+	packed, err := packOrb(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] Pack failed: %s\n", watchTimestamp(), err)
+		return
+	}
+
+	if opts.watchValidate {
+		if err := validatePackedOrb(opts, packed); err != nil {
+			fmt.Fprintf(os.Stderr, "[%s] Packed, but the orb is invalid: %s\n", watchTimestamp(), err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "[%s] Packed and validated the orb.\n", watchTimestamp())
+	} else {
+		fmt.Fprintf(os.Stderr, "[%s] Packed the orb.\n", watchTimestamp())
+	}
+
+	fmt.Println(packed)
+}
+
+// validatePackedOrb validates the packed orb as `orb validate` would.
+func validatePackedOrb(opts orbOptions, packed string) error {
+	if !opts.skipLocalSchema {
+		if err := validateOrbSchema(packed); err != nil {
+			return err
+		}
+	}
+
+	_, err := api.OrbSourceQuery(opts.cl, packed)
+	return err
+}
+
+// watchDirectories adds dir and every directory below it to watcher, since
+// fsnotify doesn't watch recursively. Hidden directories, like .git, are
+// skipped.
+func watchDirectories(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return errors.Wrapf(err, "Unable to watch %s", path)
+		}
+		return nil
+	})
+}
+
+// ignoredByWatch reports whether path is one of the swap, backup or
+// temporary files editors write while saving, which shouldn't trigger a pack.
+func ignoredByWatch(path string) bool {
+	name := filepath.Base(path)
+
+	switch {
+	case strings.HasPrefix(name, "."):
+		// vim swap files (.orb.yml.swp), emacs lock files (.#orb.yml)
+		return true
+	case strings.HasPrefix(name, "#") && strings.HasSuffix(name, "#"):
+		// emacs auto-saves
+		return true
+	case strings.HasSuffix(name, "~"):
+		return true
+	case name == "4913":
+		// vim checks it can write to the directory with this file
+		return true
+	}
+
+	for _, ext := range []string{".swp", ".swx", ".swo", ".tmp", ".bak"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func watchTimestamp() string {
+	return time.Now().Format("15:04:05")
+}
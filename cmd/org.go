@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-cli/api"
+	"github.com/CircleCI-Public/circleci-cli/api/graphql"
+	"github.com/CircleCI-Public/circleci-cli/settings"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// orgOptions are the --org-slug and --org-id flags, which commands that act
+// on an organization accept in place of its <vcs-type> <org-name> arguments.
+type orgOptions struct {
+	cfg  *settings.Config
+	slug string
+	id   string
+
+	// fromDefault is set when id is the default organization of the config
+	// file, as neither the flags nor the arguments gave one
+	fromDefault bool
+
+	// resolved is the organization once it has been looked up, so that it is
+	// only looked up once for each invocation
+	resolved *api.Organization
+}
+
+func addOrgFlags(flags *pflag.FlagSet, opts *orgOptions) {
+	flags.StringVar(&opts.slug, "org-slug", "", "the slug of the organization, such as github/example-org, in place of <vcs-type> <org-name>")
+	flags.StringVar(&opts.id, "org-id", "", "the ID of the organization, in place of <vcs-type> <org-name>")
+}
+
+func (opts *orgOptions) given() bool {
+	return opts.slug != "" || opts.id != ""
+}
+
+// orgArgs accepts n arguments when the organization is given with
+// --org-slug or --org-id, or otherwise those n along with the organization's
+// <vcs-type> <org-name>. Without either, the default organization of the
+// config file is used.
+func orgArgs(opts *orgOptions, n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if opts.given() {
+			return cobra.ExactArgs(n)(cmd, args)
+		}
+		if len(args) == n {
+			if opts.cfg.DefaultOrgID == "" {
+				return errors.New("no organization was given, use --org-slug <vcs-type>/<org-name>, --org-id <id>, or the <vcs-type> <org-name> arguments, or save a default with `circleci settings set default_org_id <id>`")
+			}
+			opts.id = opts.cfg.DefaultOrgID
+			opts.fromDefault = true
+			return nil
+		}
+		return cobra.ExactArgs(n+2)(cmd, args)
+	}
+}
+
+// organization returns the organization given by --org-slug or --org-id, or
+// by the <vcs-type> <org-name> arguments at position i of args, along with
+// the rest of args. The organization's ID is only looked up when it was given
+// with --org-id, to find its slug, or when both flags were given, to check
+// that they agree.
+func (opts *orgOptions) organization(args []string, i int) (*api.Organization, []string, error) {
+	if !opts.given() {
+		org := &api.Organization{VCSType: args[i], Name: args[i+1]}
+		rest := append(append([]string{}, args[:i]...), args[i+2:]...)
+		return org, rest, nil
+	}
+
+	if opts.resolved != nil {
+		return opts.resolved, args, nil
+	}
+
+	var slug *api.Organization
+	if opts.slug != "" {
+		parts := strings.Split(opts.slug, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, nil, fmt.Errorf("invalid --org-slug %s, expected <vcs-type>/<org-name> such as github/example-org", opts.slug)
+		}
+		slug = &api.Organization{VCSType: parts[0], Name: parts[1]}
+	}
+
+	switch {
+	case opts.id == "":
+		opts.resolved = slug
+
+	case slug == nil:
+		org, err := api.OrganizationByID(opts.client(), opts.id)
+		if err != nil {
+			if opts.fromDefault {
+				return nil, nil, errors.Wrap(err, "Unable to use the default organization from default_org_id")
+			}
+			return nil, nil, err
+		}
+		opts.resolved = org
+		if opts.fromDefault {
+			fmt.Fprintf(os.Stderr, "Using the default organization %s/%s (%s).\n", org.VCSType, org.Name, org.ID)
+		}
+
+	default:
+		org, err := api.OrganizationBySlug(opts.client(), slug.VCSType, slug.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if org.ID != opts.id {
+			return nil, nil, fmt.Errorf("--org-slug %s and --org-id %s are different organizations, the ID of %s is %s", opts.slug, opts.id, opts.slug, org.ID)
+		}
+		opts.resolved = org
+	}
+
+	return opts.resolved, args, nil
+}
+
+// organizationID is organization, but always looks up the ID of the
+// organization when it wasn't given.
+func (opts *orgOptions) organizationID(args []string, i int) (*api.Organization, []string, error) {
+	org, rest, err := opts.organization(args, i)
+	if err != nil || org.ID != "" {
+		return org, rest, err
+	}
+
+	org, err = api.OrganizationBySlug(opts.client(), strings.ToUpper(org.VCSType), org.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.given() {
+		opts.resolved = org
+	}
+
+	return org, rest, nil
+}
+
+func (opts *orgOptions) client() *graphql.Client {
+	return graphql.NewClient(opts.cfg.HTTPClient, opts.cfg.Host, opts.cfg.Endpoint, opts.cfg.Token, opts.cfg.Debug)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// rootOutputFromFlag stores the path of the file passed in through the flag
+// --output-file
+var rootOutputFromFlag string
+
+// rootOutput is the file that stdout was redirected to by --output-file, if
+// any.
+var rootOutput *outputFile
+
+// An outputFile collects what a command prints to stdout in a temporary file
+// next to path, which replaces path only once the command succeeds, so that
+// a failed command never leaves a partial file behind.
+type outputFile struct {
+	path   string
+	temp   *os.File
+	stdout *os.File
+}
+
+// redirectOutput points stdout at a new outputFile for path.
+func redirectOutput(path string) (*outputFile, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return nil, fmt.Errorf("--output-file %s is a directory, expected the path of a file", path)
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to write to --output-file %s", path)
+	}
+
+	out := &outputFile{path: path, temp: temp, stdout: os.Stdout}
+	os.Stdout = temp
+	return out, nil
+}
+
+// close points stdout back at the terminal and, when the command succeeded,
+// moves the output into place, keeping the permissions of the file it
+// replaces. Otherwise the output is thrown away.
+func (out *outputFile) close(succeeded bool) error {
+	os.Stdout = out.stdout
+	err := out.temp.Close()
+	if err != nil || !succeeded {
+		_ = os.Remove(out.temp.Name())
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(out.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(out.temp.Name(), mode); err != nil {
+		_ = os.Remove(out.temp.Name())
+		return err
+	}
+
+	if err := os.Rename(out.temp.Name(), out.path); err != nil {
+		_ = os.Remove(out.temp.Name())
+		return errors.Wrapf(err, "Unable to write to --output-file %s", out.path)
+	}
+	return nil
+}
+
+// statusOutput is where infof and infoln print, which is stderr when stdout
+// was redirected with --output-file, so that only the result ends up in the
+// file.
+func statusOutput() io.Writer {
+	if rootOutput != nil {
+		return os.Stderr
+	}
+	return os.Stdout
+}
@@ -0,0 +1,96 @@
+package cmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/CircleCI-Public/circleci-cli/clitest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("--output-file", func() {
+	var (
+		tempSettings *clitest.TempSettings
+		output       string
+	)
+
+	BeforeEach(func() {
+		tempSettings = clitest.WithTempSettings()
+		output = filepath.Join(tempSettings.Home, "out.yml")
+	})
+
+	AfterEach(func() {
+		tempSettings.Close()
+	})
+
+	It("writes the result of the command to the file", func() {
+		dir := filepath.Join(tempSettings.Home, "config")
+		Expect(os.MkdirAll(dir, 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "config.yml"), []byte("version: 2.1\n"), 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(output, []byte("old contents\n"), 0640)).To(Succeed())
+
+		command := commandWithHome(pathCLI, tempSettings.Home,
+			"config", "pack", dir,
+			"--skip-update-check",
+			"--output-file", output,
+		)
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(0))
+		Expect(session.Out.Contents()).To(BeEmpty())
+		Expect(ioutil.ReadFile(output)).To(BeEquivalentTo("version: 2.1\n\n"))
+
+		info, err := os.Stat(output)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0640)))
+	})
+
+	It("prints messages to stderr rather than to the file", func() {
+		command := commandWithHome(pathCLI, tempSettings.Home,
+			"cache", "clear",
+			"--skip-update-check",
+			"--output-file", output,
+		)
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(0))
+		Expect(session.Err).To(gbytes.Say("Cleared the cache at"))
+		Expect(ioutil.ReadFile(output)).To(BeEmpty())
+	})
+
+	It("leaves the file alone when the command fails", func() {
+		Expect(ioutil.WriteFile(output, []byte("old contents\n"), 0600)).To(Succeed())
+
+		command := commandWithHome(pathCLI, tempSettings.Home,
+			"config", "pack", filepath.Join(tempSettings.Home, "missing"),
+			"--skip-update-check",
+			"--output-file", output,
+		)
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(session).Should(clitest.ShouldFail())
+		Expect(ioutil.ReadFile(output)).To(BeEquivalentTo("old contents\n"))
+		Expect(filepath.Glob(filepath.Join(tempSettings.Home, ".out.yml.*"))).To(BeEmpty())
+	})
+
+	It("refuses to replace a directory", func() {
+		command := commandWithHome(pathCLI, tempSettings.Home,
+			"version",
+			"--skip-update-check",
+			"--output-file", tempSettings.Home,
+		)
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(session).Should(clitest.ShouldFail())
+		Expect(session.Err).To(gbytes.Say("Error: --output-file " + tempSettings.Home + " is a directory, expected the path of a file"))
+		Expect(tempSettings.Home).To(BeADirectory())
+	})
+})
@@ -2,11 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
+	"github.com/CircleCI-Public/circleci-cli/api/graphql"
 	"github.com/CircleCI-Public/circleci-cli/api/header"
 	"github.com/CircleCI-Public/circleci-cli/cmd/runner"
 	"github.com/CircleCI-Public/circleci-cli/data"
@@ -30,6 +36,23 @@ var rootOptions *settings.Config
 // rootTokenFromFlag stores the value passed in through the flag --token
 var rootTokenFromFlag string
 
+// rootTokenFromStdin is set by the flag --token-stdin
+var rootTokenFromStdin bool
+
+// rootConfigFromFlag stores the path of the config file passed in through the
+// flag --cli-config
+var rootConfigFromFlag string
+
+// rootProfileFromFlag stores the name of the profile passed in through the
+// flag --profile
+var rootProfileFromFlag string
+
+// rootNoCompression is set by the flag --no-compression
+var rootNoCompression bool
+
+// rootNoCache is set by the flag --no-cache
+var rootNoCache bool
+
 // Execute adds all child commands to rootCmd and
 // sets flags appropriately. This function is called
 // by main.main(). It only needs to happen once to
@@ -37,11 +60,28 @@ var rootTokenFromFlag string
 func Execute() {
 	header.SetCommandStr(CommandStr())
 	command := MakeCommands()
-	if err := command.Execute(); err != nil {
+	err := command.Execute()
+	if rootOutput != nil {
+		if closeErr := rootOutput.close(err == nil); closeErr != nil && err == nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", closeErr)
+			os.Exit(-1)
+		}
+	}
+	if err != nil {
+		if exitErr, ok := errors.Cause(err).(*exitCodeError); ok {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(-1)
 	}
 }
 
+// An exitCodeError exits the CLI with its code rather than the usual -1, for
+// failures that scripts may want to tell apart from the rest.
+type exitCodeError struct {
+	error
+	code int
+}
+
 // Returns a string (e.g. "circleci context list") indicating what
 // subcommand is being called, without any args or flags,
 // for API headers.
@@ -58,8 +98,15 @@ func CommandStr() string {
 	return strings.Join(parentNames, " ")
 }
 
+// hasAnnotations reports whether any of the positional arguments of cmd are
+// documented, as not every annotation is an argument's.
 func hasAnnotations(cmd *cobra.Command) bool {
-	return len(cmd.Annotations) > 0
+	for _, arg := range md_docs.PositionalArgs(cmd) {
+		if md_docs.FormatPositionalArg(cmd, arg) != "" {
+			return true
+		}
+	}
+	return false
 }
 
 var usageTemplate = `
@@ -112,8 +159,12 @@ func MakeCommands() *cobra.Command {
 	rootCmd = &cobra.Command{
 		Use:  "circleci",
 		Long: rootHelpLong(rootOptions),
-		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
-			return rootCmdPreRun(rootOptions)
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			// Don't let the update check get in the way of shell completion
+			if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+				return nil
+			}
+			return rootCmdPreRun(cmd, rootOptions)
 		},
 	}
 
@@ -140,6 +191,7 @@ func MakeCommands() *cobra.Command {
 	rootCmd.AddCommand(newVersionCommand(rootOptions))
 	rootCmd.AddCommand(newDiagnosticCommand(rootOptions))
 	rootCmd.AddCommand(newSetupCommand(rootOptions))
+	rootCmd.AddCommand(newSettingsCommand(rootOptions))
 
 	rootCmd.AddCommand(followProjectCommand(rootOptions))
 
@@ -151,21 +203,34 @@ func MakeCommands() *cobra.Command {
 
 	rootCmd.AddCommand(newNamespaceCommand(rootOptions))
 	rootCmd.AddCommand(newUsageCommand(rootOptions))
+	rootCmd.AddCommand(newDocsCommand(rootOptions))
 	rootCmd.AddCommand(newStepCommand(rootOptions))
 	rootCmd.AddCommand(newSwitchCommand(rootOptions))
 	rootCmd.AddCommand(newAdminCommand(rootOptions))
 	rootCmd.AddCommand(newCompletionCommand())
+	rootCmd.AddCommand(newCacheCommand(rootOptions))
 
 	flags := rootCmd.PersistentFlags()
 
-	flags.BoolVar(&rootOptions.Debug, "debug", rootOptions.Debug, "Enable debug logging.")
-	flags.StringVar(&rootTokenFromFlag, "token", "", "your token for using CircleCI, also CIRCLECI_CLI_TOKEN")
+	flags.BoolVar(&rootOptions.Debug, "debug", rootOptions.Debug, "Log every API request and response to stderr, with tokens and secrets redacted.")
+	flags.StringVar(&rootTokenFromFlag, "token", "", "your token for using CircleCI, also CIRCLECI_CLI_TOKEN, or the file named by CIRCLECI_CLI_TOKEN_FILE")
+	flags.BoolVar(&rootTokenFromStdin, "token-stdin", false, "read your token for using CircleCI from the first line of stdin, so that it isn't in your shell history or the process list")
+	flags.StringVar(&rootConfigFromFlag, "cli-config", "", "path to the CLI config file to use instead of ~/.circleci/cli.yml, also CIRCLECI_CLI_CONFIG")
+	flags.StringVar(&rootProfileFromFlag, "profile", "", "name of the profile in the CLI config file to use, such as staging, also CIRCLECI_CLI_PROFILE")
 	flags.StringVar(&rootOptions.Host, "host", rootOptions.Host, "URL to your CircleCI host, also CIRCLECI_CLI_HOST")
 	flags.StringVar(&rootOptions.Endpoint, "endpoint", rootOptions.Endpoint, "URI to your CircleCI GraphQL API endpoint")
 	flags.StringVar(&rootOptions.GitHubAPI, "github-api", "https://api.github.com/", "Change the default endpoint to GitHub API for retrieving updates")
 	flags.BoolVar(&rootOptions.SkipUpdateCheck, "skip-update-check", skipUpdateByDefault(), "Skip the check for updates check run before every command.")
+	flags.StringVar(&rootOptions.CACert, "ca-bundle", rootOptions.CACert, "path to a PEM file of CA certificates to trust in addition to the system ones, also CIRCLECI_CLI_CA_CERT")
+	flags.DurationVar(&rootOptions.Timeout, "timeout", 60*time.Second, "How long to wait for each API operation before giving up, for example 90s or 5m. 0 means no timeout.")
+	flags.IntVar(&rootOptions.MaxRetries, "max-retries", 3, "How many times to retry API requests that fail with a network error or a server error.")
+	flags.BoolVar(&noColor, "no-color", false, "Print output without colors, also NO_COLOR. Colors are never used when the output isn't a terminal.")
+	flags.BoolVarP(&quiet, "quiet", "q", false, "Only print errors and the requested output, such as --json, not success messages.")
+	flags.BoolVar(&rootNoCompression, "no-compression", false, "Don't compress large API requests or ask for compressed responses, for proxies that mangle them.")
+	flags.StringVar(&rootOutputFromFlag, "output-file", "", "Write the result of the command, such as a processed config or --json, to this file instead of stdout, replacing it only when the command succeeds. Messages are still printed.")
+	flags.BoolVar(&rootNoCache, "no-cache", false, "Ask the API for orb versions and their metadata even when the cache_ttl setting caches them.")
 
-	hidden := []string{"github-api", "debug", "endpoint"}
+	hidden := []string{"github-api", "endpoint"}
 
 	for _, f := range hidden {
 		if err := flags.MarkHidden(f); err != nil {
@@ -194,12 +259,162 @@ func init() {
 }
 
 func prepare() {
-	if rootTokenFromFlag != "" {
+	configChanged := rootConfigFromFlag != "" && rootConfigFromFlag != rootOptions.FileUsed
+	profileChanged := rootProfileFromFlag != "" && rootProfileFromFlag != rootOptions.Profile
+	if configChanged || profileChanged {
+		path := rootOptions.FileUsed
+		if rootConfigFromFlag != "" {
+			path = rootConfigFromFlag
+		}
+		if rootProfileFromFlag != "" {
+			rootOptions.Profile = rootProfileFromFlag
+		}
+		if err := loadConfigFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(-1)
+		}
+	}
+	if err := validateProfile(rootOptions); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(-1)
+	}
+	switch {
+	case rootTokenFromStdin && rootTokenFromFlag != "":
+		fmt.Fprintln(os.Stderr, "Error: --token and --token-stdin can't be used together")
+		os.Exit(-1)
+	case rootTokenFromStdin:
+		token, err := readTokenLine(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(-1)
+		}
+		rootOptions.Token = token
+		rootOptions.TokenSource = "--token-stdin"
+	case rootTokenFromFlag != "":
 		rootOptions.Token = rootTokenFromFlag
+		rootOptions.TokenSource = "--token"
+	}
+	// The token in the keychain was read for the host in the config file
+	if rootCmd.PersistentFlags().Changed("host") {
+		rootOptions.LoadTokenFromKeychain()
+	}
+	graphql.DefaultMaxRetries = rootOptions.MaxRetries
+	graphql.DefaultTimeout = rootOptions.Timeout
+	graphql.DefaultCompression = !rootNoCompression
+	graphql.DefaultCache = nil
+	if rootOptions.CacheTTL > 0 && !rootNoCache {
+		graphql.DefaultCache = &graphql.Cache{Dir: settings.CachePath(), TTL: rootOptions.CacheTTL}
+	}
+
+	// The HTTP client was created when the settings were loaded, before the
+	// flags were parsed, so it has to pick up --ca-bundle and --debug here.
+	if rootOptions.CACert != "" || rootOptions.Debug {
+		if err := rootOptions.WithHTTPClient(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(-1)
+		}
+	}
+	if rootOptions.HTTPClient != nil {
+		rootOptions.HTTPClient.Timeout = rootOptions.Timeout
+	}
+
+	// Last, so that nothing else can fail and leave the file behind
+	if rootOutputFromFlag != "" && rootOutput == nil {
+		out, err := redirectOutput(rootOutputFromFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(-1)
+		}
+		rootOutput = out
 	}
 }
 
-func rootCmdPreRun(rootOptions *settings.Config) error {
+// loadConfigFile replaces the settings loaded from the default config file,
+// before the flags were parsed, with those in the file at path given by
+// --cli-config, or those of the profile given by --profile. Flags that were
+// given still take precedence over the file.
+func loadConfigFile(path string) error {
+	given := map[*pflag.Flag]string{}
+	rootCmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			given[flag] = flag.Value.String()
+		}
+	})
+
+	rootOptions.Host = defaultHost
+	rootOptions.Endpoint = defaultEndpoint
+	rootOptions.RestEndpoint = defaultRestEndpoint
+	rootOptions.Token = ""
+	rootOptions.Keychain = false
+	rootOptions.TLSCert = ""
+	rootOptions.TLSInsecure = false
+	rootOptions.CACert = ""
+	rootOptions.DefaultOrgID = ""
+	rootOptions.CacheTTL = 0
+	rootOptions.OrbPublishing = settings.OrbPublishingInfo{}
+	rootOptions.Profiles = nil
+	rootOptions.FileUsed = path
+	if err := rootOptions.Load(); err != nil {
+		return errors.Wrapf(err, "Could not load the config file at %s", path)
+	}
+
+	for flag, value := range given {
+		if err := flag.Value.Set(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTokenLine reads the token given with --token-stdin from the first line
+// of r. It is read a byte at a time so that the rest of stdin is left for the
+// command, such as a config piped in after the token.
+func readTokenLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "Unable to read the token from stdin")
+		}
+	}
+
+	token := strings.TrimSpace(string(line))
+	if token == "" {
+		return "", errors.New("--token-stdin was given, but stdin didn't start with a token")
+	}
+	return token, nil
+}
+
+// validateProfile checks that the profile given with --profile or
+// CIRCLECI_CLI_PROFILE is in the config file. Only `circleci setup` can be
+// run with a profile that isn't, as that is how profiles are created.
+func validateProfile(rootOptions *settings.Config) error {
+	if rootOptions.Profile == "" || rootOptions.HasProfile(rootOptions.Profile) {
+		return nil
+	}
+	if cmd, _, err := rootCmd.Find(os.Args[1:]); err == nil && cmd.Name() == "setup" {
+		return nil
+	}
+	return fmt.Errorf("there's no profile named %s in %s, create it with `circleci setup --profile %s`", rootOptions.Profile, rootOptions.FileUsed, rootOptions.Profile)
+}
+
+func rootCmdPreRun(cmd *cobra.Command, rootOptions *settings.Config) error {
+	if cmd.Flags().Changed("host") || settings.ReadFromEnv("circleci_cli", "host") != "" {
+		if err := validateHost(rootOptions.Host); err != nil {
+			return err
+		}
+	}
+
 	// If an error occurs checking for updates, we should print the error but
 	// not break the CLI entirely.
 	err := checkForUpdates(rootOptions)
@@ -231,6 +446,17 @@ You can create a new personal API token here:
 	return err
 }
 
+// validateHost checks that the host given with --host or CIRCLECI_CLI_HOST is
+// a URL that the API can be reached at. The host in the config file is left
+// alone, so that `circleci setup` can still be used to fix it.
+func validateHost(host string) error {
+	u, err := url.Parse(host)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid host %s, expected a URL such as https://circleci.example.com", host)
+	}
+	return nil
+}
+
 func setFlagErrorFunc(cmd *cobra.Command, err error) error {
 	if e := cmd.Help(); e != nil {
 		return e
@@ -6,6 +6,7 @@ import (
 	"github.com/CircleCI-Public/circleci-cli/clitest"
 	"github.com/CircleCI-Public/circleci-cli/cmd"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
@@ -15,7 +16,7 @@ var _ = Describe("Root", func() {
 	Describe("subcommands", func() {
 		It("can create commands", func() {
 			commands := cmd.MakeCommands()
-			Expect(len(commands.Commands())).To(Equal(20))
+			Expect(len(commands.Commands())).To(Equal(23))
 		})
 	})
 
@@ -97,4 +98,106 @@ var _ = Describe("Root", func() {
 		})
 	})
 
+	Describe("with a CA bundle", func() {
+		var tempSettings *clitest.TempSettings
+
+		BeforeEach(func() {
+			tempSettings = clitest.WithTempSettings()
+		})
+
+		AfterEach(func() {
+			tempSettings.Close()
+		})
+
+		It("reports a bundle without certificates before running the command", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home,
+				"diagnostic", "--skip-update-check",
+				"--ca-bundle", tempSettings.Config.Path,
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session.Err).Should(gbytes.Say("Error: unable to parse CA bundle .*: no PEM certificates found"))
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(session.Out.Contents()).To(BeEmpty())
+		})
+
+		It("reads the bundle from CIRCLECI_CLI_CA_CERT", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home, "diagnostic", "--skip-update-check")
+			command.Env = append(command.Env, "CIRCLECI_CLI_CA_CERT="+tempSettings.Config.Path)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session.Err).Should(gbytes.Say("Error: unable to parse CA bundle"))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+	})
+
+	Describe("with a host", func() {
+		var tempSettings *clitest.TempSettings
+
+		BeforeEach(func() {
+			tempSettings = clitest.WithTempSettings()
+		})
+
+		AfterEach(func() {
+			tempSettings.Close()
+		})
+
+		It("rejects a --host that isn't a URL", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home,
+				"diagnostic", "--skip-update-check",
+				"--host", "circleci.example.com",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session.Err).Should(gbytes.Say("Error: invalid host circleci.example.com, expected a URL such as https://circleci.example.com"))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+
+		// The orb and admin commands replace the root's PersistentPreRunE
+		DescribeTable("rejects a --host that isn't a URL for commands with their own pre-run hook", func(args ...string) {
+			command := commandWithHome(pathCLI, tempSettings.Home,
+				append(args, "--skip-update-check", "--host", "notaurl")...,
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session.Err).Should(gbytes.Say("Error: invalid host notaurl, expected a URL such as https://circleci.example.com"))
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
+		},
+			Entry("orb info", "orb", "info", "foo/bar"),
+			Entry("admin delete-namespace-alias", "admin", "delete-namespace-alias", "foo"),
+		)
+
+		It("uses CIRCLECI_CLI_HOST, unless --host is given", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home,
+				"diagnostic", "--skip-update-check",
+				"--host", tempSettings.TestServer.URL(),
+			)
+			command.Env = append(command.Env, "CIRCLECI_CLI_HOST=ftp://circleci.example.com")
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session.Out).Should(gbytes.Say("API host: " + tempSettings.TestServer.URL()))
+		})
+
+		It("rejects a CIRCLECI_CLI_HOST that isn't a URL", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home, "diagnostic", "--skip-update-check")
+			command.Env = append(command.Env, "CIRCLECI_CLI_HOST=ftp://circleci.example.com")
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session.Err).Should(gbytes.Say("Error: invalid host ftp://circleci.example.com"))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+	})
 })
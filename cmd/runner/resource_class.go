@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/olekukonko/tablewriter"
@@ -62,7 +63,8 @@ func newResourceClassCommand(o *runnerOpts, preRunE validator) *cobra.Command {
 		},
 	})
 
-	cmd.AddCommand(&cobra.Command{
+	asJSON := false
+	listCmd := &cobra.Command{
 		Use:     "list <namespace>",
 		Short:   "List resource-classes for a namespace",
 		Aliases: []string{"ls"},
@@ -71,9 +73,19 @@ func newResourceClassCommand(o *runnerOpts, preRunE validator) *cobra.Command {
 		RunE: func(_ *cobra.Command, args []string) error {
 			rcs, err := o.r.GetResourceClassesByNamespace(args[0])
 			if err != nil {
+				if isPermissionError(err) {
+					return fmt.Errorf("you don't have permission to list the resource-classes in namespace %s: %s", args[0], err)
+				}
 				return err
 			}
 
+			if asJSON {
+				if rcs == nil {
+					rcs = []runner.ResourceClass{}
+				}
+				return printJSON(cmd.OutOrStdout(), rcs)
+			}
+
 			table := newResourceClassTable(cmd.OutOrStdout())
 			defer table.Render()
 			for _, rc := range rcs {
@@ -82,7 +94,10 @@ func newResourceClassCommand(o *runnerOpts, preRunE validator) *cobra.Command {
 
 			return nil
 		},
-	})
+	}
+	listCmd.Flags().BoolVar(&asJSON, "json", false,
+		"Print the resource-classes as JSON")
+	cmd.AddCommand(listCmd)
 
 	return cmd
 }
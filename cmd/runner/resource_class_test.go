@@ -76,6 +76,44 @@ func Test_ResourceClass(t *testing.T) {
 			assert.Check(t, cmp.Contains(stderr.String(), terms))
 		})
 	})
+
+	t.Run("list as JSON", func(t *testing.T) {
+		t.Run("with resource-classes", func(t *testing.T) {
+			defer runner.reset()
+			defer stdout.Reset()
+			defer stderr.Reset()
+
+			_, err := runner.CreateResourceClass("my-namespace/my-resource-class", "my-description")
+			assert.NilError(t, err)
+			_, err = runner.CreateResourceClass("other-namespace/other-resource-class", "other-description")
+			assert.NilError(t, err)
+
+			cmd.SetArgs([]string{"list", "my-namespace", "--json"})
+
+			err = cmd.Execute()
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(stdout.String(), `[
+  {
+    "id": "d8bc155b-5e91-4765-b327-0fa256f0229e",
+    "resource_class": "my-namespace/my-resource-class",
+    "description": "my-description"
+  }
+]
+`))
+		})
+
+		t.Run("without resource-classes", func(t *testing.T) {
+			defer runner.reset()
+			defer stdout.Reset()
+			defer stderr.Reset()
+
+			cmd.SetArgs([]string{"list", "my-namespace", "--json"})
+
+			err := cmd.Execute()
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(stdout.String(), "[]\n"))
+		})
+	})
 }
 
 type runnerMock struct {
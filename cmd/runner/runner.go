@@ -1,6 +1,12 @@
 package runner
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
 	"github.com/spf13/cobra"
 
 	"github.com/CircleCI-Public/circleci-cli/api/rest"
@@ -18,7 +24,7 @@ func NewCommand(config *settings.Config, preRunE validator) *cobra.Command {
 		Use:   "runner",
 		Short: "Operate on runners",
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
-			opts.r = runner.New(rest.New(config.Host, config.RestEndpoint, config.Token))
+			opts.r = runner.New(rest.NewFromConfig(config))
 		},
 	}
 	cmd.AddCommand(newResourceClassCommand(&opts, preRunE))
@@ -40,3 +46,23 @@ type running interface {
 }
 
 type validator func(cmd *cobra.Command, args []string) error
+
+// printJSON writes v to w as indented JSON.
+func printJSON(w io.Writer, v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+// isPermissionError reports whether the runner API refused the request
+// because of the token, so that it isn't mistaken for an empty result.
+func isPermissionError(err error) bool {
+	var httpErr *rest.HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	return httpErr.Code == http.StatusUnauthorized || httpErr.Code == http.StatusForbidden
+}
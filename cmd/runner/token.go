@@ -13,7 +13,8 @@ func newTokenCommand(o *runnerOpts, preRunE validator) *cobra.Command {
 		Short: "Operate on runner tokens",
 	}
 
-	cmd.AddCommand(&cobra.Command{
+	asJSON := false
+	createCmd := &cobra.Command{
 		Use:     "create <resource-class> <nickname>",
 		Short:   "Create a token for a resource-class",
 		Args:    cobra.ExactArgs(2),
@@ -23,9 +24,16 @@ func newTokenCommand(o *runnerOpts, preRunE validator) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if asJSON {
+				cmd.PrintErrln("Warning: the token is only shown once, store it somewhere safe.")
+				return printJSON(cmd.OutOrStdout(), token)
+			}
 			return generateConfig(*token, cmd.OutOrStdout())
 		},
-	})
+	}
+	createCmd.Flags().BoolVar(&asJSON, "json", false,
+		"Print the token and its metadata as JSON")
+	cmd.AddCommand(createCmd)
 
 	cmd.AddCommand(&cobra.Command{
 		Use:     "delete <token-id>",
@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+
+	"github.com/CircleCI-Public/circleci-cli/api/runner"
+)
+
+func Test_Token(t *testing.T) {
+	runnerMock := runnerMock{}
+	cmd := newTokenCommand(&runnerOpts{r: &runnerMock}, nil)
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+
+	t.Run("create as JSON", func(t *testing.T) {
+		defer runnerMock.reset()
+		defer stdout.Reset()
+		defer stderr.Reset()
+
+		cmd.SetArgs([]string{
+			"create",
+			"my-namespace/my-resource-class",
+			"my-nickname",
+			"--json",
+		})
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+
+		var token runner.Token
+		assert.NilError(t, json.Unmarshal(stdout.Bytes(), &token))
+		assert.Check(t, cmp.DeepEqual(token, runnerMock.tokens[0]))
+		assert.Check(t, cmp.Equal(token.Token, "fake-token"))
+		assert.Check(t, cmp.Contains(stderr.String(), "only shown once"))
+	})
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-cli/settings"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type settingsOptions struct {
+	cfg  *settings.Config
+	args []string
+}
+
+// A settingsField is a value in the CLI config file that can be read and
+// written with `settings get` and `settings set`.
+type settingsField struct {
+	get func(cfg *settings.Config) string
+	set func(cfg *settings.Config, value string) error
+}
+
+func stringField(field func(cfg *settings.Config) *string) settingsField {
+	return settingsField{
+		get: func(cfg *settings.Config) string { return *field(cfg) },
+		set: func(cfg *settings.Config, value string) error {
+			*field(cfg) = value
+			return nil
+		},
+	}
+}
+
+// settingsFields are keyed by their name in the config file.
+var settingsFields = map[string]settingsField{
+	"host": {
+		get: func(cfg *settings.Config) string { return cfg.Host },
+		set: func(cfg *settings.Config, value string) error {
+			if err := validateHost(value); err != nil {
+				return err
+			}
+			cfg.Host = value
+			return nil
+		},
+	},
+	"token":          stringField(func(cfg *settings.Config) *string { return &cfg.Token }),
+	"endpoint":       stringField(func(cfg *settings.Config) *string { return &cfg.Endpoint }),
+	"rest_endpoint":  stringField(func(cfg *settings.Config) *string { return &cfg.RestEndpoint }),
+	"tls_cert":       stringField(func(cfg *settings.Config) *string { return &cfg.TLSCert }),
+	"ca_cert":        stringField(func(cfg *settings.Config) *string { return &cfg.CACert }),
+	"default_org_id": stringField(func(cfg *settings.Config) *string { return &cfg.DefaultOrgID }),
+	"tls_insecure": {
+		get: func(cfg *settings.Config) string { return strconv.FormatBool(cfg.TLSInsecure) },
+		set: func(cfg *settings.Config, value string) error {
+			insecure, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("expected \"true\" or \"false\", got \"%s\"", value)
+			}
+			cfg.TLSInsecure = insecure
+			return nil
+		},
+	},
+	"cache_ttl": {
+		get: func(cfg *settings.Config) string {
+			if cfg.CacheTTL == 0 {
+				return ""
+			}
+			return cfg.CacheTTL.String()
+		},
+		set: func(cfg *settings.Config, value string) error {
+			ttl, err := time.ParseDuration(value)
+			if err != nil || ttl < 0 {
+				return fmt.Errorf("expected a duration such as 1h or 30m, or 0 to turn the cache off, got \"%s\"", value)
+			}
+			cfg.CacheTTL = ttl
+			return nil
+		},
+	},
+	"orb_publishing.default_namespace":    stringField(func(cfg *settings.Config) *string { return &cfg.OrbPublishing.DefaultNamespace }),
+	"orb_publishing.default_vcs_provider": stringField(func(cfg *settings.Config) *string { return &cfg.OrbPublishing.DefaultVcsProvider }),
+	"orb_publishing.default_owner":        stringField(func(cfg *settings.Config) *string { return &cfg.OrbPublishing.DefaultOwner }),
+}
+
+func newSettingsCommand(config *settings.Config) *cobra.Command {
+	opts := settingsOptions{
+		cfg: config,
+	}
+
+	settingsCmd := &cobra.Command{
+		Use:   "settings",
+		Short: "Read and change the settings in the CLI config file",
+		Long: fmt.Sprintf(`Read and change the settings in the CLI config file, which is ~/.circleci/cli.yml
+unless --cli-config or CIRCLECI_CLI_CONFIG is given.
+
+Keys are the names used in the config file, with - and _ treated the same:
+%s`, strings.Join(settingsKeys(), "\n")),
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print a setting from the CLI config file",
+		Long: `Print a setting from the CLI config file.
+The command fails when the setting isn't set, so that scripts can detect it.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			opts.args = args
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return getSetting(opts)
+		},
+		Args:        cobra.ExactArgs(1),
+		Annotations: make(map[string]string),
+	}
+	getCmd.Annotations["<key>"] = "The name of the setting, such as host or default_org_id"
+
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Save a setting to the CLI config file",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			opts.args = args
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return setSetting(opts)
+		},
+		Args:        cobra.ExactArgs(2),
+		Annotations: make(map[string]string),
+	}
+	setCmd.Annotations["<key>"] = getCmd.Annotations["<key>"]
+	setCmd.Annotations["<value>"] = "The value to save"
+
+	settingsCmd.AddCommand(getCmd)
+	settingsCmd.AddCommand(setCmd)
+
+	return settingsCmd
+}
+
+// settingsKeys returns the names of the settings, sorted.
+func settingsKeys() []string {
+	keys := make([]string, 0, len(settingsFields))
+	for key := range settingsFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func lookupSettingsField(key string) (settingsField, string, error) {
+	name := strings.Replace(key, "-", "_", -1)
+	field, ok := settingsFields[name]
+	if !ok {
+		return settingsField{}, "", fmt.Errorf("unknown setting %s, expected one of: %s", key, strings.Join(settingsKeys(), ", "))
+	}
+	return field, name, nil
+}
+
+// loadSettingsFile reads the config file the CLI is using, without the
+// overrides from flags and environment variables applied to opts.cfg.
+func loadSettingsFile(opts settingsOptions) (*settings.Config, error) {
+	cfg := &settings.Config{FileUsed: opts.cfg.FileUsed}
+	if err := cfg.LoadFromDisk(); err != nil {
+		return nil, errors.Wrapf(err, "Could not load the config file at %s", cfg.FileUsed)
+	}
+	if cfg.Keychain && cfg.Token == "" {
+		if token, err := settings.TokenFromKeychain(cfg.Host); err == nil {
+			cfg.Token = token
+		}
+	}
+	return cfg, nil
+}
+
+func getSetting(opts settingsOptions) error {
+	field, name, err := lookupSettingsField(opts.args[0])
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadSettingsFile(opts)
+	if err != nil {
+		return err
+	}
+
+	value := field.get(cfg)
+	if value == "" {
+		return fmt.Errorf("%s is not set in %s", name, cfg.FileUsed)
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func setSetting(opts settingsOptions) error {
+	field, name, err := lookupSettingsField(opts.args[0])
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadSettingsFile(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := field.set(cfg, opts.args[1]); err != nil {
+		return err
+	}
+
+	// A token kept in the keychain stays there
+	if name == "token" && cfg.Keychain {
+		if err := settings.SaveTokenToKeychain(cfg.Host, cfg.Token); err != nil {
+			return errors.Wrap(err, "Failed to save the token to the keychain")
+		}
+	}
+
+	if err := cfg.WriteToDisk(); err != nil {
+		return errors.Wrap(err, "Failed to save config file")
+	}
+
+	infof("Saved %s to %s.\n", name, cfg.FileUsed)
+	return nil
+}
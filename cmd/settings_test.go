@@ -0,0 +1,130 @@
+package cmd_test
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/CircleCI-Public/circleci-cli/clitest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Settings", func() {
+	var tempSettings *clitest.TempSettings
+
+	BeforeEach(func() {
+		tempSettings = clitest.WithTempSettings()
+		tempSettings.Config.Write([]byte("host: https://circleci.example.com\ntoken: mytoken\n"))
+	})
+
+	AfterEach(func() {
+		tempSettings.Close()
+	})
+
+	Describe("get", func() {
+		It("prints the setting from the config file", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home,
+				"settings", "get", "host",
+				"--skip-update-check",
+			)
+			command.Env = append(command.Env, "CIRCLECI_CLI_HOST=https://ignored.example.com")
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal("https://circleci.example.com\n"))
+		})
+
+		It("fails when the setting isn't set", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home,
+				"settings", "get", "default-org-id",
+				"--skip-update-check",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say(fmt.Sprintf("Error: default_org_id is not set in %s", regexp.QuoteMeta(tempSettings.Config.Path))))
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(session.Out.Contents()).To(BeEmpty())
+		})
+
+		It("rejects an unknown key", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home,
+				"settings", "get", "colour",
+				"--skip-update-check",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say("Error: unknown setting colour, expected one of: ca_cert, cache_ttl, default_org_id, endpoint, host, "))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+	})
+
+	Describe("set", func() {
+		It("saves the setting and keeps the others", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home,
+				"settings", "set", "default_org_id", "c5d5c3f0-1e08-4f8e-a3b5-7a3b2f0a9e11",
+				"--skip-update-check",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Out).Should(gbytes.Say(fmt.Sprintf("Saved default_org_id to %s.", regexp.QuoteMeta(tempSettings.Config.Path))))
+			Eventually(session).Should(gexec.Exit(0))
+
+			tempSettings.AssertConfigRereadMatches("default_org_id: c5d5c3f0-1e08-4f8e-a3b5-7a3b2f0a9e11")
+			tempSettings.AssertConfigRereadMatches("host: https://circleci.example.com")
+			tempSettings.AssertConfigRereadMatches("token: mytoken")
+		})
+
+		It("validates the value", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home,
+				"settings", "set", "tls-insecure", "maybe",
+				"--skip-update-check",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say(`Error: expected "true" or "false", got "maybe"`))
+			Eventually(session).Should(clitest.ShouldFail())
+			tempSettings.AssertConfigRereadMatches("host: https://circleci.example.com\ntoken: mytoken\n")
+		})
+
+		It("saves the cache TTL as a duration", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home,
+				"settings", "set", "cache-ttl", "90m",
+				"--skip-update-check",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			tempSettings.AssertConfigRereadMatches("cache_ttl: 1h30m0s")
+
+			command = commandWithHome(pathCLI, tempSettings.Home,
+				"settings", "set", "cache-ttl", "soon",
+				"--skip-update-check",
+			)
+
+			session, err = gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say(`Error: expected a duration such as 1h or 30m, or 0 to turn the cache off, got "soon"`))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+
+		It("rejects an unknown key", func() {
+			command := commandWithHome(pathCLI, tempSettings.Home,
+				"settings", "set", "colour", "blue",
+				"--skip-update-check",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session.Err).Should(gbytes.Say("Error: unknown setting colour"))
+			Eventually(session).Should(clitest.ShouldFail())
+		})
+	})
+})
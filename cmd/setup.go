@@ -19,6 +19,11 @@ type setupOptions struct {
 	host  string
 	token string
 	args  []string
+	// Save the token to the OS keychain rather than the config file
+	keychain   bool
+	resetToken bool
+	// Save the token and host without checking that they can be used
+	noValidate bool
 	// This lets us pass in our own interface for testing
 	tty setupUserInterface
 	// Linked with --integration-testing flag for stubbing UI in gexec tests
@@ -125,12 +130,24 @@ func newSetupCommand(config *settings.Config) *cobra.Command {
 		},
 		RunE: func(_ *cobra.Command, _ []string) error {
 			if opts.integrationTesting {
-				opts.tty = setupTestUI{
+				ui := setupTestUI{
 					host:            "boondoggle",
 					token:           "boondoggle",
 					confirmEndpoint: true,
 					confirmToken:    true,
 				}
+				// The answers can be given, to set up against a test server
+				if opts.host != "" {
+					ui.host = opts.host
+				}
+				if opts.token != "" {
+					ui.token = opts.token
+				}
+				opts.tty = ui
+			}
+
+			if opts.resetToken {
+				return resetToken(opts)
 			}
 
 			if opts.noPrompt {
@@ -148,6 +165,10 @@ func newSetupCommand(config *settings.Config) *cobra.Command {
 
 	setupCommand.Flags().BoolVar(&opts.noPrompt, "no-prompt", false, "Disable prompt to bypass interactive UI. (MUST supply --host and --token)")
 
+	setupCommand.Flags().BoolVar(&opts.keychain, "keychain", false, "Save the token to the OS keychain instead of the config file, falling back to the config file when no keychain is available.")
+	setupCommand.Flags().BoolVar(&opts.resetToken, "reset-token", false, "Remove the saved token from the OS keychain and the config file.")
+	setupCommand.Flags().BoolVar(&opts.noValidate, "no-validate", false, "Save the token and host without checking them against the API, such as when setting up offline.")
+
 	setupCommand.Flags().StringVar(&opts.host, "host", "", "URL to your CircleCI host")
 	if err := setupCommand.Flags().MarkHidden("host"); err != nil {
 		panic(err)
@@ -163,12 +184,9 @@ func newSetupCommand(config *settings.Config) *cobra.Command {
 
 func setup(opts setupOptions) error {
 	if shouldAskForToken(opts.cfg.Token, opts.tty) {
-		token, err := opts.tty.readTokenFromUser("CircleCI API Token")
-		if err != nil {
-			return errors.Wrap(err, "Error reading token")
+		if err := readSetupToken(opts); err != nil {
+			return err
 		}
-		opts.cfg.Token = token
-		fmt.Println("API token has been set.")
 	}
 	opts.cfg.Host = opts.tty.readHostFromUser("CircleCI Host", defaultHost)
 	fmt.Println("CircleCI host has been set.")
@@ -179,43 +197,65 @@ func setup(opts setupOptions) error {
 		opts.cfg.Endpoint = defaultEndpoint
 	}
 
-	if err := opts.cfg.WriteToDisk(); err != nil {
-		return errors.Wrap(err, "Failed to save config file")
+	if !opts.noValidate {
+		if err := validateSetup(opts); err != nil {
+			return err
+		}
 	}
 
-	fmt.Printf("Setup complete.\nYour configuration has been saved to %s.\n", opts.cfg.FileUsed)
+	saveTokenToKeychain(opts.cfg, opts.keychain || opts.cfg.Keychain)
 
-	if !opts.integrationTesting {
-		setupDiagnosticCheck(opts)
+	if err := opts.cfg.WriteToDisk(); err != nil {
+		return errors.Wrap(err, "Failed to save config file")
 	}
 
+	fmt.Printf("Setup complete.\nYour configuration has been saved to %s.\n", configSavedTo(opts.cfg))
+
 	return nil
 }
 
-func setupDiagnosticCheck(opts setupOptions) {
-	// Reset client after setup config
-	opts.cl.Reset(opts.cfg.Host, opts.cfg.Endpoint, opts.cfg.Token, opts.cfg.Debug)
-
-	fmt.Printf("\n")
-	fmt.Printf("Trying an introspection query on API to verify your setup... ")
-
-	responseIntro, err := api.IntrospectionQuery(opts.cl)
-	if err != nil || responseIntro.Schema.QueryType.Name == "" {
-		fmt.Println("\nUnable to make a query against the GraphQL API, please check your settings.")
-	} else {
-		fmt.Println("Ok.")
+func readSetupToken(opts setupOptions) error {
+	token, err := opts.tty.readTokenFromUser("CircleCI API Token")
+	if err != nil {
+		return errors.Wrap(err, "Error reading token")
 	}
+	opts.cfg.Token = token
+	fmt.Println("API token has been set.")
+	return nil
+}
 
-	fmt.Printf("Trying to query our API for your profile name... ")
-	responseWho, err := api.WhoamiQuery(opts.cl)
+// setupValidateAttempts is how many times setup asks for the token and host
+// before giving up when they can't be used with the API.
+const setupValidateAttempts = 3
+
+// validateSetup checks the token and host of opts.cfg by looking up the user
+// they belong to, asking for them again when they can't be used.
+func validateSetup(opts setupOptions) error {
+	for attempt := 1; ; attempt++ {
+		opts.cl.Reset(opts.cfg.Host, opts.cfg.Endpoint, opts.cfg.Token, opts.cfg.Debug)
+
+		fmt.Printf("Checking your token with %s... ", opts.cfg.Host)
+		responseWho, err := api.WhoamiQuery(opts.cl)
+		if err == nil {
+			// If user does not have a name set in their VCS, let's just say ok
+			if responseWho.Me.Name == "" {
+				fmt.Println("Ok.")
+			} else {
+				fmt.Printf("Ok, the token belongs to %s.\n", responseWho.Me.Name)
+			}
+			return nil
+		}
 
-	if err != nil {
-		fmt.Println("\nUnable to query our API for your profile name, please check your settings.")
-		// If user does not have a name set in their VCS, let's just say hi :)
-	} else if responseWho.Me.Name == "" {
-		fmt.Printf("Hello!")
-	} else {
-		fmt.Printf("Hello, %s.\n", responseWho.Me.Name)
+		fmt.Printf("\nUnable to use the token with %s: %s\n", opts.cfg.Host, err)
+		if attempt == setupValidateAttempts {
+			return fmt.Errorf("the token couldn't be used after %d attempts, use --no-validate to save it without checking it", attempt)
+		}
+
+		if err := readSetupToken(opts); err != nil {
+			return err
+		}
+		opts.cfg.Host = opts.tty.readHostFromUser("CircleCI Host", opts.cfg.Host)
+		fmt.Println("CircleCI host has been set.")
 	}
 }
 
@@ -241,7 +281,14 @@ func setupNoPrompt(opts setupOptions) error {
 		return errors.New("No existing host or token saved.\nThe proper format is `circleci setup --host HOST --token TOKEN --no-prompt")
 	}
 
-	config := settings.Config{}
+	// The defaults are kept at the top of the file when saving to a profile
+	config := settings.Config{
+		FileUsed:     opts.cfg.FileUsed,
+		Profile:      opts.cfg.Profile,
+		Host:         defaultHost,
+		Endpoint:     defaultEndpoint,
+		RestEndpoint: defaultRestEndpoint,
+	}
 
 	// First calling load will ensure the new config can be saved to disk
 	if err := config.LoadFromDisk(); err != nil {
@@ -266,11 +313,55 @@ func setupNoPrompt(opts setupOptions) error {
 		config.Token = opts.cfg.Token
 	}
 
+	saveTokenToKeychain(&config, opts.keychain || opts.cfg.Keychain)
+
 	// Then save the new config to disk
 	if err := config.WriteToDisk(); err != nil {
 		return errors.Wrap(err, "Failed to save config file")
 	}
 
-	fmt.Printf("Setup complete.\nYour configuration has been saved to %s.\n", config.FileUsed)
+	fmt.Printf("Setup complete.\nYour configuration has been saved to %s.\n", configSavedTo(&config))
+	return nil
+}
+
+// configSavedTo describes where the configuration of cfg is saved, which is
+// the active profile when there is one.
+func configSavedTo(cfg *settings.Config) string {
+	if cfg.Profile != "" {
+		return fmt.Sprintf("the %s profile in %s", cfg.Profile, cfg.FileUsed)
+	}
+	return cfg.FileUsed
+}
+
+// saveTokenToKeychain moves the token of config into the OS keychain when
+// useKeychain is set, so that it isn't written to the config file. When
+// there's no keychain to save it to, the token stays in the config file.
+func saveTokenToKeychain(config *settings.Config, useKeychain bool) {
+	config.Keychain = false
+	if !useKeychain || config.Token == "" {
+		return
+	}
+
+	if err := settings.SaveTokenToKeychain(config.Host, config.Token); err != nil {
+		fmt.Printf("Unable to save the token to the keychain (%s), it will be saved to the config file instead.\n", err)
+		return
+	}
+
+	config.Keychain = true
+	fmt.Println("API token has been saved to the keychain.")
+}
+
+func resetToken(opts setupOptions) error {
+	if err := settings.DeleteTokenFromKeychain(opts.cfg.Host); err != nil && opts.cfg.Keychain {
+		return errors.Wrap(err, "Failed to remove the token from the keychain")
+	}
+
+	opts.cfg.Token = ""
+	opts.cfg.Keychain = false
+	if err := opts.cfg.WriteToDisk(); err != nil {
+		return errors.Wrap(err, "Failed to save config file")
+	}
+
+	fmt.Printf("API token has been removed.\nRun `circleci setup` to set a new one.\n")
 	return nil
 }
@@ -3,11 +3,14 @@ package cmd_test
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
 
+	"github.com/CircleCI-Public/circleci-cli/api/graphql"
 	"github.com/CircleCI-Public/circleci-cli/clitest"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -27,6 +30,7 @@ var _ = Describe("Setup with prompts", func() {
 		command = commandWithHome(pathCLI, tempSettings.Home,
 			"setup",
 			"--integration-testing",
+			"--no-validate",
 			"--skip-update-check",
 		)
 	})
@@ -109,6 +113,77 @@ token: fooBarBaz
 	})
 })
 
+var _ = Describe("Setup with prompts validating the token", func() {
+	var (
+		command      *exec.Cmd
+		tempSettings *clitest.TempSettings
+		whoami       string
+	)
+
+	BeforeEach(func() {
+		tempSettings = clitest.WithTempSettings()
+
+		command = commandWithHome(pathCLI, tempSettings.Home,
+			"setup",
+			"--integration-testing",
+			"--skip-update-check",
+			"--host", tempSettings.TestServer.URL(),
+			"--token", "mytoken",
+		)
+
+		request := graphql.NewRequest(`query { me { name } }`)
+		request.SetToken("mytoken")
+		expected, err := request.Encode()
+		Expect(err).ShouldNot(HaveOccurred())
+		whoami = expected.String()
+	})
+
+	AfterEach(func() {
+		tempSettings.Close()
+	})
+
+	It("reports who the token belongs to before saving it", func() {
+		tempSettings.AppendPostHandler("mytoken", clitest.MockRequestResponse{
+			Status:   http.StatusOK,
+			Request:  whoami,
+			Response: `{"me": {"name": "zzak"}}`,
+		})
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Eventually(session).Should(gexec.Exit(0))
+		Expect(session.Out).To(gbytes.Say(fmt.Sprintf(`Checking your token with %s\.\.\. Ok, the token belongs to zzak\.`, regexp.QuoteMeta(tempSettings.TestServer.URL()))))
+		Expect(session.Out).To(gbytes.Say("Setup complete."))
+
+		contents, err := ioutil.ReadFile(tempSettings.Config.Path)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring("token: mytoken"))
+	})
+
+	It("asks again when the token can't be used, giving up after three attempts", func() {
+		for i := 0; i < 3; i++ {
+			tempSettings.AppendPostHandler("mytoken", clitest.MockRequestResponse{
+				Status:        http.StatusOK,
+				Request:       whoami,
+				Response:      `{"me": null}`,
+				ErrorResponse: `[{"message": "You must log in first"}]`,
+			})
+		}
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Eventually(session).Should(clitest.ShouldFail())
+		for i := 0; i < 3; i++ {
+			Expect(session.Out).To(gbytes.Say("CircleCI API Token"))
+			Expect(session.Out).To(gbytes.Say("Unable to use the token with .*: You must log in first"))
+		}
+		Expect(session.Err).To(gbytes.Say("Error: the token couldn't be used after 3 attempts, use --no-validate to save it without checking it"))
+		Expect(string(session.Out.Contents())).ToNot(ContainSubstring("Setup complete."))
+	})
+})
+
 var _ = Describe("Setup without prompts", func() {
 	var (
 		tempSettings *clitest.TempSettings
@@ -265,4 +340,113 @@ orb_publishing:
 			})
 		})
 	})
+
+	Context("with --config", func() {
+		var configPath string
+
+		BeforeEach(func() {
+			configPath = filepath.Join(tempSettings.Home, "orgs", "other.yml")
+			tempSettings.Config.Write([]byte(`
+host: https://example.com
+token: defaultToken
+`))
+		})
+
+		It("creates the config file it points at", func() {
+			command = commandWithHome(pathCLI, tempSettings.Home,
+				"setup",
+				"--cli-config", configPath,
+				"--host", "https://zomg.com",
+				"--token", "othertoken",
+				"--no-prompt",
+				"--skip-update-check",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(fmt.Sprintf(`Setup complete.
+Your configuration has been saved to %s.
+`, configPath)))
+
+			saved, err := ioutil.ReadFile(configPath)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(saved)).To(ContainSubstring("host: https://zomg.com\n"))
+			Expect(string(saved)).To(ContainSubstring("token: othertoken\n"))
+
+			By("leaving the default config file alone")
+			tempSettings.AssertConfigRereadMatches(`
+host: https://example.com
+token: defaultToken
+`)
+		})
+
+		It("creates the config file given by CIRCLECI_CLI_CONFIG", func() {
+			command = commandWithHome(pathCLI, tempSettings.Home,
+				"setup",
+				"--host", "https://zomg.com",
+				"--token", "othertoken",
+				"--no-prompt",
+				"--skip-update-check",
+			)
+			command.Env = append(command.Env, "CIRCLECI_CLI_CONFIG="+configPath)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(configPath).To(BeAnExistingFile())
+		})
+	})
+
+	Context("with --profile", func() {
+		BeforeEach(func() {
+			tempSettings.Config.Write([]byte(`
+host: https://example.com
+token: defaultToken
+`))
+		})
+
+		It("saves the settings to the profile", func() {
+			command = commandWithHome(pathCLI, tempSettings.Home,
+				"setup",
+				"--profile", "staging",
+				"--host", "https://staging.example.com",
+				"--token", "stagingtoken",
+				"--no-prompt",
+				"--skip-update-check",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(fmt.Sprintf(`Setup complete.
+Your configuration has been saved to the staging profile in %s.
+`, tempSettings.Config.Path)))
+
+			saved, err := ioutil.ReadFile(tempSettings.Config.Path)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(saved)).To(ContainSubstring("host: https://example.com\n"))
+			Expect(string(saved)).To(ContainSubstring("token: defaultToken\n"))
+			Expect(string(saved)).To(ContainSubstring(`profiles:
+    staging:
+        host: https://staging.example.com
+        endpoint: graphql-unstable
+        rest_endpoint: api/v2
+        token: stagingtoken
+`))
+		})
+
+		It("fails for any other command when the profile doesn't exist", func() {
+			command = commandWithHome(pathCLI, tempSettings.Home,
+				"diagnostic",
+				"--profile", "staging",
+				"--skip-update-check",
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(session.Err).To(gbytes.Say(fmt.Sprintf("Error: there's no profile named staging in %s, create it with `circleci setup --profile staging`", tempSettings.Config.Path)))
+		})
+	})
 })
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"path/filepath"
 	"runtime"
 
 	"github.com/CircleCI-Public/circleci-cli/api/graphql"
@@ -12,7 +11,7 @@ import (
 	"github.com/CircleCI-Public/circleci-cli/settings"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	"gotest.tools/v3/golden"
+	"github.com/zalando/go-keyring"
 )
 
 var _ = Describe("Setup with prompts", func() {
@@ -45,43 +44,11 @@ var _ = Describe("Setup with prompts", func() {
 
 	Context("with happy diagnostic responses", func() {
 		BeforeEach(func() {
-			query := `query IntrospectionQuery {
-		    __schema {
-		      queryType { name }
-		      mutationType { name }
-		      types {
-		        ...FullType
-		      }
-		    }
-		  }
-
-		  fragment FullType on __Type {
-		    kind
-		    name
-		    description
-		    fields(includeDeprecated: true) {
-		      name
-		    }
-		  }`
-
-			request := graphql.NewRequest(query)
-			expected, err := request.Encode()
-			Expect(err).ShouldNot(HaveOccurred())
-
-			tmpBytes := golden.Get(GinkgoT(), filepath.FromSlash("diagnostic/response.json"))
-			mockResponse := string(tmpBytes)
-
-			tempSettings.AppendPostHandler("", clitest.MockRequestResponse{
-				Status:   http.StatusOK,
-				Request:  expected.String(),
-				Response: mockResponse})
-
 			// Here we want to actually validate the token in our test too
-			query = `query { me { name } }`
-			request = graphql.NewRequest(query)
+			query := `query { me { name } }`
+			request := graphql.NewRequest(query)
 			request.SetToken(token)
-			Expect(err).ShouldNot(HaveOccurred())
-			expected, err = request.Encode()
+			expected, err := request.Encode()
 			Expect(err).ShouldNot(HaveOccurred())
 
 			response := `{ "me": { "name": "zomg" } }`
@@ -131,12 +98,10 @@ API token has been set.
 CircleCI Host
 CircleCI host has been set.
 Do you want to reset the endpoint? (default: graphql-unstable)
+Checking your token with %s... Ok, the token belongs to %s.
 Setup complete.
 Your configuration has been saved to %s.
-
-Trying an introspection query on API to verify your setup... Ok.
-Trying to query our API for your profile name... Hello, %s.
-`, tempSettings.Config.Path, `zomg`)))
+`, tempSettings.TestServer.URL(), `zomg`, tempSettings.Config.Path)))
 
 				tempSettings.AssertConfigRereadMatches(fmt.Sprintf(`host: %s
 endpoint: graphql-unstable
@@ -161,12 +126,10 @@ token: %s
 CircleCI Host
 CircleCI host has been set.
 Do you want to reset the endpoint? (default: graphql-unstable)
+Checking your token with %s... Ok, the token belongs to %s.
 Setup complete.
 Your configuration has been saved to %s.
-
-Trying an introspection query on API to verify your setup... Ok.
-Trying to query our API for your profile name... Hello, %s.
-`, tempSettings.Config.Path, `zomg`)))
+`, tempSettings.TestServer.URL(), `zomg`, tempSettings.Config.Path)))
 
 				tempSettings.AssertConfigRereadMatches(fmt.Sprintf(`host: %s
 endpoint: graphql-unstable
@@ -174,137 +137,68 @@ token: %s
 `, tempSettings.TestServer.URL(), token))
 			})
 		})
-	})
-
-	Context("when introspection query returns an error", func() {
-		BeforeEach(func() {
-			query := `query IntrospectionQuery {
-		    __schema {
-		      queryType { name }
-		      mutationType { name }
-		      types {
-		        ...FullType
-		      }
-		    }
-		  }
-
-		  fragment FullType on __Type {
-		    kind
-		    name
-		    description
-		    fields(includeDeprecated: true) {
-		      name
-		    }
-		  }`
-
-			request := graphql.NewRequest(query)
-			expected, err := request.Encode()
-			Expect(err).ShouldNot(HaveOccurred())
-
-			tempSettings.AppendPostHandler("", clitest.MockRequestResponse{
-				Status:   http.StatusInternalServerError,
-				Request:  expected.String(),
-				Response: "{}"})
 
-			// Here we want to actually validate the token in our test too
-			query = `query { me { name } }`
-			request = graphql.NewRequest(query)
-			request.SetToken(token)
-			Expect(err).ShouldNot(HaveOccurred())
-			expected, err = request.Encode()
-			Expect(err).ShouldNot(HaveOccurred())
+		Describe("saving the token to the keychain", func() {
+			BeforeEach(func() {
+				keyring.MockInit()
+				opts.keychain = true
+			})
 
-			response := `{ "me": { "name": "zomg" } }`
+			It("should leave the token out of the config file", func() {
+				output := clitest.WithCapturedOutput(func() {
+					err := setup(opts)
+					Expect(err).ShouldNot(HaveOccurred())
+				})
 
-			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
-				Status:   http.StatusOK,
-				Request:  expected.String(),
-				Response: response})
-		})
+				Expect(output).To(ContainSubstring("API token has been saved to the keychain.\nSetup complete."))
+				Expect(output).To(ContainSubstring("Ok, the token belongs to zomg."))
 
-		It("should show an error", func() {
-			opts.tty = setupTestUI{
-				host:            tempSettings.TestServer.URL(),
-				token:           token,
-				confirmEndpoint: true,
-				confirmToken:    true,
-			}
+				tempSettings.AssertConfigRereadMatches(fmt.Sprintf(`host: %s
+endpoint: graphql-unstable
+token: ""
+keychain: true
+`, tempSettings.TestServer.URL()))
 
-			output := clitest.WithCapturedOutput(func() {
-				err := setup(opts)
+				saved, err := settings.TokenFromKeychain(tempSettings.TestServer.URL())
 				Expect(err).ShouldNot(HaveOccurred())
+				Expect(saved).To(Equal(token))
 			})
 
-			Expect(output).To(ContainSubstring(fmt.Sprintf(`CircleCI API Token
-API token has been set.
-CircleCI Host
-CircleCI host has been set.
-Do you want to reset the endpoint? (default: graphql-unstable)
-Setup complete.
-Your configuration has been saved to %s.
+			It("should remove the token with --reset-token", func() {
+				clitest.WithCapturedOutput(func() {
+					Expect(setup(opts)).To(Succeed())
+				})
+
+				output := clitest.WithCapturedOutput(func() {
+					Expect(resetToken(opts)).To(Succeed())
+				})
+				Expect(output).To(Equal("API token has been removed.\nRun `circleci setup` to set a new one.\n"))
 
-Trying an introspection query on API to verify your setup... 
-Unable to make a query against the GraphQL API, please check your settings.
-Trying to query our API for your profile name... Hello, %s.
-`, tempSettings.Config.Path, `zomg`)))
+				_, err := settings.TokenFromKeychain(tempSettings.TestServer.URL())
+				Expect(err).To(MatchError(keyring.ErrNotFound))
+			})
 		})
 	})
 
 	Context("when whoami query returns an auth error", func() {
 		BeforeEach(func() {
-			query := `query IntrospectionQuery {
-		    __schema {
-		      queryType { name }
-		      mutationType { name }
-		      types {
-		        ...FullType
-		      }
-		    }
-		  }
-
-		  fragment FullType on __Type {
-		    kind
-		    name
-		    description
-		    fields(includeDeprecated: true) {
-		      name
-		    }
-		  }`
-
+			query := `query { me { name } }`
 			request := graphql.NewRequest(query)
-			expected, err := request.Encode()
-			Expect(err).ShouldNot(HaveOccurred())
-
-			tmpBytes := golden.Get(GinkgoT(), filepath.FromSlash("diagnostic/response.json"))
-			mockResponse := string(tmpBytes)
-
-			tempSettings.AppendPostHandler("", clitest.MockRequestResponse{
-				Status:   http.StatusOK,
-				Request:  expected.String(),
-				Response: mockResponse})
-
-			// Here we want to actually validate the token in our test too
-			query = `query { me { name } }`
-			request = graphql.NewRequest(query)
 			request.SetToken(token)
-			Expect(err).ShouldNot(HaveOccurred())
-			expected, err = request.Encode()
+			expected, err := request.Encode()
 			Expect(err).ShouldNot(HaveOccurred())
 
 			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
 				Status:   http.StatusUnauthorized,
 				Request:  expected.String(),
 				Response: `{}`})
+			tempSettings.AppendPostHandler(token, clitest.MockRequestResponse{
+				Status:   http.StatusOK,
+				Request:  expected.String(),
+				Response: `{ "me": { "name": "zomg" } }`})
 		})
 
-		It("should show an error", func() {
-			opts.tty = setupTestUI{
-				host:            tempSettings.TestServer.URL(),
-				token:           token,
-				confirmEndpoint: true,
-				confirmToken:    true,
-			}
-
+		It("should ask for the token and host again", func() {
 			output := clitest.WithCapturedOutput(func() {
 				err := setup(opts)
 				Expect(err).ShouldNot(HaveOccurred())
@@ -315,13 +209,28 @@ API token has been set.
 CircleCI Host
 CircleCI host has been set.
 Do you want to reset the endpoint? (default: graphql-unstable)
+Checking your token with %[1]s... 
+Unable to use the token with %[1]s: failure calling GraphQL API: 401 Unauthorized
+CircleCI API Token
+API token has been set.
+CircleCI Host
+CircleCI host has been set.
+Checking your token with %[1]s... Ok, the token belongs to zomg.
 Setup complete.
-Your configuration has been saved to %s.
+Your configuration has been saved to %[2]s.
+`, tempSettings.TestServer.URL(), tempSettings.Config.Path)))
+		})
+
+		It("should save the token without checking it with --no-validate", func() {
+			opts.noValidate = true
+			output := clitest.WithCapturedOutput(func() {
+				err := setup(opts)
+				Expect(err).ShouldNot(HaveOccurred())
+			})
 
-Trying an introspection query on API to verify your setup... Ok.
-Trying to query our API for your profile name... 
-Unable to query our API for your profile name, please check your settings.
-`, tempSettings.Config.Path)))
+			Expect(output).ToNot(ContainSubstring("Checking your token"))
+			Expect(output).To(ContainSubstring("Setup complete."))
+			Expect(tempSettings.TestServer.ReceivedRequests()).To(BeEmpty())
 		})
 	})
 })
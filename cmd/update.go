@@ -15,9 +15,11 @@ import (
 )
 
 type updateCommandOptions struct {
-	cfg    *settings.Config
-	dryRun bool
-	args   []string
+	cfg             *settings.Config
+	dryRun          bool
+	version         string
+	verifySignature bool
+	args            []string
 }
 
 func newUpdateCommand(config *settings.Config) *cobra.Command {
@@ -87,6 +89,8 @@ func newUpdateCommand(config *settings.Config) *cobra.Command {
 	})
 
 	update.PersistentFlags().BoolVar(&opts.dryRun, "check", false, "Check if there are any updates available without installing")
+	update.PersistentFlags().StringVar(&opts.version, "version", "", "Install the given released version, such as v0.1.2, instead of the latest one. This can be older than the current version.")
+	update.PersistentFlags().BoolVar(&opts.verifySignature, "verify-signature", false, "Also verify the signature of the release checksums with gpg before installing")
 
 	return update
 }
@@ -94,6 +98,10 @@ func newUpdateCommand(config *settings.Config) *cobra.Command {
 func updateCLI(opts updateCommandOptions) error {
 	slug := "CircleCI-Public/circleci-cli"
 
+	if opts.version != "" {
+		return updateCLIToVersion(opts, slug)
+	}
+
 	spr := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
 	spr.Suffix = " Checking for updates..."
 	spr.Start()
@@ -127,6 +135,7 @@ func updateCLI(opts updateCommandOptions) error {
 
 	spr.Suffix = " Installing update..."
 	spr.Restart()
+	check.VerifySignature = opts.verifySignature
 	message, err := update.InstallLatest(check)
 	spr.Stop()
 	if err != nil {
@@ -137,3 +146,42 @@ func updateCLI(opts updateCommandOptions) error {
 
 	return nil
 }
+
+func updateCLIToVersion(opts updateCommandOptions, slug string) error {
+	spr := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	spr.Suffix = fmt.Sprintf(" Checking for version %s...", opts.version)
+	spr.Start()
+
+	check, err := update.CheckForVersion(opts.cfg.GitHubAPI, slug, version.Version, version.PackageManager(), opts.version)
+	spr.Stop()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("You are running %s\n", check.Current)
+
+	if check.Latest.Version.Equals(check.Current) {
+		fmt.Printf("Already running version %s.\n", check.Current)
+		return nil
+	}
+
+	if opts.dryRun {
+		fmt.Printf("Version %s is available\n", check.Latest.Version)
+		fmt.Printf("You can install it with `circleci update --version %s`\n", opts.version)
+		return nil
+	}
+
+	spr.Suffix = fmt.Sprintf(" Installing version %s...", check.Latest.Version)
+	spr.Restart()
+	check.VerifySignature = opts.verifySignature
+	message, err := update.InstallVersion(check)
+	spr.Stop()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(message)
+
+	return nil
+}
@@ -1,9 +1,13 @@
 package cmd_test
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"net/http"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/CircleCI-Public/circleci-cli/clitest"
 	. "github.com/onsi/ginkgo"
@@ -94,6 +98,51 @@ var _ = Describe("Update", func() {
 		})
 	})
 
+	Describe("update --check --version", func() {
+		It("should report that the version is available", func() {
+			command = exec.Command(pathCLI,
+				"update", "--check", "--version", "v1.0.0",
+				"--github-api", tempSettings.TestServer.URL(),
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session.Out).Should(gbytes.Say("You are running 0.0.0-dev"))
+			Eventually(session.Out).Should(gbytes.Say("Version 1.0.0 is available"))
+			Eventually(session.Out).Should(gbytes.Say("You can install it with `circleci update --version v1.0.0`"))
+
+			Eventually(session.Err.Contents()).Should(BeEmpty())
+			Eventually(session).Should(gexec.Exit(0))
+		})
+
+		It("should fail when the version wasn't released", func() {
+			command = exec.Command(pathCLI,
+				"update", "--check", "--version", "v2.0.0",
+				"--github-api", tempSettings.TestServer.URL(),
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(session.Err).To(gbytes.Say("Error: version 2.0.0 of the CLI was not found"))
+		})
+
+		It("should refuse an invalid version", func() {
+			command = exec.Command(pathCLI,
+				"update", "--check", "--version", "latest",
+				"--github-api", tempSettings.TestServer.URL(),
+			)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(session.Err).To(gbytes.Say(`Error: invalid version "latest"`))
+		})
+	})
+
 	Describe("update check", func() {
 		BeforeEach(func() {
 			command = exec.Command(pathCLI,
@@ -118,8 +167,25 @@ var _ = Describe("Update", func() {
 	})
 
 	Describe("update", func() {
+		var (
+			updateCLI  string
+			assetBytes []byte
+			release    = `
+{
+  "id": 1,
+  "tag_name": "v1.0.0",
+  "name": "v1.0.0",
+  "assets": [
+    { "id": 1, "name": "linux_amd64.zip" },
+    { "id": 2, "name": "checksums.txt" }
+  ]
+}
+`
+		)
+
 		BeforeEach(func() {
-			updateCLI, err := gexec.Build("github.com/CircleCI-Public/circleci-cli")
+			var err error
+			updateCLI, err = gexec.Build("github.com/CircleCI-Public/circleci-cli")
 			Expect(err).ShouldNot(HaveOccurred())
 
 			command = exec.Command(updateCLI,
@@ -127,22 +193,37 @@ var _ = Describe("Update", func() {
 				"--github-api", tempSettings.TestServer.URL(),
 			)
 
-			assetBytes := golden.Get(GinkgoT(), filepath.FromSlash("update/foo.zip"))
-			assetResponse := string(assetBytes)
+			assetBytes = golden.Get(GinkgoT(), filepath.FromSlash("update/foo.zip"))
+		})
+
+		// appendInstallHandlers serves the release the update is installed
+		// from, with checksums listing the given SHA256 for its binaries.
+		appendInstallHandlers := func(checksum string) {
+			checksums := ""
+			for _, name := range []string{"linux_amd64.zip", "darwin_amd64.tar.gz", "windows_amd64.tar.gz"} {
+				checksums += fmt.Sprintf("%s  %s\n", checksum, name)
+			}
 
 			tempSettings.TestServer.AppendHandlers(
 				ghttp.CombineHandlers(
-					ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases"),
-					ghttp.RespondWith(http.StatusOK, response),
+					ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/tags/v1.0.0"),
+					ghttp.RespondWith(http.StatusOK, release),
 				),
 				ghttp.CombineHandlers(
 					ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/1"),
-					ghttp.RespondWith(http.StatusOK, assetResponse),
+					ghttp.RespondWith(http.StatusOK, string(assetBytes)),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/repos/CircleCI-Public/circleci-cli/releases/assets/2"),
+					ghttp.RespondWith(http.StatusOK, checksums),
 				),
 			)
-		})
+		}
 
 		It("should update the program", func() {
+			sum := sha256.Sum256(assetBytes)
+			appendInstallHandlers(hex.EncodeToString(sum[:]))
+
 			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
 			Expect(err).ShouldNot(HaveOccurred())
 
@@ -154,6 +235,20 @@ var _ = Describe("Update", func() {
 			Eventually(session.Err.Contents()).Should(BeEmpty())
 			Eventually(session).Should(gexec.Exit(0))
 		})
+
+		It("should refuse to install a download that doesn't match its checksum", func() {
+			appendInstallHandlers(strings.Repeat("0", 64))
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Eventually(session).Should(clitest.ShouldFail())
+			Expect(session.Err).To(gbytes.Say("Error: failed to install update: checksum mismatch for linux_amd64.zip"))
+
+			version, err := exec.Command(updateCLI, "version", "--skip-update-check").Output()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(version)).To(ContainSubstring("0.0.0-dev"))
+		})
 	})
 
 	Describe("When Github returns a 403 error", func() {
@@ -1,10 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
 	"os"
-	"path"
 	"path/filepath"
-	"strings"
 
 	"github.com/CircleCI-Public/circleci-cli/md_docs"
 	"github.com/CircleCI-Public/circleci-cli/settings"
@@ -15,6 +15,12 @@ import (
 type usageOptions struct {
 	cfg  *settings.Config
 	args []string
+
+	// The format and directory of the docs generated by `circleci docs`
+	format    string
+	outputDir string
+	// Remove the pages of commands that no longer exist from outputDir
+	clean bool
 }
 
 func newUsageCommand(config *settings.Config) *cobra.Command {
@@ -36,6 +42,36 @@ func newUsageCommand(config *settings.Config) *cobra.Command {
 	}
 }
 
+func newDocsCommand(config *settings.Config) *cobra.Command {
+	opts := usageOptions{
+		cfg: config,
+	}
+
+	docsCmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Generate the reference of every command of the CLI.",
+		Long: `Generate the reference of every command of the CLI.
+
+With --format=markdown a page is written for each command, as with "circleci
+usage". With --format=json or --format=yaml the whole reference is written to a
+single circleci.json or circleci.yml document.
+
+With --clean, the markdown pages of commands that no longer exist are removed
+from the directory once the reference has been written.`,
+		Hidden: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return generateDocs(opts.format, opts.outputDir, opts.clean)
+		},
+		Args: cobra.NoArgs,
+	}
+
+	docsCmd.Flags().StringVar(&opts.format, "format", "markdown", "the format of the reference, one of json, yaml or markdown")
+	docsCmd.Flags().StringVar(&opts.outputDir, "output-dir", defaultDocsPath, "the directory to write the reference to, which is created if it doesn't exist")
+	docsCmd.Flags().BoolVar(&opts.clean, "clean", false, "remove the pages of commands that no longer exist from the directory, only with --format=markdown")
+
+	return docsCmd
+}
+
 var defaultDocsPath = "docs"
 
 func usage(opts usageOptions) error {
@@ -44,6 +80,36 @@ func usage(opts usageOptions) error {
 		docsPath = opts.args[0]
 	}
 
+	return generateDocs("markdown", docsPath, false)
+}
+
+// generateDocs writes the reference of every command to docsPath in the given
+// format, removing stale markdown pages when clean is set.
+func generateDocs(format, docsPath string, clean bool) error {
+	if clean && format != "markdown" {
+		return errors.New("--clean can only be used with --format=markdown")
+	}
+
+	var document func(*cobra.Command, string) error
+	switch format {
+	case "markdown":
+		document = func(root *cobra.Command, out string) error {
+			emptyStr := func(s string) string { return "" }
+			identity := func(s string) string { return s }
+			return md_docs.GenMarkdownTreeCustomOpts(root, out, emptyStr, identity, md_docs.GenMarkdownOptions{
+				LinkExtension: ".html",
+				IntroHeader:   md_docs.CircleCIIntroHeader,
+				Clean:         clean,
+			})
+		}
+	case "json":
+		document = singleDocument("circleci.json", md_docs.GenCommandJSON)
+	case "yaml":
+		document = singleDocument("circleci.yml", md_docs.GenCommandYAML)
+	default:
+		return fmt.Errorf("invalid --format %s, expected json, yaml or markdown", format)
+	}
+
 	if err := os.MkdirAll(docsPath, 0700); err != nil {
 		return errors.Wrap(err, "Could not create usage docs directory")
 	}
@@ -53,10 +119,22 @@ func usage(opts usageOptions) error {
 		return err
 	}
 
-	// generate markdown to out
-	emptyStr := func(s string) string { return "" }
-	return md_docs.GenMarkdownTreeCustom(rootCmd, out, emptyStr, func(name string) string {
-		base := strings.TrimSuffix(name, path.Ext(name))
-		return base + ".html"
-	})
+	return document(rootCmd, out)
+}
+
+// singleDocument writes the reference into a single file called name, with
+// gen.
+func singleDocument(name string, gen func(*cobra.Command, io.Writer) error) func(*cobra.Command, string) error {
+	return func(root *cobra.Command, out string) error {
+		f, err := os.Create(filepath.Join(out, name))
+		if err != nil {
+			return errors.Wrapf(err, "Could not create %s", name)
+		}
+		defer f.Close()
+
+		if err := gen(root, f); err != nil {
+			return err
+		}
+		return f.Close()
+	}
 }
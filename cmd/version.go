@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"runtime"
 
 	"github.com/CircleCI-Public/circleci-cli/settings"
 	"github.com/CircleCI-Public/circleci-cli/version"
@@ -11,6 +12,17 @@ import (
 type versionOptions struct {
 	cfg  *settings.Config
 	args []string
+	json bool
+}
+
+// versionInfo is printed by `version --json`.
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
 }
 
 func newVersionCommand(config *settings.Config) *cobra.Command {
@@ -18,7 +30,7 @@ func newVersionCommand(config *settings.Config) *cobra.Command {
 		cfg: config,
 	}
 
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "version",
 		Short: "Display version information",
 		PersistentPreRun: func(_ *cobra.Command, _ []string) {
@@ -27,8 +39,35 @@ func newVersionCommand(config *settings.Config) *cobra.Command {
 		PreRun: func(cmd *cobra.Command, args []string) {
 			opts.args = args
 		},
-		Run: func(_ *cobra.Command, _ []string) {
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if opts.json {
+				return printVersionJSON()
+			}
 			fmt.Printf("%s+%s (%s)\n", version.Version, version.Commit, version.PackageManager())
+			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&opts.json, "json", false, "print the version, commit, build date, Go version, OS and architecture as json")
+	addFieldFlag(cmd)
+
+	return cmd
+}
+
+func printVersionJSON() error {
+	info := versionInfo{
+		Version:   version.Version,
+		Commit:    version.Commit,
+		Date:      version.Date,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+
+	out, err := marshalJSON(info)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
 }
@@ -0,0 +1,61 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"os/exec"
+	"runtime"
+
+	"github.com/CircleCI-Public/circleci-cli/clitest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Version", func() {
+	It("prints the version", func() {
+		command := exec.Command(pathCLI, "version")
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(session.Out).Should(gbytes.Say(`0.0.0-dev\+dirty-local-tree \(source\)`))
+		Eventually(session).Should(gexec.Exit(0))
+	})
+
+	It("prints the build information as json with --json", func() {
+		command := exec.Command(pathCLI, "version", "--json")
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(0))
+
+		var info map[string]string
+		Expect(json.Unmarshal(session.Out.Contents(), &info)).To(Succeed())
+		Expect(info).To(Equal(map[string]string{
+			"version":    "0.0.0-dev",
+			"commit":     "dirty-local-tree",
+			"date":       "unknown",
+			"go_version": runtime.Version(),
+			"os":         runtime.GOOS,
+			"arch":       runtime.GOARCH,
+		}))
+	})
+
+	It("prints a single field with --json --field", func() {
+		command := exec.Command(pathCLI, "version", "--json", "--field", "os")
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(0))
+		Expect(string(session.Out.Contents())).To(Equal(runtime.GOOS + "\n"))
+	})
+
+	It("rejects --field without --json", func() {
+		command := exec.Command(pathCLI, "version", "--field", "os")
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(session.Err).Should(gbytes.Say("Error: --field can only be used with --json"))
+		Eventually(session).Should(clitest.ShouldFail())
+	})
+})
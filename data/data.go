@@ -23,9 +23,8 @@ func LoadData() (*YML, error) {
 	)
 
 	d := &YML{}
-	box := packr.New("circleci-cli-box", "../_data")
 
-	bts, err = box.Find("data.yml")
+	bts, err = box().Find("data.yml")
 	if err != nil {
 		return nil, err
 	}
@@ -37,3 +36,13 @@ func LoadData() (*YML, error) {
 
 	return d, nil
 }
+
+// Schema returns the JSON Schema in _data with the given name, such as
+// orb-schema.json.
+func Schema(name string) ([]byte, error) {
+	return box().Find(name)
+}
+
+func box() *packr.Box {
+	return packr.New("circleci-cli-box", "../_data")
+}
@@ -49,6 +49,19 @@ func (n Node) MarshalYAML() (interface{}, error) {
 	return n.marshalParent()
 }
 
+// Files returns the full paths of the files in the tree, which are the ones
+// that are packed when it is marshalled.
+func (n Node) Files() []string {
+	var files []string
+	if n.Info.Mode().IsRegular() {
+		files = append(files, n.FullPath)
+	}
+	for _, child := range n.Children {
+		files = append(files, child.Files()...)
+	}
+	return files
+}
+
 func (n Node) basename() string {
 	return n.Info.Name()
 }
@@ -172,7 +185,7 @@ func buildTree(absRootPath string, pathNodes PathNodes) *Node {
 	return rootNode
 }
 
-func collectNodes(absRootPath string, allowedDirs map[string]string) (PathNodes, error) {
+func collectNodes(absRootPath string, allowedDirs map[string]string, exclude []string) (PathNodes, error) {
 	pathNodes := PathNodes{}
 	pathNodes.Map = make(map[string]*Node)
 	pathNodes.Keys = []string{}
@@ -193,6 +206,13 @@ func collectNodes(absRootPath string, allowedDirs map[string]string) (PathNodes,
 			return filepath.SkipDir
 		}
 
+		if absRootPath != path && isExcluded(absRootPath, path, exclude) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		fp, err := filepath.Abs(path)
 		if err != nil {
 			return err
@@ -210,8 +230,39 @@ func collectNodes(absRootPath string, allowedDirs map[string]string) (PathNodes,
 	return pathNodes, err
 }
 
+// isExcluded reports whether the path relative to the root matches any of the
+// exclude patterns, which have already been checked by NewTreeExcluding.
+func isExcluded(absRootPath, path string, exclude []string) bool {
+	rel, err := filepath.Rel(absRootPath, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, pattern := range exclude {
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return true
+		}
+	}
+	return false
+}
+
 // NewTree creates a new filetree starting at the root
 func NewTree(rootPath string, allowedDirectories ...string) (*Node, error) {
+	return NewTreeExcluding(rootPath, nil, allowedDirectories...)
+}
+
+// NewTreeExcluding creates a new filetree starting at the root, leaving out
+// the files and directories whose path relative to the root, with forward
+// slashes, matches one of the exclude patterns. Patterns use the syntax of
+// filepath.Match, so `test/*` leaves out everything in the test directory.
+func NewTreeExcluding(rootPath string, exclude []string, allowedDirectories ...string) (*Node, error) {
+	for _, pattern := range exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %s: %s", pattern, err)
+		}
+	}
+
 	allowedDirs := make(map[string]string)
 
 	for _, dir := range allowedDirectories {
@@ -223,7 +274,7 @@ func NewTree(rootPath string, allowedDirectories ...string) (*Node, error) {
 		return nil, err
 	}
 
-	pathNodes, err := collectNodes(absRootPath, allowedDirs)
+	pathNodes, err := collectNodes(absRootPath, allowedDirs, exclude)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +107,29 @@ sub_dir:
         baz
 `))
 		})
+
+		It("Leaves out excluded paths", func() {
+			fixture := filepath.Join(subDir, "fixture.yml")
+			Expect(ioutil.WriteFile(fixture, []byte("fixture: true"), 0600)).To(Succeed())
+
+			tree, err := filetree.NewTreeExcluding(tempRoot, []string{"empty_dir", "sub_dir/fix*"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tree.Files()).To(Equal([]string{subDirFile}))
+
+			out, err := yaml.Marshal(tree)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(MatchYAML(`sub_dir:
+  sub_dir_file:
+    foo:
+      bar:
+        baz
+`))
+		})
+
+		It("Rejects invalid exclude patterns", func() {
+			_, err := filetree.NewTreeExcluding(tempRoot, []string{"sub_dir/["})
+			Expect(err).To(MatchError("invalid exclude pattern sub_dir/[: syntax error in pattern"))
+		})
 	})
 
 	Describe("MarshalYAML", func() {
@@ -9,7 +9,10 @@ import (
 	"os/exec"
 	"path"
 	"regexp"
+	"sort"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/CircleCI-Public/circleci-cli/api"
 	"github.com/CircleCI-Public/circleci-cli/api/graphql"
@@ -17,12 +20,34 @@ import (
 	"github.com/CircleCI-Public/circleci-cli/settings"
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
+	yaml "gopkg.in/yaml.v3"
 )
 
 var picardRepo = "circleci/picard"
 
 const DefaultConfigPath = ".circleci/config.yml"
 
+// The values of --pull. PullMissing leaves it to the build agent to pull the
+// images that aren't cached, as it always has.
+const (
+	PullAlways  = "always"
+	PullMissing = "missing"
+	PullNever   = "never"
+)
+
+// pullAttempts is how many times pulling an image is tried, since pulls of
+// large images often fail part way on a poor connection.
+var (
+	pullAttempts   = 3
+	pullRetryDelay = 2 * time.Second
+)
+
+// docker runs the docker CLI with args, returning its combined output. It is
+// replaced in tests.
+var docker = func(args ...string) ([]byte, error) {
+	return exec.Command("docker", args...).CombinedOutput() // #nosec
+}
+
 type buildAgentSettings struct {
 	LatestSha256 string
 }
@@ -40,20 +65,42 @@ func UpdateBuildAgent() error {
 }
 
 func Execute(flags *pflag.FlagSet, cfg *settings.Config) error {
+	pull, _ := flags.GetString("pull")
+	if pull != PullAlways && pull != PullMissing && pull != PullNever {
+		return fmt.Errorf("invalid --pull %s, expected always, missing or never", pull)
+	}
+
+	if err := checkVolumes(flags); err != nil {
+		return err
+	}
+
 	processedArgs, configPath := buildAgentArguments(flags)
+	envArgs, err := envFileArguments(flags)
+	if err != nil {
+		return err
+	}
+	processedArgs = append(envArgs, processedArgs...)
 	orgSlug, _ := flags.GetString("org-slug")
+	job, _ := flags.GetString("job")
 	cl := graphql.NewClient(cfg.HTTPClient, cfg.Host, cfg.Endpoint, cfg.Token, cfg.Debug)
-	configResponse, err := api.ConfigQuery(cl, configPath, orgSlug, nil, pipeline.LocalPipelineValues())
+	processedConfig, err := processConfig(cl, configPath, orgSlug, job)
 
 	if err != nil {
 		return err
 	}
 
-	if !configResponse.Valid {
-		return fmt.Errorf("config errors %v", configResponse.Errors)
+	if workdir, _ := flags.GetString("workdir"); workdir != "" {
+		var replaced string
+		processedConfig, replaced, err = setWorkingDirectory(processedConfig, job, workdir)
+		if err != nil {
+			return err
+		}
+		if replaced != "" && replaced != workdir {
+			fmt.Fprintf(os.Stderr, "Warning: --workdir %s replaces the working_directory %s of job %s\n", workdir, replaced, job)
+		}
 	}
 
-	processedConfigPath, err := writeStringToTempFile(configResponse.OutputYaml)
+	processedConfigPath, err := writeStringToTempFile(processedConfig)
 
 	// The file at processedConfigPath must be left in place until after the call
 	// to `docker run` has completed. Typically, we would `defer` a call to remove
@@ -78,12 +125,16 @@ func Execute(flags *pflag.FlagSet, cfg *settings.Config) error {
 		return err
 	}
 
-	image, err := picardImage(os.Stdout)
+	image, err := picardImage(os.Stdout, pull)
 
 	if err != nil {
 		return errors.Wrap(err, "Could not find picard image")
 	}
 
+	if err := pullJobImages(processedConfig, job, pull); err != nil {
+		return err
+	}
+
 	arguments := generateDockerCommand(processedConfigPath, image, pwd, processedArgs...)
 
 	if cfg.Debug {
@@ -101,6 +152,41 @@ func Execute(flags *pflag.FlagSet, cfg *settings.Config) error {
 	return errors.Wrap(err, "failed to execute docker")
 }
 
+// processConfig expands the config at configPath the same way as `config
+// process` does, resolving any orbs it uses, since build-agent can only run
+// a processed config. Failing here, rather than in build-agent, means the
+// user sees why the config couldn't be processed instead of a confusing
+// error from inside the container.
+func processConfig(cl *graphql.Client, configPath, orgSlug, job string) (string, error) {
+	configResponse, err := api.ConfigQuery(cl, configPath, orgSlug, nil, pipeline.LocalPipelineValues())
+
+	if err != nil {
+		return "", errors.Wrapf(err, "Unable to process %s, so the job was not run", configPath)
+	}
+
+	if !configResponse.Valid {
+		return "", errors.Wrapf(configResponse.Errors, "Unable to process %s, so the job was not run", configPath)
+	}
+
+	var processed struct {
+		Jobs map[string]interface{} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal([]byte(configResponse.OutputYaml), &processed); err != nil {
+		return "", errors.Wrap(err, "Unable to parse the processed config")
+	}
+
+	if _, ok := processed.Jobs[job]; job != "" && !ok {
+		jobs := make([]string, 0, len(processed.Jobs))
+		for name := range processed.Jobs {
+			jobs = append(jobs, name)
+		}
+		sort.Strings(jobs)
+		return "", fmt.Errorf("job '%s' is not defined in the processed config, the jobs it defines are: %s", job, strings.Join(jobs, ", "))
+	}
+
+	return configResponse.OutputYaml, nil
+}
+
 // The `local execute` command proxies execution to the picard docker container,
 // and ultimately to `build-agent`. We want to pass most arguments passed to the
 // `local execute` command on to build-agent
@@ -113,7 +199,7 @@ func AddFlagsForDocumentation(flags *pflag.FlagSet) {
 	flags.Int("node-total", 1, "total number of parallel nodes")
 	flags.Int("index", 0, "node index of parallelism")
 	flags.Bool("skip-checkout", true, "use local path as-is")
-	flags.StringArrayP("volume", "v", nil, "Volume bind-mounting")
+	flags.StringArrayP("volume", "v", nil, "Bind-mount a host directory or file into the job's container, as host-path:container-path")
 	flags.String("checkout-key", "~/.ssh/id_rsa", "Git Checkout key")
 	flags.String("revision", "", "Git Revision")
 	flags.String("branch", "", "Git branch")
@@ -123,8 +209,8 @@ func AddFlagsForDocumentation(flags *pflag.FlagSet) {
 
 // Given the full set of flags that were passed to this command, return the path
 // to the config file, and the list of supplied args _except_ for the `--config`
-// or `-c` argument, and except for --debug and --org-slug which are consumed by
-// this program.
+// or `-c` argument, and except for --debug, --org-slug, --env-file, --pull and
+// --workdir which are consumed by this program.
 // The `build-agent` can only deal with config version 2.0. In order to feed
 // version 2.0 config to it, we need to process the supplied config file using the
 // GraphQL API, and feed the result of that into `build-agent`. The first step of
@@ -136,7 +222,7 @@ func buildAgentArguments(flags *pflag.FlagSet) ([]string, string) {
 
 	// build a list of all supplied flags, that we will pass on to build-agent
 	flags.Visit(func(flag *pflag.Flag) {
-		if flag.Name != "org-slug" && flag.Name != "config" && flag.Name != "debug" {
+		if flag.Name != "org-slug" && flag.Name != "config" && flag.Name != "debug" && flag.Name != "env-file" && flag.Name != "pull" && flag.Name != "workdir" {
 			result = append(result, unparseFlag(flags, flag)...)
 		}
 	})
@@ -147,7 +233,116 @@ func buildAgentArguments(flags *pflag.FlagSet) ([]string, string) {
 	return result, configPath
 }
 
-func picardImage(output io.Writer) (string, error) {
+// envFileArguments reads the file given with --env-file, and returns an --env
+// argument for build-agent for each of the variables in it, except those also
+// set with -e, which take precedence. As with `docker run --env-file`, each
+// line is a KEY=VALUE pair taken literally, and blank lines and lines
+// starting with # are ignored.
+func envFileArguments(flags *pflag.FlagSet) ([]string, error) {
+	path, _ := flags.GetString("env-file")
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := ioutil.ReadFile(path) // #nosec
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not read the env file")
+	}
+
+	explicit := map[string]bool{}
+	envs, _ := flags.GetStringArray("env")
+	for _, env := range envs {
+		explicit[strings.SplitN(env, "=", 2)[0]] = true
+	}
+
+	result := []string{}
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid line %d in env file %s, expected KEY=VALUE", i+1, path)
+		}
+
+		if !explicit[parts[0]] {
+			result = append(result, "--env", line)
+		}
+	}
+
+	return result, nil
+}
+
+// checkVolumes checks that each --volume is a host-path:container-path pair,
+// with an optional mode such as ro, and that the host paths exist, as docker
+// would otherwise create missing ones as empty directories owned by root.
+func checkVolumes(flags *pflag.FlagSet) error {
+	volumes, _ := flags.GetStringArray("volume")
+	for _, volume := range volumes {
+		parts := strings.Split(volume, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid --volume %s, expected host-path:container-path", volume)
+		}
+		if _, err := os.Stat(parts[0]); err != nil {
+			return fmt.Errorf("the host path %s of --volume %s doesn't exist", parts[0], volume)
+		}
+	}
+	return nil
+}
+
+// setWorkingDirectory sets the working_directory of job in the processed
+// config to workdir, returning the new config along with the
+// working_directory that the job had, if any.
+func setWorkingDirectory(processedConfig, job, workdir string) (string, string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(processedConfig), &doc); err != nil {
+		return "", "", errors.Wrap(err, "Unable to parse the processed config")
+	}
+
+	var root *yaml.Node
+	if len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	jobNode := mappingValue(mappingValue(root, "jobs"), job)
+	if jobNode == nil || jobNode.Kind != yaml.MappingNode {
+		return "", "", fmt.Errorf("job '%s' is not defined in the processed config", job)
+	}
+
+	var replaced string
+	if current := mappingValue(jobNode, "working_directory"); current != nil {
+		replaced = current.Value
+		current.Value = workdir
+	} else {
+		jobNode.Content = append(jobNode.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "working_directory"},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: workdir},
+		)
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return "", "", err
+	}
+	return string(out), replaced, nil
+}
+
+// mappingValue returns the value of key in the mapping node, or nil when
+// node isn't a mapping or doesn't have key.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func picardImage(output io.Writer, pull string) (string, error) {
 
 	sha, err := loadCurrentBuildAgentSha()
 
@@ -155,6 +350,10 @@ func picardImage(output io.Writer) (string, error) {
 		fmt.Printf("Failed to load build agent settings: %s\n", err)
 	}
 
+	if sha == "" && pull == PullNever {
+		return "", errors.New("the build agent hasn't been downloaded yet, and --pull never doesn't allow downloading it")
+	}
+
 	if sha == "" {
 
 		fmt.Println("Downloading latest CircleCI build agent...")
@@ -172,6 +371,83 @@ func picardImage(output io.Writer) (string, error) {
 	return fmt.Sprintf("%s@%s", picardRepo, sha), nil
 }
 
+// jobImages returns the docker images of job in the processed config.
+func jobImages(processedConfig, job string) ([]string, error) {
+	var processed struct {
+		Jobs map[string]struct {
+			Docker []struct {
+				Image string `yaml:"image"`
+			} `yaml:"docker"`
+		} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal([]byte(processedConfig), &processed); err != nil {
+		return nil, errors.Wrap(err, "Unable to parse the processed config")
+	}
+
+	images := []string{}
+	for _, container := range processed.Jobs[job].Docker {
+		if container.Image != "" {
+			images = append(images, container.Image)
+		}
+	}
+	return images, nil
+}
+
+// pullJobImages pulls the images of job before it runs with --pull always,
+// or with --pull never checks that they are all cached, so that the job
+// fails before it starts rather than part way through. The build agent pulls
+// those that are missing otherwise.
+func pullJobImages(processedConfig, job, pull string) error {
+	if pull == PullMissing {
+		return nil
+	}
+
+	images, err := jobImages(processedConfig, job)
+	if err != nil {
+		return err
+	}
+
+	if pull == PullNever {
+		var missing []string
+		for _, image := range images {
+			if _, err := docker("image", "inspect", image); err != nil {
+				missing = append(missing, image)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("the images %s aren't cached, and --pull never doesn't allow pulling them", strings.Join(missing, ", "))
+		}
+		return nil
+	}
+
+	for _, image := range images {
+		fmt.Printf("Pulling %s...\n", image)
+		if err := pullImage(image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pullImage pulls image, trying again up to pullAttempts times when the pull
+// fails.
+func pullImage(image string) error {
+	var (
+		output []byte
+		err    error
+	)
+	for attempt := 1; attempt <= pullAttempts; attempt++ {
+		if output, err = docker("pull", image); err == nil {
+			return nil
+		}
+		if attempt < pullAttempts {
+			fmt.Printf("Pulling %s failed, trying again in %s\n", image, pullRetryDelay)
+			time.Sleep(pullRetryDelay)
+		}
+	}
+	return errors.Wrapf(err, "failed to pull %s after %d attempts: %s", image, pullAttempts, strings.TrimSpace(string(output)))
+}
+
 func ensureDockerIsAvailable() (string, error) {
 
 	dockerPath, err := exec.LookPath("docker")
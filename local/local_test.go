@@ -1,12 +1,16 @@
 package local
 
 import (
+	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 
+	"github.com/CircleCI-Public/circleci-cli/api/graphql"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
 	"github.com/spf13/pflag"
 )
 
@@ -123,6 +127,180 @@ var _ = Describe("build", func() {
 
 	})
 
+	Describe("reading an env file", func() {
+
+		var envFile string
+
+		BeforeEach(func() {
+			f, err := ioutil.TempFile("", "circleci-cli-test-*.env")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = f.WriteString("# secrets\nTOKEN=abc=123\n\nREGION=us-east-1\nEMPTY=\n")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.Close()).To(Succeed())
+			envFile = f.Name()
+		})
+
+		AfterEach(func() {
+			Expect(os.Remove(envFile)).To(Succeed())
+		})
+
+		parse := func(args ...string) (*pflag.FlagSet, error) {
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			AddFlagsForDocumentation(flags)
+			flags.String("env-file", "", "")
+			return flags, flags.Parse(args)
+		}
+
+		It("passes each variable to build-agent, letting -e override them", func() {
+			flags, err := parse("--env-file", envFile, "-e", "REGION=eu-west-1")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(envFileArguments(flags)).To(Equal([]string{
+				"--env", "TOKEN=abc=123",
+				"--env", "EMPTY=",
+			}))
+
+			args, _ := buildAgentArguments(flags)
+			Expect(args).To(Equal([]string{"--env", "REGION=eu-west-1"}))
+		})
+
+		It("does nothing without --env-file", func() {
+			flags, err := parse()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(envFileArguments(flags)).To(BeEmpty())
+		})
+
+		It("refuses lines that aren't KEY=VALUE", func() {
+			Expect(ioutil.WriteFile(envFile, []byte("TOKEN=abc\nREGION\n"), 0600)).To(Succeed())
+			flags, err := parse("--env-file", envFile)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = envFileArguments(flags)
+			Expect(err).To(MatchError(fmt.Sprintf("invalid line 2 in env file %s, expected KEY=VALUE", envFile)))
+		})
+	})
+
+	Describe("checking volumes", func() {
+
+		parse := func(args ...string) *pflag.FlagSet {
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			AddFlagsForDocumentation(flags)
+			Expect(flags.Parse(args)).To(Succeed())
+			return flags
+		}
+
+		It("accepts host paths that exist, with or without a mode", func() {
+			dir, err := ioutil.TempDir("", "circleci-cli-test-")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			Expect(checkVolumes(parse("-v", dir+":/fixtures", "--volume", dir+":/data:ro"))).To(Succeed())
+		})
+
+		It("refuses host paths that don't exist", func() {
+			err := checkVolumes(parse("--volume", "/does/not/exist:/fixtures"))
+			Expect(err).To(MatchError("the host path /does/not/exist of --volume /does/not/exist:/fixtures doesn't exist"))
+		})
+
+		It("refuses volumes without a container path", func() {
+			err := checkVolumes(parse("--volume", "/tmp"))
+			Expect(err).To(MatchError("invalid --volume /tmp, expected host-path:container-path"))
+		})
+	})
+
+	Describe("setting the working directory", func() {
+
+		It("replaces the working_directory of the job", func() {
+			processed, replaced, err := setWorkingDirectory("version: 2\njobs:\n  build:\n    working_directory: ~/project\n    docker: []\n  lint:\n    working_directory: ~/lint\n", "build", "/src")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(replaced).To(Equal("~/project"))
+			Expect(processed).To(ContainSubstring("build:\n        working_directory: /src\n"))
+			Expect(processed).To(ContainSubstring("working_directory: ~/lint"))
+		})
+
+		It("adds a working_directory to a job without one", func() {
+			processed, replaced, err := setWorkingDirectory("version: 2\njobs:\n  build:\n    docker: []\n", "build", "/src")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(replaced).To(BeEmpty())
+			Expect(processed).To(ContainSubstring("working_directory: /src"))
+		})
+
+		It("is consumed rather than passed to build-agent", func() {
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			AddFlagsForDocumentation(flags)
+			flags.String("workdir", "", "")
+			Expect(flags.Parse([]string{"--workdir", "/src", "--job", "build"})).To(Succeed())
+
+			args, _ := buildAgentArguments(flags)
+			Expect(args).To(Equal([]string{"--job", "build"}))
+		})
+	})
+
+	Describe("processing the config", func() {
+
+		var (
+			server     *ghttp.Server
+			configPath string
+		)
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+
+			f, err := ioutil.TempFile("", "circleci-cli-test-*.yml")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = f.WriteString("version: 2.1\norbs:\n  node: circleci/node@4.1.0\n")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.Close()).To(Succeed())
+			configPath = f.Name()
+		})
+
+		AfterEach(func() {
+			server.Close()
+			Expect(os.Remove(configPath)).To(Succeed())
+		})
+
+		process := func(job string) (string, error) {
+			cl := graphql.NewClient(http.DefaultClient, server.URL(), "graphql-unstable", "token", false)
+			return processConfig(cl, configPath, "", job)
+		}
+
+		It("returns the processed config", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{ "data": {
+				"buildConfig": {
+					"valid": true,
+					"outputYaml": "version: 2\njobs:\n  build:\n    docker:\n      - image: cimg/node:lts\n"
+				}
+			} }`))
+
+			processed, err := process("build")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processed).To(ContainSubstring("cimg/node:lts"))
+		})
+
+		It("explains when orbs can't be resolved", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{ "data": {
+				"buildConfig": {
+					"errors": [{ "message": "Cannot find circleci/node@4.1.0 in the orb registry." }]
+				}
+			} }`))
+
+			_, err := process("build")
+			Expect(err).To(MatchError(fmt.Sprintf("Unable to process %s, so the job was not run: Cannot find circleci/node@4.1.0 in the orb registry.", configPath)))
+		})
+
+		It("fails when the job isn't in the processed config", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{ "data": {
+				"buildConfig": {
+					"valid": true,
+					"outputYaml": "version: 2\njobs:\n  node/test:\n    docker: []\n  lint:\n    docker: []\n"
+				}
+			} }`))
+
+			_, err := process("build")
+			Expect(err).To(MatchError("job 'build' is not defined in the processed config, the jobs it defines are: lint, node/test"))
+		})
+	})
+
 	Describe("loading settings", func() {
 
 		var (
@@ -145,9 +323,74 @@ var _ = Describe("build", func() {
 		It("can load settings", func() {
 			Expect(storeBuildAgentSha("deipnosophist")).To(Succeed())
 			Expect(loadCurrentBuildAgentSha()).To(Equal("deipnosophist"))
-			image, err := picardImage(ioutil.Discard)
+			image, err := picardImage(ioutil.Discard, PullMissing)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(image).To(Equal("circleci/picard@deipnosophist"))
 		})
+
+		It("won't download the build agent with --pull never", func() {
+			_, err := picardImage(ioutil.Discard, PullNever)
+			Expect(err).To(MatchError("the build agent hasn't been downloaded yet, and --pull never doesn't allow downloading it"))
+		})
+	})
+
+	Describe("pulling the job's images", func() {
+		processed := "version: 2\njobs:\n  build:\n    docker:\n      - image: cimg/node:lts\n      - image: cimg/postgres:14.0\n  lint:\n    docker:\n      - image: cimg/go:1.17\n"
+
+		var (
+			calls  [][]string
+			failed map[string]int
+		)
+
+		BeforeEach(func() {
+			calls = nil
+			failed = map[string]int{}
+			pullRetryDelay = 0
+			docker = func(args ...string) ([]byte, error) {
+				calls = append(calls, args)
+				image := args[len(args)-1]
+				if failed[image] > 0 {
+					failed[image]--
+					return []byte("unexpected EOF"), fmt.Errorf("exit status 1")
+				}
+				return nil, nil
+			}
+		})
+
+		It("finds the images of the job", func() {
+			Expect(jobImages(processed, "build")).To(Equal([]string{"cimg/node:lts", "cimg/postgres:14.0"}))
+			Expect(jobImages(processed, "missing")).To(BeEmpty())
+		})
+
+		It("leaves the images to the build agent by default", func() {
+			Expect(pullJobImages(processed, "build", PullMissing)).To(Succeed())
+			Expect(calls).To(BeEmpty())
+		})
+
+		It("pulls every image with --pull always, trying again when a pull fails", func() {
+			failed["cimg/node:lts"] = 1
+			Expect(pullJobImages(processed, "build", PullAlways)).To(Succeed())
+			Expect(calls).To(Equal([][]string{
+				{"pull", "cimg/node:lts"},
+				{"pull", "cimg/node:lts"},
+				{"pull", "cimg/postgres:14.0"},
+			}))
+		})
+
+		It("gives up after several failed pulls", func() {
+			failed["cimg/node:lts"] = pullAttempts
+			err := pullJobImages(processed, "build", PullAlways)
+			Expect(err).To(MatchError("failed to pull cimg/node:lts after 3 attempts: unexpected EOF: exit status 1"))
+		})
+
+		It("fails with --pull never when an image isn't cached", func() {
+			failed["cimg/postgres:14.0"] = 1
+			err := pullJobImages(processed, "build", PullNever)
+			Expect(err).To(MatchError("the images cimg/postgres:14.0 aren't cached, and --pull never doesn't allow pulling them"))
+			Expect(calls).To(Equal([][]string{
+				{"image", "inspect", "cimg/node:lts"},
+				{"image", "inspect", "cimg/postgres:14.0"},
+			}))
+		})
 	})
 })
@@ -0,0 +1,120 @@
+package md_docs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// asciiDocListing writes s as a `----` delimited listing block.
+func asciiDocListing(buf *bytes.Buffer, s string) {
+	buf.WriteString("----\n" + strings.TrimRight(s, "\n") + "\n----\n\n")
+}
+
+// GenAsciiDoc creates AsciiDoc output.
+func GenAsciiDoc(cmd *cobra.Command, w io.Writer) error {
+	return GenAsciiDocCustom(cmd, w, func(s string) string { return s })
+}
+
+// GenAsciiDocCustom creates custom AsciiDoc output. SEE ALSO entries are
+// written as `xref:` cross references to the page named by linkHandler.
+func GenAsciiDocCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string) string) error {
+	cmd.InitDefaultHelpCmd()
+	cmd.InitDefaultHelpFlag()
+
+	buf := new(bytes.Buffer)
+	name := cmd.CommandPath()
+
+	short := cmd.Short
+	long := cmd.Long
+	if len(long) == 0 {
+		long = short
+	}
+
+	buf.WriteString("== " + name + "\n\n")
+	buf.WriteString(short + "\n\n")
+
+	buf.WriteString("=== Synopsis\n\n")
+	buf.WriteString(long + "\n\n")
+
+	if cmd.Runnable() {
+		asciiDocListing(buf, cmd.UseLine())
+	}
+
+	if len(cmd.Example) > 0 {
+		buf.WriteString("=== Examples\n\n")
+		asciiDocListing(buf, cmd.Example)
+	}
+
+	if args := formatPositionalArgs(cmd); args != "" {
+		buf.WriteString("=== Arguments\n\n")
+		asciiDocListing(buf, args)
+	}
+
+	flags := cmd.NonInheritedFlags()
+	if flags.HasAvailableFlags() {
+		buf.WriteString("=== Flags\n\n")
+		asciiDocListing(buf, flags.FlagUsages())
+	}
+
+	parentFlags := cmd.InheritedFlags()
+	if parentFlags.HasAvailableFlags() {
+		buf.WriteString("=== Flags inherited from parent commands\n\n")
+		asciiDocListing(buf, parentFlags.FlagUsages())
+	}
+
+	if hasSeeAlso(cmd, isDocumented) {
+		buf.WriteString("=== SEE ALSO\n\n")
+		if cmd.HasParent() {
+			parent := cmd.Parent()
+			link := linkHandler(underscoredName(parent) + ".adoc")
+			buf.WriteString(fmt.Sprintf("* xref:%s[%s] - %s\n", link, parent.CommandPath(), parent.Short))
+			cmd.VisitParents(func(c *cobra.Command) {
+				if c.DisableAutoGenTag {
+					cmd.DisableAutoGenTag = c.DisableAutoGenTag
+				}
+			})
+		}
+
+		children := cmd.Commands()
+		sort.Sort(byName(children))
+
+		for _, child := range children {
+			if !isDocumented(child) {
+				continue
+			}
+			link := linkHandler(underscoredName(child) + ".adoc")
+			buf.WriteString(fmt.Sprintf("* xref:%s[%s] - %s\n", link, child.CommandPath(), child.Short))
+		}
+		buf.WriteString("\n")
+	}
+	if !cmd.DisableAutoGenTag {
+		buf.WriteString("_Auto generated by spf13/cobra on " + genDate().Format("2-Jan-2006") + "_\n")
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// GenAsciiDocTree will generate an AsciiDoc page for this command and all
+// descendants in the directory given, named like `circleci_orb.adoc`.
+func GenAsciiDocTree(cmd *cobra.Command, dir string) error {
+	identity := func(s string) string { return s }
+	emptyStr := func(s string) string { return "" }
+	return GenAsciiDocTreeCustom(cmd, dir, emptyStr, identity)
+}
+
+// GenAsciiDocTreeCustom is the the same as GenAsciiDocTree, but
+// with custom filePrepender and linkHandler.
+func GenAsciiDocTreeCustom(cmd *cobra.Command, dir string, filePrepender, linkHandler func(string) string) error {
+	basename := func(c *cobra.Command) string { return underscoredName(c) + ".adoc" }
+	return genTree(cmd, dir, isDocumented, basename, func(c *cobra.Command, filename string, w io.Writer) error {
+		if _, err := io.WriteString(w, filePrepender(filename)); err != nil {
+			return err
+		}
+		return GenAsciiDocCustom(c, w, linkHandler)
+	})
+}
@@ -0,0 +1,119 @@
+package md_docs
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// CommandDoc is the JSON representation of a command written by GenCommandJSON,
+// and the YAML one written by GenCommandYAML.
+type CommandDoc struct {
+	Path     string          `json:"path" yaml:"path"`
+	Short    string          `json:"short" yaml:"short"`
+	Long     string          `json:"long,omitempty" yaml:"long,omitempty"`
+	Example  string          `json:"example,omitempty" yaml:"example,omitempty"`
+	Args     []PositionalDoc `json:"args,omitempty" yaml:"args,omitempty"`
+	Flags    []FlagDoc       `json:"flags,omitempty" yaml:"flags,omitempty"`
+	Commands []CommandDoc    `json:"commands,omitempty" yaml:"commands,omitempty"`
+}
+
+// PositionalDoc describes a positional argument, as documented in the
+// command's annotations.
+type PositionalDoc struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Type        string `json:"type,omitempty" yaml:"type,omitempty"`
+	Optional    bool   `json:"optional" yaml:"optional"`
+}
+
+// FlagDoc describes a single flag of a command.
+type FlagDoc struct {
+	Name      string `json:"name" yaml:"name"`
+	Shorthand string `json:"shorthand,omitempty" yaml:"shorthand,omitempty"`
+	Type      string `json:"type" yaml:"type"`
+	Default   string `json:"default" yaml:"default"`
+	Usage     string `json:"usage" yaml:"usage"`
+	Inherited bool   `json:"inherited" yaml:"inherited"`
+}
+
+// GenCommandJSON writes a nested JSON document describing this command and
+// all of its available descendants, including their arguments and flags.
+func GenCommandJSON(cmd *cobra.Command, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(commandDoc(cmd))
+}
+
+// GenCommandYAML is GenCommandJSON, but writes the document as YAML.
+func GenCommandYAML(cmd *cobra.Command, w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(commandDoc(cmd)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func commandDoc(cmd *cobra.Command) CommandDoc {
+	cmd.InitDefaultHelpCmd()
+	cmd.InitDefaultHelpFlag()
+
+	doc := CommandDoc{
+		Path:    cmd.CommandPath(),
+		Short:   cmd.Short,
+		Long:    cmd.Long,
+		Example: cmd.Example,
+	}
+
+	// Only documented arguments are rendered by printArguments, so match it.
+	if len(cmd.Annotations) > 0 {
+		for _, arg := range PositionalArgs(cmd) {
+			description, ok := positionalArgDescription(cmd, arg)
+			if !ok {
+				continue
+			}
+			argType, optional, _ := positionalArgType(cmd, arg)
+			doc.Args = append(doc.Args, PositionalDoc{
+				Name:        arg,
+				Description: description,
+				Type:        argType,
+				Optional:    optional,
+			})
+		}
+	}
+
+	doc.Flags = append(doc.Flags, flagDocs(cmd.NonInheritedFlags(), false)...)
+	doc.Flags = append(doc.Flags, flagDocs(cmd.InheritedFlags(), true)...)
+
+	for _, child := range cmd.Commands() {
+		if !isDocumented(child) {
+			continue
+		}
+		doc.Commands = append(doc.Commands, commandDoc(child))
+	}
+
+	return doc
+}
+
+func flagDocs(flags *pflag.FlagSet, inherited bool) []FlagDoc {
+	var docs []FlagDoc
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+		docs = append(docs, FlagDoc{
+			Name:      flag.Name,
+			Shorthand: flag.Shorthand,
+			Type:      flag.Value.Type(),
+			Default:   flag.DefValue,
+			Usage:     flag.Usage,
+			Inherited: inherited,
+		})
+	})
+	return docs
+}
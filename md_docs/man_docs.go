@@ -0,0 +1,180 @@
+package md_docs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// GenManHeader is a lot like the .TH header at the start of man pages. These
+// include the title, section, date, source, and manual. We will use the
+// generation date (honoring SOURCE_DATE_EPOCH) if Date is unset and will use
+// "Auto generated by spf13/cobra" if the Source is unset.
+type GenManHeader struct {
+	Title   string
+	Section string
+	Date    *time.Time
+	Source  string
+	Manual  string
+}
+
+// GenManTree will generate a man page for this command and all descendants
+// in the directory given. The header may be nil. Files are named after the
+// command path joined with dashes, e.g. `circleci-config-validate.1`.
+func GenManTree(cmd *cobra.Command, header *GenManHeader, dir string) error {
+	if header == nil {
+		header = &GenManHeader{}
+	}
+	basename := func(c *cobra.Command) string {
+		return dashedName(c) + "." + manSection(header)
+	}
+	return genTree(cmd, dir, isDocumented, basename, func(c *cobra.Command, _ string, w io.Writer) error {
+		// Each page gets its own copy so the title is derived per command.
+		headerCopy := *header
+		return GenMan(c, &headerCopy, w)
+	})
+}
+
+// GenMan will generate a man page for the given command and write it to
+// w. The header argument may be nil.
+func GenMan(cmd *cobra.Command, header *GenManHeader, w io.Writer) error {
+	cmd.InitDefaultHelpCmd()
+	cmd.InitDefaultHelpFlag()
+
+	if header == nil {
+		header = &GenManHeader{}
+	}
+	fillManHeader(header, cmd)
+
+	buf := new(bytes.Buffer)
+	name := dashedName(cmd)
+	section := manSection(header)
+
+	long := cmd.Long
+	if len(long) == 0 {
+		long = cmd.Short
+	}
+
+	buf.WriteString(fmt.Sprintf(".TH %q %q %q %q %q\n",
+		header.Title, section, header.Date.Format("Jan 2006"), header.Source, header.Manual))
+	buf.WriteString(".nh\n.ad l\n")
+
+	buf.WriteString(".SH NAME\n")
+	buf.WriteString(fmt.Sprintf("%s \\- %s\n", name, manEscape(cmd.Short)))
+
+	buf.WriteString(".SH SYNOPSIS\n")
+	buf.WriteString(fmt.Sprintf(".B %s\n", manEscape(cmd.UseLine())))
+
+	buf.WriteString(".SH DESCRIPTION\n")
+	buf.WriteString(manEscape(long) + "\n")
+
+	if len(cmd.Example) > 0 {
+		buf.WriteString(".SH EXAMPLES\n")
+		manLiteral(buf, cmd.Example)
+	}
+
+	if args := formatPositionalArgs(cmd); args != "" {
+		buf.WriteString(".SH ARGUMENTS\n")
+		manLiteral(buf, args)
+	}
+
+	manPrintFlags(buf, "OPTIONS", cmd.NonInheritedFlags())
+	manPrintFlags(buf, "OPTIONS INHERITED FROM PARENT COMMANDS", cmd.InheritedFlags())
+
+	if hasSeeAlso(cmd, isDocumented) {
+		var seeAlso []string
+		if cmd.HasParent() {
+			seeAlso = append(seeAlso, fmt.Sprintf(".BR %s (%s)", dashedName(cmd.Parent()), section))
+			cmd.VisitParents(func(c *cobra.Command) {
+				if c.DisableAutoGenTag {
+					cmd.DisableAutoGenTag = c.DisableAutoGenTag
+				}
+			})
+		}
+
+		children := cmd.Commands()
+		sort.Sort(byName(children))
+
+		for _, child := range children {
+			if !isDocumented(child) {
+				continue
+			}
+			seeAlso = append(seeAlso, fmt.Sprintf(".BR %s (%s)", dashedName(child), section))
+		}
+
+		buf.WriteString(".SH SEE ALSO\n")
+		buf.WriteString(strings.Join(seeAlso, ",\n") + "\n")
+	}
+
+	if !cmd.DisableAutoGenTag {
+		buf.WriteString(".SH HISTORY\n")
+		buf.WriteString(header.Date.Format("2-Jan-2006") + " Auto generated by spf13/cobra\n")
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func fillManHeader(header *GenManHeader, cmd *cobra.Command) {
+	if header.Title == "" {
+		header.Title = strings.ToUpper(dashedName(cmd))
+	}
+	if header.Section == "" {
+		header.Section = "1"
+	}
+	if header.Date == nil {
+		now := genDate()
+		header.Date = &now
+	}
+	if header.Source == "" {
+		header.Source = "Auto generated by spf13/cobra"
+	}
+}
+
+func manSection(header *GenManHeader) string {
+	if header.Section == "" {
+		return "1"
+	}
+	return header.Section
+}
+
+func manPrintFlags(buf *bytes.Buffer, title string, flags *pflag.FlagSet) {
+	if !flags.HasAvailableFlags() {
+		return
+	}
+	buf.WriteString(".SH " + title + "\n")
+	manLiteral(buf, flags.FlagUsages())
+}
+
+// manLiteral writes s as a no-fill block so that column alignment of usage
+// and example text is preserved.
+func manLiteral(buf *bytes.Buffer, s string) {
+	buf.WriteString(".PP\n.nf\n")
+	buf.WriteString(manEscape(strings.TrimRight(s, "\n")) + "\n")
+	buf.WriteString(".fi\n")
+}
+
+// manEscape escapes backslashes and guards lines that troff would otherwise
+// interpret as requests.
+func manEscape(s string) string {
+	s = strings.Replace(s, `\`, `\e`, -1)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dashedName returns the command path joined with dashes, e.g.
+// `circleci-config-validate`.
+func dashedName(cmd *cobra.Command) string {
+	return strings.Replace(cmd.CommandPath(), " ", "-", -1)
+}
@@ -4,16 +4,19 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"regexp"
+	"runtime"
 	"sort"
 	"strings"
-	"time"
+	"unicode"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-var introHeader = `
+// CircleCIIntroHeader is the banner of links and badges for the CircleCI CLI,
+// which can be used as GenMarkdownOptions.IntroHeader.
+var CircleCIIntroHeader = `
 [Readme](https://github.com/CircleCI-Public/circleci-cli#readme) |
 [Code of Conduct](https://github.com/CircleCI-Public/circleci-cli/blob/master/CODE_OF_CONDUCT.md) |
 [Contribution Guidelines](https://github.com/CircleCI-Public/circleci-cli/blob/master/CONTRIBUTING.md) |
@@ -26,6 +29,155 @@ var introHeader = `
 [![License](https://img.shields.io/badge/license-MIT-red.svg)](./LICENSE)
 `
 
+// GenMarkdownOptions controls optional behaviour of the markdown generators.
+// The zero value matches GenMarkdownCustom and GenMarkdownTreeCustom.
+type GenMarkdownOptions struct {
+	// IncludeHidden documents commands marked Hidden, such as internal or
+	// preview commands, with a note on their page. They are skipped otherwise.
+	IncludeHidden bool
+
+	// TOC emits a collapsible table of contents on the root command's page
+	// linking to the section of every documented command.
+	TOC bool
+
+	// EnvVar returns the environment variable bound to the named flag, if
+	// any, which is appended to that flag's line as `[env: NAME]`.
+	EnvVar func(flag string) string
+
+	// LinkExtension replaces the ".md" extension used to build links to
+	// other command pages, e.g. ".html". The link is still passed through
+	// linkHandler afterwards.
+	LinkExtension string
+
+	// NoLinkExtension builds links without any extension, for hosts that
+	// serve clean URLs.
+	NoLinkExtension bool
+
+	// Parallelism is how many pages GenMarkdownTreeCustomOpts renders at
+	// once, which is GOMAXPROCS when it is zero. The filePrepender and
+	// linkHandler it is given may be called concurrently.
+	Parallelism int
+
+	// IntroHeader is written on the root command's page between its
+	// description and synopsis, such as CircleCIIntroHeader. There is no
+	// header when it is empty.
+	IntroHeader string
+
+	// ExampleLanguage is the language the fence around a command's examples
+	// is tagged with, which is shell when it is empty. It can be overridden
+	// for a command with ExampleLanguageAnnotation.
+	ExampleLanguage string
+
+	// SeeAlsoOrder is the order child commands are listed in, in SEE ALSO
+	// and the table of contents. They are sorted by name by default.
+	SeeAlsoOrder SeeAlsoOrder
+
+	// Clean has GenMarkdownTreeCustomOpts remove the pages left in its
+	// directory by commands that no longer exist, once every page has been
+	// written. Only .md files named after the command or its descendants are
+	// removed.
+	Clean bool
+}
+
+// SeeAlsoOrder is an order to list child commands in.
+type SeeAlsoOrder int
+
+const (
+	// SeeAlsoByName sorts child commands alphabetically.
+	SeeAlsoByName SeeAlsoOrder = iota
+	// SeeAlsoRegistered keeps child commands in the order cmd.Commands()
+	// returns them. That is the order they were added in, as long as
+	// cobra.EnableCommandSorting is turned off before the commands are used.
+	SeeAlsoRegistered
+	// SeeAlsoByGroup lists child commands by their GroupAnnotation, and by
+	// name within each group. Groups are listed in the order their first
+	// command appears in cmd.Commands(), followed by the commands without
+	// a group.
+	SeeAlsoByGroup
+)
+
+// GroupAnnotation is the command annotation naming the group a command is
+// listed with when SeeAlsoByGroup is used, e.g.
+//
+//	cmd.Annotations[md_docs.GroupAnnotation] = "orbs"
+const GroupAnnotation = "group"
+
+// children returns the child commands of cmd in the order set by
+// SeeAlsoOrder. The slice cobra holds the children in isn't modified.
+func (opts GenMarkdownOptions) children(cmd *cobra.Command) []*cobra.Command {
+	children := append([]*cobra.Command(nil), cmd.Commands()...)
+
+	switch opts.SeeAlsoOrder {
+	case SeeAlsoRegistered:
+	case SeeAlsoByGroup:
+		rank := map[string]int{}
+		for _, child := range children {
+			group := child.Annotations[GroupAnnotation]
+			if _, seen := rank[group]; !seen && group != "" {
+				rank[group] = len(rank)
+			}
+		}
+		// Commands without a group go last
+		rank[""] = len(rank)
+
+		sort.SliceStable(children, func(i, j int) bool {
+			gi, gj := rank[children[i].Annotations[GroupAnnotation]], rank[children[j].Annotations[GroupAnnotation]]
+			if gi != gj {
+				return gi < gj
+			}
+			return children[i].Name() < children[j].Name()
+		})
+	default:
+		sort.Sort(byName(children))
+	}
+	return children
+}
+
+// ExampleLanguageAnnotation is the command annotation that sets the language
+// of its examples, when they aren't shell commands, e.g.
+//
+//	cmd.Annotations[md_docs.ExampleLanguageAnnotation] = "yaml"
+const ExampleLanguageAnnotation = "example-language"
+
+// exampleLanguage returns the language to tag the examples of cmd with.
+func (opts GenMarkdownOptions) exampleLanguage(cmd *cobra.Command) string {
+	if language := cmd.Annotations[ExampleLanguageAnnotation]; language != "" {
+		return language
+	}
+	if opts.ExampleLanguage != "" {
+		return opts.ExampleLanguage
+	}
+	return "shell"
+}
+
+// parallelism returns the number of pages to render at once.
+func (opts GenMarkdownOptions) parallelism() int {
+	if opts.Parallelism > 0 {
+		return opts.Parallelism
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// linkExtension returns the extension used for links to command pages.
+func (opts GenMarkdownOptions) linkExtension() string {
+	switch {
+	case opts.NoLinkExtension:
+		return ""
+	case opts.LinkExtension != "":
+		return opts.LinkExtension
+	default:
+		return ".md"
+	}
+}
+
+// includes reports whether docs should be generated for cmd.
+func (opts GenMarkdownOptions) includes(cmd *cobra.Command) bool {
+	if isDocumented(cmd) || isDeprecated(cmd) {
+		return true
+	}
+	return opts.IncludeHidden && cmd.Hidden && len(cmd.Deprecated) == 0
+}
+
 // PositionalArgs returns a slice of the given command's positional arguments
 func PositionalArgs(cmd *cobra.Command) []string {
 	args := strings.Split(cmd.Use, " ")
@@ -35,47 +187,180 @@ func PositionalArgs(cmd *cobra.Command) []string {
 	return args[1:]
 }
 
+// Positional arguments are documented with an annotation keyed by the
+// argument itself, e.g. `cmd.Annotations["<org-slug>"] = "The org slug"`.
+// Their type and whether they are optional can be documented with additional
+// annotations keyed by ArgAnnotationKey:
+//
+//	cmd.Annotations[md_docs.ArgAnnotationKey("<org-slug>", md_docs.ArgTypeAnnotation)] = "string"
+//	cmd.Annotations[md_docs.ArgAnnotationKey("<org-slug>", md_docs.ArgOptionalAnnotation)] = "true"
+const (
+	ArgTypeAnnotation     = "arg-type"
+	ArgOptionalAnnotation = "arg-optional"
+)
+
+// ArgAnnotationKey returns the annotation key for a property of the given
+// positional argument, e.g. `<org-slug>:arg-type`.
+func ArgAnnotationKey(arg, property string) string {
+	return arg + ":" + property
+}
+
 // FormatPositionalArg will format the positional arguments of a command from it's annotations.
 func FormatPositionalArg(cmd *cobra.Command, arg string) string {
-	description, ok := cmd.Annotations[arg]
+	description, ok := positionalArgDescription(cmd, arg)
 	if !ok {
 		return ""
 	}
-	return fmt.Sprintf("%-11v %s\n", arg, description)
+	argType, optional, ok := positionalArgType(cmd, arg)
+	if !ok {
+		return fmt.Sprintf("%-11v %s\n", arg, description)
+	}
+
+	required := "required"
+	if optional {
+		required = "optional"
+		arg = "[" + strings.TrimSuffix(strings.TrimPrefix(arg, "<"), ">") + "]"
+	}
+	hint := required
+	if argType != "" {
+		hint = argType + ", " + required
+	}
+	return fmt.Sprintf("%-11v %s\n", fmt.Sprintf("%s (%s)", arg, hint), description)
+}
+
+// positionalArgDescription looks up the description of a positional argument
+// in the command's annotations.
+func positionalArgDescription(cmd *cobra.Command, arg string) (string, bool) {
+	description, ok := cmd.Annotations[arg]
+	return description, ok
+}
+
+// positionalArgType looks up the type and optional annotations of a
+// positional argument, reporting whether either was set.
+func positionalArgType(cmd *cobra.Command, arg string) (string, bool, bool) {
+	argType, hasType := cmd.Annotations[ArgAnnotationKey(arg, ArgTypeAnnotation)]
+	optional, hasOptional := cmd.Annotations[ArgAnnotationKey(arg, ArgOptionalAnnotation)]
+	return argType, optional == "true", hasType || hasOptional
+}
+
+// formatPositionalArgs formats the documented positional arguments of a
+// command, or returns "" when none are. Not every annotation documents an
+// argument, e.g. ExampleLanguageAnnotation, so the arguments section of every
+// format is only written when this isn't empty.
+func formatPositionalArgs(cmd *cobra.Command) string {
+	var args strings.Builder
+	for _, arg := range PositionalArgs(cmd) {
+		args.WriteString(FormatPositionalArg(cmd, arg))
+	}
+	return args.String()
 }
 
 // Print the arguments with 11 characters of padding
 func printArguments(buf *bytes.Buffer, command *cobra.Command, name string) error {
-	if len(command.Annotations) > 0 {
+	if args := formatPositionalArgs(command); args != "" {
 		buf.WriteString("### Arguments\n\n```\n")
-		for _, arg := range PositionalArgs(command) {
-			buf.WriteString(FormatPositionalArg(command, arg))
-		}
+		buf.WriteString(args)
 		buf.WriteString("```\n\n")
 	}
 
 	return nil
 }
 
-func printFlags(buf *bytes.Buffer, cmd *cobra.Command, name string) error {
+func printFlags(buf *bytes.Buffer, cmd *cobra.Command, name string, opts GenMarkdownOptions) error {
 	flags := cmd.NonInheritedFlags()
-	flags.SetOutput(buf)
 	if flags.HasAvailableFlags() {
-		buf.WriteString("### Flags\n\n```\n")
-		flags.PrintDefaults()
-		buf.WriteString("```\n\n")
+		buf.WriteString("### Flags\n\n")
+		uncategorized, categories, byCategory := groupFlags(flags)
+		if uncategorized.HasAvailableFlags() {
+			buf.WriteString("```\n" + flagUsages(uncategorized, opts) + "```\n\n")
+		}
+		for _, category := range categories {
+			buf.WriteString("#### " + category + "\n\n")
+			buf.WriteString("```\n" + flagUsages(byCategory[category], opts) + "```\n\n")
+		}
 	}
 
 	parentFlags := cmd.InheritedFlags()
-	parentFlags.SetOutput(buf)
 	if parentFlags.HasAvailableFlags() {
 		buf.WriteString("### Flags inherited from parent commands\n\n```\n")
-		parentFlags.PrintDefaults()
+		buf.WriteString(flagUsages(parentFlags, opts))
 		buf.WriteString("```\n\n")
 	}
 	return nil
 }
 
+// FlagCategoryAnnotation is the flag annotation used to group a command's
+// flags under separate `#### <Category>` headings, e.g.
+//
+//	cmd.Flags().SetAnnotation("token", md_docs.FlagCategoryAnnotation, []string{"Authentication"})
+const FlagCategoryAnnotation = "category"
+
+// groupFlags splits flags by their category annotation, returning the flags
+// without one and the sorted names of the categories that have visible flags.
+func groupFlags(flags *pflag.FlagSet) (*pflag.FlagSet, []string, map[string]*pflag.FlagSet) {
+	uncategorized := pflag.NewFlagSet("", pflag.ContinueOnError)
+	byCategory := map[string]*pflag.FlagSet{}
+	var categories []string
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		category := flag.Annotations[FlagCategoryAnnotation]
+		if len(category) == 0 || category[0] == "" {
+			uncategorized.AddFlag(flag)
+			return
+		}
+		set, ok := byCategory[category[0]]
+		if !ok {
+			set = pflag.NewFlagSet(category[0], pflag.ContinueOnError)
+			byCategory[category[0]] = set
+		}
+		set.AddFlag(flag)
+		if !flag.Hidden && !contains(categories, category[0]) {
+			categories = append(categories, category[0])
+		}
+	})
+	sort.Strings(categories)
+
+	return uncategorized, categories, byCategory
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// flagLine matches the first line pflag prints for a flag, capturing its name.
+var flagLine = regexp.MustCompile(`^  (?:-., |    )--([^\s\[=]+)`)
+
+// flagUsages returns the same usage text as flags.PrintDefaults, with the
+// bound environment variable appended after each flag when opts.EnvVar is set.
+func flagUsages(flags *pflag.FlagSet, opts GenMarkdownOptions) string {
+	usages := flags.FlagUsages()
+	if opts.EnvVar == nil {
+		return usages
+	}
+
+	lines := strings.Split(strings.TrimSuffix(usages, "\n"), "\n")
+	env := ""
+	// Usage text can span several lines, so the note is written once the
+	// next flag starts.
+	for i, line := range lines {
+		if match := flagLine.FindStringSubmatch(line); match != nil {
+			if env != "" {
+				lines[i-1] += " [env: " + env + "]"
+			}
+			env = opts.EnvVar(match[1])
+		}
+	}
+	if env != "" {
+		lines[len(lines)-1] += " [env: " + env + "]"
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
 // GenMarkdown creates markdown output.
 func GenMarkdown(cmd *cobra.Command, w io.Writer) error {
 	return GenMarkdownCustom(cmd, w, func(s string) string { return s })
@@ -83,9 +368,44 @@ func GenMarkdown(cmd *cobra.Command, w io.Writer) error {
 
 // GenMarkdownCustom creates custom markdown output.
 func GenMarkdownCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string) string) error {
+	return GenMarkdownCustomOpts(cmd, w, linkHandler, GenMarkdownOptions{})
+}
+
+// GenMarkdownCustomOpts is the same as GenMarkdownCustom, but with options.
+func GenMarkdownCustomOpts(cmd *cobra.Command, w io.Writer, linkHandler func(string) string, opts GenMarkdownOptions) error {
+	link := func(c *cobra.Command) string { return linkHandler(underscoredName(c) + opts.linkExtension()) }
+	return genMarkdown(cmd, w, link, true, opts)
+}
+
+// genMarkdown renders the markdown section for cmd, using link to build the
+// SEE ALSO targets. The auto-gen footer is only written when autoGenTag is set
+// and it hasn't been disabled on the command or its parents.
+func genMarkdown(cmd *cobra.Command, w io.Writer, link func(*cobra.Command) string, autoGenTag bool, opts GenMarkdownOptions) error {
+	prepareMarkdown(cmd)
+	return renderMarkdown(cmd, w, link, autoGenTag, opts)
+}
+
+// prepareMarkdown makes the changes to cmd that rendering it relies on:
+// adding the help command and flag, merging the flags inherited from its
+// parents, and inheriting DisableAutoGenTag from them. Rendering a prepared
+// command only reads from it, so prepared commands can be rendered
+// concurrently.
+func prepareMarkdown(cmd *cobra.Command) {
 	cmd.InitDefaultHelpCmd()
 	cmd.InitDefaultHelpFlag()
+	cmd.NonInheritedFlags()
+	cmd.InheritedFlags()
 
+	cmd.VisitParents(func(c *cobra.Command) {
+		if c.DisableAutoGenTag {
+			cmd.DisableAutoGenTag = c.DisableAutoGenTag
+		}
+	})
+}
+
+// renderMarkdown writes the markdown section for a command that has been
+// prepared with prepareMarkdown.
+func renderMarkdown(cmd *cobra.Command, w io.Writer, link func(*cobra.Command) string, autoGenTag bool, opts GenMarkdownOptions) error {
 	buf := new(bytes.Buffer)
 	name := cmd.CommandPath()
 
@@ -96,66 +416,189 @@ func GenMarkdownCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string)
 	}
 
 	buf.WriteString("## " + name + "\n\n")
-	buf.WriteString(short + "\n\n")
+	if len(cmd.Deprecated) > 0 {
+		buf.WriteString("> ⚠️ Deprecated: " + escapeMarkdown(cmd.Deprecated) + "\n\n")
+	}
+	buf.WriteString(escapeMarkdown(short) + "\n\n")
 
-	if name == "circleci" {
-		buf.WriteString(introHeader + "\n\n")
+	if isHidden(cmd) {
+		buf.WriteString("> **Preview**: this command is hidden and may change or be removed without notice.\n\n")
+	}
+
+	if opts.TOC && !cmd.HasParent() {
+		printTOC(buf, cmd, link, opts)
+	}
+
+	if opts.IntroHeader != "" && !cmd.HasParent() {
+		buf.WriteString(opts.IntroHeader + "\n\n")
 	}
 	buf.WriteString("### Synopsis\n\n")
-	buf.WriteString(long + "\n\n")
+	buf.WriteString(escapeMarkdown(long) + "\n\n")
 
 	if cmd.Runnable() {
 		buf.WriteString(fmt.Sprintf("```\n%s\n```\n\n", cmd.UseLine()))
 	}
 
+	if len(cmd.Aliases) > 0 {
+		buf.WriteString("### Aliases\n\n")
+		buf.WriteString(formatAliases(cmd) + "\n\n")
+	}
+
 	if len(cmd.Example) > 0 {
 		buf.WriteString("### Examples\n\n")
-		buf.WriteString(fmt.Sprintf("```\n%s\n```\n\n", cmd.Example))
+		buf.WriteString(fmt.Sprintf("```%s\n%s\n```\n\n", opts.exampleLanguage(cmd), cmd.Example))
 	}
 
 	if err := printArguments(buf, cmd, name); err != nil {
 		return err
 	}
 
-	if err := printFlags(buf, cmd, name); err != nil {
+	if err := printFlags(buf, cmd, name, opts); err != nil {
 		return err
 	}
-	if hasSeeAlso(cmd) {
+	if hasSeeAlso(cmd, opts.includes) {
 		buf.WriteString("### SEE ALSO\n\n")
 		if cmd.HasParent() {
 			parent := cmd.Parent()
-			pname := parent.CommandPath()
-			link := pname + ".md"
-			link = strings.Replace(link, " ", "_", -1)
-			buf.WriteString(fmt.Sprintf("* [%s](%s)\t - %s\n", pname, linkHandler(link), parent.Short))
-			cmd.VisitParents(func(c *cobra.Command) {
-				if c.DisableAutoGenTag {
-					cmd.DisableAutoGenTag = c.DisableAutoGenTag
-				}
-			})
-		}
-
-		children := cmd.Commands()
-		sort.Sort(byName(children))
+			buf.WriteString(fmt.Sprintf("* [%s](%s)\t - %s\n", parent.CommandPath(), link(parent), escapeMarkdown(parent.Short)))
+		}
 
-		for _, child := range children {
-			if !child.IsAvailableCommand() || child.IsAdditionalHelpTopicCommand() {
+		for _, child := range opts.children(cmd) {
+			if !opts.includes(child) {
 				continue
 			}
 			cname := name + " " + child.Name()
-			link := cname + ".md"
-			link = strings.Replace(link, " ", "_", -1)
-			buf.WriteString(fmt.Sprintf("* [%s](%s)\t - %s\n", cname, linkHandler(link), child.Short))
+			short := escapeMarkdown(child.Short)
+			if len(child.Aliases) > 0 {
+				short += " (aliases: " + formatAliases(child) + ")"
+			}
+			if len(child.Deprecated) > 0 {
+				short += " (deprecated)"
+			}
+			buf.WriteString(fmt.Sprintf("* [%s](%s)\t - %s\n", cname, link(child), short))
 		}
 		buf.WriteString("\n")
 	}
-	if !cmd.DisableAutoGenTag {
-		buf.WriteString("###### Auto generated by spf13/cobra on " + time.Now().Format("2-Jan-2006") + "\n")
+	if autoGenTag && !cmd.DisableAutoGenTag {
+		writeAutoGenTag(buf)
 	}
 	_, err := buf.WriteTo(w)
 	return err
 }
 
+// formatAliases returns the aliases of cmd as a comma-separated list of code
+// spans, e.g. "`ls`, `l`".
+func formatAliases(cmd *cobra.Command) string {
+	aliases := make([]string, len(cmd.Aliases))
+	for i, alias := range cmd.Aliases {
+		aliases[i] = "`" + alias + "`"
+	}
+	return strings.Join(aliases, ", ")
+}
+
+// markdownEscaper escapes the characters that GitHub would otherwise render
+// as emphasis, code spans or HTML when they appear in prose.
+var markdownEscaper = strings.NewReplacer(
+	`_`, `\_`,
+	`*`, `\*`,
+	"`", "\\`",
+	`<`, `\<`,
+)
+
+// escapeMarkdown escapes description text written outside of code fences.
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// printTOC writes a `<details>` table of contents listing every command below
+// cmd, linking to the GitHub anchor of each command's heading.
+func printTOC(buf *bytes.Buffer, cmd *cobra.Command, link func(*cobra.Command) string, opts GenMarkdownOptions) {
+	buf.WriteString("<details>\n<summary>Table of contents</summary>\n\n")
+
+	var walk func(c *cobra.Command, depth int)
+	walk = func(c *cobra.Command, depth int) {
+		for _, child := range opts.children(c) {
+			if !opts.includes(child) {
+				continue
+			}
+			target := link(child)
+			if !strings.HasPrefix(target, "#") {
+				target += "#" + githubAnchor(child.CommandPath())
+			}
+			buf.WriteString(fmt.Sprintf("%s* [%s](%s)\n", strings.Repeat("  ", depth), child.CommandPath(), target))
+			walk(child, depth+1)
+		}
+	}
+	walk(cmd, 0)
+
+	buf.WriteString("\n</details>\n\n")
+}
+
+// isHidden reports whether cmd, or any of its parents, is hidden.
+func isHidden(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Hidden {
+			return true
+		}
+	}
+	return false
+}
+
+func writeAutoGenTag(buf *bytes.Buffer) {
+	buf.WriteString("###### Auto generated by spf13/cobra on " + genDate().Format("2-Jan-2006") + "\n")
+}
+
+// GenMarkdownSinglePage writes the markdown for this command and all of its
+// descendants to a single document. The SEE ALSO links point at the in-page
+// anchors GitHub generates for each `## circleci ...` heading, and a single
+// auto-gen footer is written at the end rather than one per command.
+func GenMarkdownSinglePage(cmd *cobra.Command, w io.Writer) error {
+	link := func(c *cobra.Command) string { return "#" + githubAnchor(c.CommandPath()) }
+
+	var walk func(c *cobra.Command) error
+	walk = func(c *cobra.Command) error {
+		if err := genMarkdown(c, w, link, false, GenMarkdownOptions{}); err != nil {
+			return err
+		}
+		for _, child := range c.Commands() {
+			if !(GenMarkdownOptions{}).includes(child) {
+				continue
+			}
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(cmd); err != nil {
+		return err
+	}
+
+	if cmd.DisableAutoGenTag {
+		return nil
+	}
+	buf := new(bytes.Buffer)
+	writeAutoGenTag(buf)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// githubAnchor returns the fragment GitHub generates for a heading: the text
+// lowercased, with punctuation other than `-` and `_` dropped and spaces
+// replaced by dashes.
+func githubAnchor(heading string) string {
+	var anchor strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			anchor.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			anchor.WriteRune(r)
+		}
+	}
+	return anchor.String()
+}
+
 // GenMarkdownTree will generate a markdown page for this command and all
 // descendants in the directory given. The header may be nil.
 // This function may not work correctly if your command names have `-` in them.
@@ -171,28 +614,39 @@ func GenMarkdownTree(cmd *cobra.Command, dir string) error {
 // GenMarkdownTreeCustom is the the same as GenMarkdownTree, but
 // with custom filePrepender and linkHandler.
 func GenMarkdownTreeCustom(cmd *cobra.Command, dir string, filePrepender, linkHandler func(string) string) error {
-	for _, c := range cmd.Commands() {
-		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
-			continue
-		}
-		if err := GenMarkdownTreeCustom(c, dir, filePrepender, linkHandler); err != nil {
+	return GenMarkdownTreeCustomOpts(cmd, dir, filePrepender, linkHandler, GenMarkdownOptions{})
+}
+
+// GenMarkdownTreeCustomOpts is the same as GenMarkdownTreeCustom, but with
+// options. Pages are rendered opts.Parallelism at a time, and the first error
+// stops the rest from being written. Stale pages are only removed when every
+// page was written.
+func GenMarkdownTreeCustomOpts(cmd *cobra.Command, dir string, filePrepender, linkHandler func(string) string, opts GenMarkdownOptions) error {
+	basename := func(c *cobra.Command) string { return underscoredName(c) + ".md" }
+	link := func(c *cobra.Command) string { return linkHandler(underscoredName(c) + opts.linkExtension()) }
+	err := genTreeParallel(cmd, dir, opts.includes, basename, opts.parallelism(), prepareMarkdown, func(c *cobra.Command, filename string, w io.Writer) error {
+		if _, err := io.WriteString(w, filePrepender(filename)); err != nil {
 			return err
 		}
-	}
-
-	basename := strings.Replace(cmd.CommandPath(), " ", "_", -1) + ".md"
-	filename := filepath.Join(dir, basename)
-	f, err := os.Create(filename)
-	if err != nil {
+		return renderMarkdown(c, w, link, true, opts)
+	})
+	if err != nil || !opts.Clean {
 		return err
 	}
-	defer f.Close()
+	return removeStale(cmd, dir, opts.includes, basename)
+}
 
-	if _, err := io.WriteString(f, filePrepender(filename)); err != nil {
-		return err
-	}
-	if err := GenMarkdownCustom(cmd, f, linkHandler); err != nil {
-		return err
-	}
-	return nil
+// GenMarkdownTreeWithFrontMatter is the same as GenMarkdownTree, but prepends
+// the result of fm to every file. Unlike the filePrepender of
+// GenMarkdownTreeCustom, fm is handed the command being rendered so front
+// matter like a title or weight can be derived from it.
+func GenMarkdownTreeWithFrontMatter(cmd *cobra.Command, dir string, fm func(cmd *cobra.Command) string) error {
+	identity := func(s string) string { return s }
+	basename := func(c *cobra.Command) string { return underscoredName(c) + ".md" }
+	return genTree(cmd, dir, GenMarkdownOptions{}.includes, basename, func(c *cobra.Command, _ string, w io.Writer) error {
+		if _, err := io.WriteString(w, fm(c)); err != nil {
+			return err
+		}
+		return GenMarkdownCustom(c, w, identity)
+	})
 }
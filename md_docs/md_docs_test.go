@@ -0,0 +1,547 @@
+package md_docs
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestGenMarkdownEscapesDescriptions(t *testing.T) {
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	process := &cobra.Command{
+		Use:     "process",
+		Short:   "process a_config *now*",
+		Example: "circleci process my_orb *",
+		Run:     func(cmd *cobra.Command, args []string) {},
+	}
+	process.Flags().String("org-slug", "", "an org_slug")
+	root.AddCommand(process)
+
+	t.Run("synopsis", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdown(process, out))
+
+		assert.Check(t, cmp.Contains(out.String(), "### Synopsis\n\nprocess a\\_config \\*now\\*\n\n"))
+		assert.Check(t, !bytes.Contains(out.Bytes(), []byte("process a_config *now*")))
+	})
+
+	t.Run("code blocks are untouched", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdown(process, out))
+
+		assert.Check(t, cmp.Contains(out.String(), "```shell\ncircleci process my_orb *\n```"))
+		assert.Check(t, cmp.Contains(out.String(), "an org_slug"))
+	})
+
+	t.Run("see also", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdown(root, out))
+
+		assert.Check(t, cmp.Contains(out.String(), "* [circleci process](circleci_process.md)\t - process a\\_config \\*now\\*\n"))
+	})
+}
+
+func TestGenMarkdownTreeIncludeHidden(t *testing.T) {
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	root.AddCommand(
+		&cobra.Command{Use: "public", Short: "public command", Run: func(cmd *cobra.Command, args []string) {}},
+		&cobra.Command{Use: "preview", Short: "preview command", Hidden: true, Run: func(cmd *cobra.Command, args []string) {}},
+	)
+
+	t.Run("excluded by default", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NilError(t, GenMarkdownTree(root, dir))
+
+		_, err := os.Stat(filepath.Join(dir, "circleci_preview.md"))
+		assert.Check(t, os.IsNotExist(err))
+
+		index, err := ioutil.ReadFile(filepath.Join(dir, "circleci.md"))
+		assert.NilError(t, err)
+		assert.Check(t, !bytes.Contains(index, []byte("circleci preview")))
+	})
+
+	t.Run("included when requested", func(t *testing.T) {
+		dir := t.TempDir()
+		identity := func(s string) string { return s }
+		emptyStr := func(s string) string { return "" }
+		assert.NilError(t, GenMarkdownTreeCustomOpts(root, dir, emptyStr, identity, GenMarkdownOptions{IncludeHidden: true}))
+
+		page, err := ioutil.ReadFile(filepath.Join(dir, "circleci_preview.md"))
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(string(page), "> **Preview**"))
+
+		index, err := ioutil.ReadFile(filepath.Join(dir, "circleci.md"))
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Contains(string(index), "* [circleci preview](circleci_preview.md)"))
+		assert.Check(t, !bytes.Contains(index, []byte("> **Preview**")))
+	})
+}
+
+func TestGenMarkdownTOC(t *testing.T) {
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	orb := &cobra.Command{Use: "orb", Short: "orbs"}
+	orb.AddCommand(
+		&cobra.Command{Use: "publish", Short: "publish an orb", Run: func(cmd *cobra.Command, args []string) {}},
+		&cobra.Command{Use: "list", Short: "list orbs", Run: func(cmd *cobra.Command, args []string) {}},
+	)
+	root.AddCommand(orb)
+	identity := func(s string) string { return s }
+
+	out := new(bytes.Buffer)
+	assert.NilError(t, GenMarkdownCustomOpts(root, out, identity, GenMarkdownOptions{TOC: true}))
+	assert.Check(t, cmp.Contains(out.String(), `<details>
+<summary>Table of contents</summary>
+
+* [circleci orb](circleci_orb.md#circleci-orb)
+  * [circleci orb list](circleci_orb_list.md#circleci-orb-list)
+  * [circleci orb publish](circleci_orb_publish.md#circleci-orb-publish)
+
+</details>
+`))
+
+	out.Reset()
+	assert.NilError(t, GenMarkdownCustomOpts(orb, out, identity, GenMarkdownOptions{TOC: true}))
+	assert.Check(t, !bytes.Contains(out.Bytes(), []byte("<details>")))
+
+	out.Reset()
+	assert.NilError(t, GenMarkdownCustom(root, out, identity))
+	assert.Check(t, !bytes.Contains(out.Bytes(), []byte("<details>")))
+}
+
+func TestGenMarkdownEnvVars(t *testing.T) {
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	root.PersistentFlags().String("token", "", "your token for using CircleCI")
+	root.PersistentFlags().String("host", "https://circleci.com", "URL to your CircleCI host")
+	setup := &cobra.Command{Use: "setup", Short: "setup", Run: func(cmd *cobra.Command, args []string) {}}
+	setup.Flags().Bool("no-prompt", false, "Disable prompt")
+	root.AddCommand(setup)
+	identity := func(s string) string { return s }
+
+	plain := new(bytes.Buffer)
+	assert.NilError(t, GenMarkdownCustom(setup, plain, identity))
+
+	t.Run("without lookup", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustomOpts(setup, out, identity, GenMarkdownOptions{EnvVar: nil}))
+		assert.Equal(t, out.String(), plain.String())
+		assert.Check(t, !bytes.Contains(out.Bytes(), []byte("[env:")))
+	})
+
+	t.Run("with lookup", func(t *testing.T) {
+		env := map[string]string{"token": "CIRCLECI_CLI_TOKEN", "host": "CIRCLECI_CLI_HOST"}
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustomOpts(setup, out, identity, GenMarkdownOptions{
+			EnvVar: func(flag string) string { return env[flag] },
+		}))
+		assert.Check(t, cmp.Contains(out.String(), `      --host string    URL to your CircleCI host (default "https://circleci.com") [env: CIRCLECI_CLI_HOST]
+      --token string   your token for using CircleCI [env: CIRCLECI_CLI_TOKEN]
+`))
+		assert.Check(t, cmp.Contains(out.String(), "      --no-prompt   Disable prompt\n"))
+	})
+}
+
+func TestGenMarkdownFlagCategories(t *testing.T) {
+	publish := &cobra.Command{Use: "publish", Short: "publish", Run: func(cmd *cobra.Command, args []string) {}}
+	publish.Flags().String("token", "", "your token")
+	publish.Flags().Bool("dry-run", false, "don't publish")
+	publish.Flags().String("namespace", "", "orb namespace")
+	identity := func(s string) string { return s }
+
+	t.Run("single block without annotations", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustom(publish, out, identity))
+		assert.Check(t, cmp.Contains(out.String(), "### Flags\n\n```\n      --dry-run"))
+		assert.Check(t, !bytes.Contains(out.Bytes(), []byte("\n#### ")))
+	})
+
+	t.Run("grouped by category", func(t *testing.T) {
+		assert.NilError(t, publish.Flags().SetAnnotation("token", FlagCategoryAnnotation, []string{"Authentication"}))
+		assert.NilError(t, publish.Flags().SetAnnotation("namespace", FlagCategoryAnnotation, []string{"Publishing"}))
+		assert.NilError(t, publish.Flags().SetAnnotation("dry-run", FlagCategoryAnnotation, []string{"Publishing"}))
+
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustom(publish, out, identity))
+		assert.Check(t, cmp.Contains(out.String(), "### Flags\n\n```\n  -h, --help   help for publish\n```\n\n"+
+			"#### Authentication\n\n```\n      --token string   your token\n```\n\n"+
+			"#### Publishing\n\n```\n      --dry-run            don't publish\n      --namespace string   orb namespace\n```\n\n"))
+	})
+}
+
+func TestGenMarkdownLinkExtension(t *testing.T) {
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	root.AddCommand(&cobra.Command{Use: "orb", Short: "orbs", Run: func(cmd *cobra.Command, args []string) {}})
+	identity := func(s string) string { return s }
+
+	for _, tc := range []struct {
+		name     string
+		opts     GenMarkdownOptions
+		expected string
+	}{
+		{name: "default", opts: GenMarkdownOptions{}, expected: "(circleci_orb.md)"},
+		{name: "html", opts: GenMarkdownOptions{LinkExtension: ".html"}, expected: "(circleci_orb.html)"},
+		{name: "none", opts: GenMarkdownOptions{NoLinkExtension: true}, expected: "(circleci_orb)"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			assert.NilError(t, GenMarkdownCustomOpts(root, out, identity, tc.opts))
+			assert.Check(t, cmp.Contains(out.String(), "* [circleci orb]"+tc.expected))
+		})
+	}
+}
+
+func TestFormatPositionalArg(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "create <org-slug> <name> <description>",
+		Annotations: map[string]string{
+			"<org-slug>": "The org slug",
+			ArgAnnotationKey("<org-slug>", ArgTypeAnnotation): "string",
+			"<name>": "The name",
+			ArgAnnotationKey("<name>", ArgOptionalAnnotation): "true",
+			"<description>": "A description",
+		},
+	}
+
+	assert.DeepEqual(t, PositionalArgs(cmd), []string{"<org-slug>", "<name>", "<description>"})
+	assert.Check(t, cmp.Equal(FormatPositionalArg(cmd, "<org-slug>"), "<org-slug> (string, required) The org slug\n"))
+	assert.Check(t, cmp.Equal(FormatPositionalArg(cmd, "<name>"), "[name] (optional) The name\n"))
+	assert.Check(t, cmp.Equal(FormatPositionalArg(cmd, "<description>"), "<description> A description\n"))
+}
+
+func TestGenAsciiDocTree(t *testing.T) {
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	root.AddCommand(&cobra.Command{Use: "orb", Short: "orbs", Example: "circleci orb list", Run: func(cmd *cobra.Command, args []string) {}})
+
+	dir := t.TempDir()
+	assert.NilError(t, GenAsciiDocTree(root, dir))
+
+	index, err := ioutil.ReadFile(filepath.Join(dir, "circleci.adoc"))
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Contains(string(index), "== circleci\n"))
+	assert.Check(t, cmp.Contains(string(index), "* xref:circleci_orb.adoc[circleci orb] - orbs\n"))
+
+	page, err := ioutil.ReadFile(filepath.Join(dir, "circleci_orb.adoc"))
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Contains(string(page), "=== Examples\n\n----\ncircleci orb list\n----\n"))
+	assert.Check(t, cmp.Contains(string(page), "* xref:circleci.adoc[circleci] - root\n"))
+}
+
+func TestGenMarkdownTreeParallel(t *testing.T) {
+	newTree := func() *cobra.Command {
+		root := &cobra.Command{Use: "circleci", Short: "root"}
+		root.PersistentFlags().String("token", "", "your token")
+		for _, group := range []string{"config", "context", "orb", "policy"} {
+			parent := &cobra.Command{Use: group, Short: group + " commands", DisableAutoGenTag: group == "policy"}
+			parent.PersistentFlags().Bool("json", false, "print json")
+			for _, sub := range []string{"list", "create", "delete", "show", "validate"} {
+				parent.AddCommand(&cobra.Command{Use: sub + " <name>", Short: sub + " a thing", Example: "circleci " + group + " " + sub, Run: func(cmd *cobra.Command, args []string) {}})
+			}
+			root.AddCommand(parent)
+		}
+		return root
+	}
+	identity := func(s string) string { return s }
+	readDir := func(t *testing.T, dir string) map[string]string {
+		files, err := ioutil.ReadDir(dir)
+		assert.NilError(t, err)
+		contents := map[string]string{}
+		for _, f := range files {
+			b, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+			assert.NilError(t, err)
+			contents[f.Name()] = string(b)
+		}
+		return contents
+	}
+
+	serial := t.TempDir()
+	basename := func(c *cobra.Command) string { return underscoredName(c) + ".md" }
+	assert.NilError(t, genTree(newTree(), serial, isDocumented, basename, func(c *cobra.Command, _ string, w io.Writer) error {
+		return GenMarkdownCustom(c, w, identity)
+	}))
+	expected := readDir(t, serial)
+	assert.Check(t, cmp.Len(expected, 25))
+
+	for _, parallelism := range []int{0, 1, 8} {
+		dir := t.TempDir()
+		assert.NilError(t, GenMarkdownTreeCustomOpts(newTree(), dir, func(string) string { return "" }, identity, GenMarkdownOptions{Parallelism: parallelism}))
+		assert.DeepEqual(t, readDir(t, dir), expected)
+	}
+
+	t.Run("returns the first error", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NilError(t, os.Mkdir(filepath.Join(dir, "circleci_orb_list.md"), 0755))
+		err := GenMarkdownTreeCustomOpts(newTree(), dir, identity, identity, GenMarkdownOptions{Parallelism: 4})
+		assert.ErrorContains(t, err, "circleci_orb_list.md")
+	})
+}
+
+func TestGenMarkdownTreeOutputDir(t *testing.T) {
+	run := func(cmd *cobra.Command, args []string) {}
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	orb := &cobra.Command{Use: "orb", Short: "orbs"}
+	orb.AddCommand(&cobra.Command{Use: "list", Short: "list orbs", Run: run})
+	root.AddCommand(orb)
+	identity := func(s string) string { return s }
+	emptyStr := func(s string) string { return "" }
+
+	t.Run("is created when missing", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "docs", "reference")
+		assert.NilError(t, GenMarkdownTreeCustom(root, dir, emptyStr, identity))
+		_, err := os.Stat(filepath.Join(dir, "circleci_orb_list.md"))
+		assert.NilError(t, err)
+	})
+
+	t.Run("only loses stale pages with Clean", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, name := range []string{"circleci_orb_publish.md", "circleci_old.md", "README.md", "circleci_orb_list.html", "circleciextra.md"} {
+			assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("stale"), 0644))
+		}
+
+		assert.NilError(t, GenMarkdownTreeCustomOpts(root, dir, emptyStr, identity, GenMarkdownOptions{}))
+		assert.Check(t, cmp.Len(readDirNames(t, dir), 8))
+
+		assert.NilError(t, GenMarkdownTreeCustomOpts(root, dir, emptyStr, identity, GenMarkdownOptions{Clean: true}))
+		assert.DeepEqual(t, readDirNames(t, dir), []string{
+			"README.md",
+			"circleci.md",
+			"circleci_orb.md",
+			"circleci_orb_list.html",
+			"circleci_orb_list.md",
+			"circleciextra.md",
+		})
+	})
+
+	t.Run("only cleans below the command", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "circleci_config.md"), []byte("sibling"), 0644))
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "circleci_orb_old.md"), []byte("stale"), 0644))
+
+		assert.NilError(t, GenMarkdownTreeCustomOpts(orb, dir, emptyStr, identity, GenMarkdownOptions{Clean: true}))
+		assert.DeepEqual(t, readDirNames(t, dir), []string{"circleci_config.md", "circleci_orb.md", "circleci_orb_list.md"})
+	})
+}
+
+func readDirNames(t *testing.T, dir string) []string {
+	files, err := ioutil.ReadDir(dir)
+	assert.NilError(t, err)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	return names
+}
+
+func TestGenMarkdownIntroHeader(t *testing.T) {
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	orb := &cobra.Command{Use: "orb", Short: "orbs", Run: func(cmd *cobra.Command, args []string) {}}
+	root.AddCommand(orb)
+	identity := func(s string) string { return s }
+
+	t.Run("none by default", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustom(root, out, identity))
+		assert.Check(t, !bytes.Contains(out.Bytes(), []byte("Code of Conduct")))
+	})
+
+	t.Run("written on the root page", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustomOpts(root, out, identity, GenMarkdownOptions{IntroHeader: "Our banner"}))
+		assert.Check(t, cmp.Contains(out.String(), "root\n\nOur banner\n\n### Synopsis"))
+	})
+
+	t.Run("not written on other pages", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustomOpts(orb, out, identity, GenMarkdownOptions{IntroHeader: CircleCIIntroHeader}))
+		assert.Check(t, !bytes.Contains(out.Bytes(), []byte("Code of Conduct")))
+	})
+}
+
+func TestGenMarkdownExampleLanguage(t *testing.T) {
+	process := &cobra.Command{
+		Use:     "process <path>",
+		Short:   "process a config",
+		Example: "circleci config process .circleci/config.yml",
+		Run:     func(cmd *cobra.Command, args []string) {},
+	}
+	process.Flags().Bool("dry-run", false, "don't process")
+	identity := func(s string) string { return s }
+
+	t.Run("shell by default", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustom(process, out, identity))
+		assert.Check(t, cmp.Contains(out.String(), "### Examples\n\n```shell\ncircleci config process"))
+		assert.Check(t, cmp.Contains(out.String(), "### Flags\n\n```\n"))
+	})
+
+	t.Run("set by the options", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustomOpts(process, out, identity, GenMarkdownOptions{ExampleLanguage: "console"}))
+		assert.Check(t, cmp.Contains(out.String(), "### Examples\n\n```console\n"))
+	})
+
+	t.Run("overridden by the annotation", func(t *testing.T) {
+		process.Annotations = map[string]string{ExampleLanguageAnnotation: "yaml"}
+		defer func() { process.Annotations = nil }()
+
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustomOpts(process, out, identity, GenMarkdownOptions{ExampleLanguage: "console"}))
+		assert.Check(t, cmp.Contains(out.String(), "### Examples\n\n```yaml\n"))
+		assert.Check(t, !bytes.Contains(out.Bytes(), []byte("### Arguments")))
+	})
+}
+
+func TestGenMarkdownSeeAlsoOrder(t *testing.T) {
+	cobra.EnableCommandSorting = false
+	defer func() { cobra.EnableCommandSorting = true }()
+
+	run := func(cmd *cobra.Command, args []string) {}
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	for _, c := range []struct{ name, group string }{
+		{"validate", "config"},
+		{"publish", "orbs"},
+		{"pack", "config"},
+		{"version", ""},
+		{"info", "orbs"},
+	} {
+		child := &cobra.Command{Use: c.name, Short: c.name, Run: run}
+		if c.group != "" {
+			child.Annotations = map[string]string{GroupAnnotation: c.group}
+		}
+		root.AddCommand(child)
+	}
+	identity := func(s string) string { return s }
+
+	seeAlso := func(t *testing.T, order SeeAlsoOrder) []string {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustomOpts(root, out, identity, GenMarkdownOptions{SeeAlsoOrder: order}))
+		var names []string
+		for _, line := range strings.Split(out.String(), "\n") {
+			if strings.HasPrefix(line, "* [circleci ") {
+				names = append(names, strings.TrimPrefix(strings.SplitN(line, "]", 2)[0], "* [circleci "))
+			}
+		}
+		return names
+	}
+
+	assert.DeepEqual(t, seeAlso(t, SeeAlsoByName), []string{"info", "pack", "publish", "validate", "version"})
+	assert.DeepEqual(t, seeAlso(t, SeeAlsoRegistered), []string{"validate", "publish", "pack", "version", "info"})
+	assert.DeepEqual(t, seeAlso(t, SeeAlsoByGroup), []string{"pack", "validate", "info", "publish", "version"})
+}
+
+func TestGenMarkdownDeprecated(t *testing.T) {
+	run := func(cmd *cobra.Command, args []string) {}
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	build := &cobra.Command{Use: "build", Short: "Run a job", Deprecated: "use `local execute` instead", Run: run}
+	hidden := &cobra.Command{Use: "old", Short: "old", Deprecated: "gone", Hidden: true, Run: run}
+	root.AddCommand(build, hidden)
+	identity := func(s string) string { return s }
+
+	t.Run("the command page has a notice", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustom(build, out, identity))
+		assert.Check(t, cmp.Contains(out.String(), "## circleci build\n\n> ⚠️ Deprecated: use \\`local execute\\` instead\n\nRun a job\n\n"))
+	})
+
+	t.Run("the parent lists it as deprecated", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustom(root, out, identity))
+		assert.Check(t, cmp.Contains(out.String(), "* [circleci build](circleci_build.md)\t - Run a job (deprecated)\n"))
+		assert.Check(t, !strings.Contains(out.String(), "circleci old"))
+	})
+
+	t.Run("the single page has a section for it", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownSinglePage(root, out))
+		assert.Check(t, cmp.Contains(out.String(), "## circleci build\n\n> ⚠️ Deprecated: "))
+		assert.Check(t, cmp.Contains(out.String(), "* [circleci build](#circleci-build)\t - Run a job (deprecated)\n"))
+	})
+
+	t.Run("the tree has a page for it", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NilError(t, GenMarkdownTree(root, dir))
+		_, err := os.Stat(filepath.Join(dir, "circleci_build.md"))
+		assert.NilError(t, err)
+		_, err = os.Stat(filepath.Join(dir, "circleci_old.md"))
+		assert.Check(t, os.IsNotExist(err))
+	})
+}
+
+func TestGenCommandYAML(t *testing.T) {
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	orb := &cobra.Command{Use: "orb", Short: "orbs", Run: func(cmd *cobra.Command, args []string) {}}
+	orb.Flags().Bool("json", false, "print JSON")
+	root.AddCommand(orb)
+
+	out := new(bytes.Buffer)
+	assert.NilError(t, GenCommandYAML(root, out))
+	assert.Check(t, cmp.Contains(out.String(), "path: circleci\nshort: root\n"))
+	assert.Check(t, cmp.Contains(out.String(), "  - path: circleci orb\n    short: orbs\n    flags:\n      - name: help\n"))
+	assert.Check(t, cmp.Contains(out.String(), "      - name: json\n        type: bool\n        default: \"false\"\n        usage: print JSON\n        inherited: false\n"))
+}
+
+func TestGenMarkdownAliases(t *testing.T) {
+	run := func(cmd *cobra.Command, args []string) {}
+	root := &cobra.Command{Use: "circleci", Short: "root"}
+	list := &cobra.Command{Use: "list", Short: "list orbs", Aliases: []string{"ls", "l"}, Run: run}
+	publish := &cobra.Command{Use: "publish", Short: "publish an orb", Run: run}
+	root.AddCommand(list, publish)
+	identity := func(s string) string { return s }
+
+	t.Run("the command page lists them", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustom(list, out, identity))
+		assert.Check(t, cmp.Contains(out.String(), "```\ncircleci list [flags]\n```\n\n### Aliases\n\n`ls`, `l`\n\n"))
+	})
+
+	t.Run("commands without aliases have no section", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustom(publish, out, identity))
+		assert.Check(t, !strings.Contains(out.String(), "### Aliases"))
+	})
+
+	t.Run("the parent notes them in SEE ALSO", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		assert.NilError(t, GenMarkdownCustom(root, out, identity))
+		assert.Check(t, cmp.Contains(out.String(), "* [circleci list](circleci_list.md)\t - list orbs (aliases: `ls`, `l`)\n"))
+		assert.Check(t, cmp.Contains(out.String(), "* [circleci publish](circleci_publish.md)\t - publish an orb\n"))
+	})
+}
+
+func TestGenArgumentsOnlyForDocumentedArgs(t *testing.T) {
+	gens := map[string]struct {
+		gen     func(cmd *cobra.Command, w io.Writer) error
+		section string
+	}{
+		"man":      {func(cmd *cobra.Command, w io.Writer) error { return GenMan(cmd, &GenManHeader{}, w) }, ".SH ARGUMENTS"},
+		"rest":     {GenReST, "Arguments\n"},
+		"asciidoc": {GenAsciiDoc, "=== Arguments"},
+		"markdown": {GenMarkdown, "### Arguments"},
+	}
+
+	for name, g := range gens {
+		t.Run(name, func(t *testing.T) {
+			annotated := &cobra.Command{
+				Use:         "process <path>",
+				Short:       "process a config",
+				Run:         func(cmd *cobra.Command, args []string) {},
+				Annotations: map[string]string{GroupAnnotation: "Config", ExampleLanguageAnnotation: "yaml"},
+			}
+			out := new(bytes.Buffer)
+			assert.NilError(t, g.gen(annotated, out))
+			assert.Check(t, !strings.Contains(out.String(), g.section), out.String())
+
+			annotated.Annotations["<path>"] = "The path to the config"
+			out.Reset()
+			assert.NilError(t, g.gen(annotated, out))
+			assert.Check(t, cmp.Contains(out.String(), g.section))
+			assert.Check(t, cmp.Contains(out.String(), "The path to the config"))
+		})
+	}
+}
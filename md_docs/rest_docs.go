@@ -0,0 +1,149 @@
+package md_docs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// reSTHeading writes title underlined with the given character, as required
+// by reStructuredText the underline must be at least as long as the title.
+func reSTHeading(buf *bytes.Buffer, title string, underline string) {
+	buf.WriteString(title + "\n")
+	buf.WriteString(strings.Repeat(underline, len(title)) + "\n\n")
+}
+
+// reSTLiteral writes s as an indented `::` literal block.
+func reSTLiteral(buf *bytes.Buffer, s string) {
+	buf.WriteString("::\n\n")
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		if line == "" {
+			buf.WriteString("\n")
+			continue
+		}
+		buf.WriteString("  " + line + "\n")
+	}
+	buf.WriteString("\n")
+}
+
+// reSTLink formats a SEE ALSO entry. The linkHandler is given the reference
+// name of the target page, e.g. `circleci_config`; when it returns the name
+// unchanged a Sphinx `:ref:` is emitted, otherwise the result is used as the
+// target of a plain hyperlink.
+func reSTLink(name, ref string, linkHandler func(string) string) string {
+	link := linkHandler(ref)
+	if link == ref {
+		return fmt.Sprintf(":ref:`%s <%s>`", name, ref)
+	}
+	return fmt.Sprintf("`%s <%s>`_", name, link)
+}
+
+// GenReST creates reStructuredText output.
+func GenReST(cmd *cobra.Command, w io.Writer) error {
+	return GenReSTCustom(cmd, w, func(s string) string { return s })
+}
+
+// GenReSTCustom creates custom reStructuredText output.
+func GenReSTCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string) string) error {
+	cmd.InitDefaultHelpCmd()
+	cmd.InitDefaultHelpFlag()
+
+	buf := new(bytes.Buffer)
+	name := cmd.CommandPath()
+
+	short := cmd.Short
+	long := cmd.Long
+	if len(long) == 0 {
+		long = short
+	}
+
+	reSTHeading(buf, name, "=")
+	buf.WriteString(short + "\n\n")
+
+	reSTHeading(buf, "Synopsis", "-")
+	buf.WriteString(long + "\n\n")
+
+	if cmd.Runnable() {
+		reSTLiteral(buf, cmd.UseLine())
+	}
+
+	if len(cmd.Example) > 0 {
+		reSTHeading(buf, "Examples", "-")
+		reSTLiteral(buf, cmd.Example)
+	}
+
+	if args := formatPositionalArgs(cmd); args != "" {
+		reSTHeading(buf, "Arguments", "-")
+		reSTLiteral(buf, args)
+	}
+
+	flags := cmd.NonInheritedFlags()
+	if flags.HasAvailableFlags() {
+		reSTHeading(buf, "Flags", "-")
+		reSTLiteral(buf, flags.FlagUsages())
+	}
+
+	parentFlags := cmd.InheritedFlags()
+	if parentFlags.HasAvailableFlags() {
+		reSTHeading(buf, "Flags inherited from parent commands", "-")
+		reSTLiteral(buf, parentFlags.FlagUsages())
+	}
+
+	if hasSeeAlso(cmd, isDocumented) {
+		reSTHeading(buf, "SEE ALSO", "-")
+		if cmd.HasParent() {
+			parent := cmd.Parent()
+			buf.WriteString(fmt.Sprintf("* %s - %s\n", reSTLink(parent.CommandPath(), underscoredName(parent), linkHandler), parent.Short))
+			cmd.VisitParents(func(c *cobra.Command) {
+				if c.DisableAutoGenTag {
+					cmd.DisableAutoGenTag = c.DisableAutoGenTag
+				}
+			})
+		}
+
+		children := cmd.Commands()
+		sort.Sort(byName(children))
+
+		for _, child := range children {
+			if !isDocumented(child) {
+				continue
+			}
+			buf.WriteString(fmt.Sprintf("* %s - %s\n", reSTLink(child.CommandPath(), underscoredName(child), linkHandler), child.Short))
+		}
+		buf.WriteString("\n")
+	}
+	if !cmd.DisableAutoGenTag {
+		buf.WriteString("*Auto generated by spf13/cobra on " + genDate().Format("2-Jan-2006") + "*\n")
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// GenReSTTree will generate a reStructuredText page for this command and all
+// descendants in the directory given. Files are named the same way as
+// GenMarkdownTree, e.g. `circleci_config_validate.rst`, and each one starts
+// with a `.. _circleci_config_validate:` label so the `:ref:` links resolve.
+func GenReSTTree(cmd *cobra.Command, dir string) error {
+	label := func(filename string) string {
+		return ".. _" + strings.TrimSuffix(filepath.Base(filename), ".rst") + ":\n\n"
+	}
+	identity := func(s string) string { return s }
+	return GenReSTTreeCustom(cmd, dir, label, identity)
+}
+
+// GenReSTTreeCustom is the the same as GenReSTTree, but
+// with custom filePrepender and linkHandler.
+func GenReSTTreeCustom(cmd *cobra.Command, dir string, filePrepender, linkHandler func(string) string) error {
+	basename := func(c *cobra.Command) string { return underscoredName(c) + ".rst" }
+	return genTree(cmd, dir, isDocumented, basename, func(c *cobra.Command, filename string, w io.Writer) error {
+		if _, err := io.WriteString(w, filePrepender(filename)); err != nil {
+			return err
+		}
+		return GenReSTCustom(c, w, linkHandler)
+	})
+}
@@ -1,18 +1,42 @@
 package md_docs
 
 import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/spf13/cobra"
 )
 
+// Now returns the time used for the auto generated tag. It can be replaced
+// by callers and tests that need deterministic output.
+var Now = time.Now
+
+// genDate returns the date stamped into generated docs. SOURCE_DATE_EPOCH is
+// honored when set so that builds can be reproduced, see
+// https://reproducible-builds.org/specs/source-date-epoch/
+func genDate() time.Time {
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		if seconds, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+			return time.Unix(seconds, 0).UTC()
+		}
+	}
+	return Now()
+}
+
 // Test to see if we have a reason to print See Also information in docs
 // Basically this is a test for a parent commend or a subcommand which is
 // both not deprecated and not the autogenerated help command.
-func hasSeeAlso(cmd *cobra.Command) bool {
+func hasSeeAlso(cmd *cobra.Command, include func(*cobra.Command) bool) bool {
 	if cmd.HasParent() {
 		return true
 	}
 	for _, c := range cmd.Commands() {
-		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
+		if !include(c) {
 			continue
 		}
 		return true
@@ -20,6 +44,157 @@ func hasSeeAlso(cmd *cobra.Command) bool {
 	return false
 }
 
+// isDocumented is the default test for whether a command gets documented:
+// it must be available and not an additional help topic.
+func isDocumented(cmd *cobra.Command) bool {
+	return cmd.IsAvailableCommand() && !cmd.IsAdditionalHelpTopicCommand()
+}
+
+// isDeprecated reports whether cmd is only left out of the available
+// commands because it is deprecated. Such commands are documented with a
+// deprecation notice.
+func isDeprecated(cmd *cobra.Command) bool {
+	if len(cmd.Deprecated) == 0 || cmd.Hidden || cmd.IsAdditionalHelpTopicCommand() {
+		return false
+	}
+	return cmd.Runnable() || cmd.HasAvailableSubCommands()
+}
+
+// genTree walks cmd and all of its descendants accepted by include
+// depth-first, creating a file in dir for each one named with the given
+// basename function and handing it to gen to render. dir is created if it
+// doesn't exist.
+func genTree(cmd *cobra.Command, dir string, include func(*cobra.Command) bool, basename func(*cobra.Command) string, gen func(cmd *cobra.Command, filename string, w io.Writer) error) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, c := range treeCommands(cmd, include) {
+		if err := genFile(c, filepath.Join(dir, basename(c)), gen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// treeCommands returns cmd and all of its descendants accepted by include,
+// each after its own descendants.
+func treeCommands(cmd *cobra.Command, include func(*cobra.Command) bool) []*cobra.Command {
+	var commands []*cobra.Command
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		for _, child := range c.Commands() {
+			if include(child) {
+				walk(child)
+			}
+		}
+		commands = append(commands, c)
+	}
+	walk(cmd)
+	return commands
+}
+
+// genTreeParallel is genTree, but renders up to workers files at once.
+// Commands are handed to prepare one at a time, in the order genTree visits
+// them, before any are rendered, so that gen only has to read from them. Once
+// a file fails, the files that haven't been started yet are skipped and the
+// first error is returned.
+func genTreeParallel(cmd *cobra.Command, dir string, include func(*cobra.Command) bool, basename func(*cobra.Command) string, workers int, prepare func(*cobra.Command), gen func(cmd *cobra.Command, filename string, w io.Writer) error) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	commands := treeCommands(cmd, include)
+	for _, c := range commands {
+		prepare(c)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		failed   = make(chan struct{})
+		jobs     = make(chan *cobra.Command)
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			close(failed)
+		})
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				if err := genFile(c, filepath.Join(dir, basename(c)), gen); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+schedule:
+	for _, c := range commands {
+		select {
+		case jobs <- c:
+		case <-failed:
+			break schedule
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return firstErr
+}
+
+func genFile(cmd *cobra.Command, filename string, gen func(cmd *cobra.Command, filename string, w io.Writer) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gen(cmd, filename, f)
+}
+
+// removeStale removes the files in dir that genTree would name for a command
+// below cmd, but that it didn't write for cmd's current descendants, such as
+// the pages of removed commands. Only files named like underscoredName(cmd)
+// or its descendants, with the extension of basename(cmd), are touched.
+func removeStale(cmd *cobra.Command, dir string, include func(*cobra.Command) bool, basename func(*cobra.Command) string) error {
+	generated := map[string]bool{}
+	for _, c := range treeCommands(cmd, include) {
+		generated[basename(c)] = true
+	}
+
+	ext := filepath.Ext(basename(cmd))
+	prefix := underscoredName(cmd)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || generated[name] || filepath.Ext(name) != ext {
+			continue
+		}
+		if stem := strings.TrimSuffix(name, ext); stem != prefix && !strings.HasPrefix(stem, prefix+"_") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// underscoredName returns the command path joined with underscores, e.g.
+// `circleci_config_validate`, which is used to name generated files.
+func underscoredName(cmd *cobra.Command) string {
+	return strings.Replace(cmd.CommandPath(), " ", "_", -1)
+}
+
 type byName []*cobra.Command
 
 func (s byName) Len() int           { return len(s) }
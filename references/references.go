@@ -37,6 +37,60 @@ func IsDevVersion(version string) bool {
 	return strings.HasPrefix(version, "dev:")
 }
 
+// semverRegexp matches the semantic versions that the registry accepts, such
+// as 1.2.3. Pre-release and build suffixes aren't accepted.
+var semverRegexp = regexp.MustCompile(`^(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)$`)
+
+// NormalizeVersion returns an error unless version is a version that can be
+// published: either a semantic version such as 1.2.3, or a dev version such
+// as dev:alpha. A leading `v` is dropped from a semantic version, so that
+// v1.2.3 is returned as 1.2.3.
+func NormalizeVersion(version string) (string, error) {
+	if IsDevVersion(version) {
+		label := strings.TrimPrefix(version, "dev:")
+		if label == "" || strings.ContainsAny(label, " \t\n") {
+			return "", fmt.Errorf("Invalid orb version '%s': Expected a dev version with a label and no spaces, such as 'dev:alpha'", version)
+		}
+		return version, nil
+	}
+
+	normalized := strings.TrimPrefix(version, "v")
+	if !semverRegexp.MatchString(normalized) {
+		return "", fmt.Errorf("Invalid orb version '%s': Expected a semantic version such as '1.2.3', or a dev version such as 'dev:alpha'", version)
+	}
+	return normalized, nil
+}
+
+// partialVersionRegexp matches the versions that an orb can be looked up by,
+// which include partial versions such as 1 or 1.2 that resolve to the latest
+// matching version.
+var partialVersionRegexp = regexp.MustCompile(`^(0|[1-9][0-9]*)(\.(0|[1-9][0-9]*)){0,2}$`)
+
+// NormalizeOrbRef returns ref with a leading `v` dropped from its version, or
+// an error if the version can't be used to look up the orb. Unlike
+// NormalizeVersion, partial versions such as 5 or 5.1 are accepted, along
+// with `volatile` and dev versions. A ref without a version is returned
+// unchanged.
+func NormalizeOrbRef(ref string) (string, error) {
+	namespace, orb, version, err := SplitIntoOrbNamespaceAndVersion(ref)
+	if err != nil || version == "volatile" {
+		return ref, nil
+	}
+
+	if IsDevVersion(version) {
+		if _, err := NormalizeVersion(version); err != nil {
+			return "", err
+		}
+		return ref, nil
+	}
+
+	normalized := strings.TrimPrefix(version, "v")
+	if !partialVersionRegexp.MatchString(normalized) {
+		return "", fmt.Errorf("Invalid orb version '%s': Expected a version such as '1', '1.2' or '1.2.3', 'volatile', or a dev version such as 'dev:alpha'", version)
+	}
+	return fmt.Sprintf("%s/%s@%s", namespace, orb, normalized), nil
+}
+
 // IsOrbRefWithOptionalVersion returns an error unless ref is a valid orb reference with optional version.
 func IsOrbRefWithOptionalVersion(ref string) error {
 
@@ -44,4 +44,37 @@ var _ = Describe("Parsing Orbs", func() {
 		Expect(version).To(Equal("dev:bah/bah"))
 		Expect(err).ShouldNot(HaveOccurred())
 	})
+
+	It("Should normalize versions", func() {
+		Expect(references.NormalizeVersion("1.2.3")).To(Equal("1.2.3"))
+		Expect(references.NormalizeVersion("v1.2.3")).To(Equal("1.2.3"))
+		Expect(references.NormalizeVersion("dev:bah/bah")).To(Equal("dev:bah/bah"))
+
+		_, err := references.NormalizeVersion("1.2")
+		Expect(err).Should(MatchError("Invalid orb version '1.2': Expected a semantic version such as '1.2.3', or a dev version such as 'dev:alpha'"))
+
+		_, err = references.NormalizeVersion("1.2.3-beta")
+		Expect(err).Should(HaveOccurred())
+
+		_, err = references.NormalizeVersion("dev:")
+		Expect(err).Should(MatchError("Invalid orb version 'dev:': Expected a dev version with a label and no spaces, such as 'dev:alpha'"))
+	})
+
+	It("Should normalize the version of references", func() {
+		Expect(references.NormalizeOrbRef("foo/bar@v1.2.3")).To(Equal("foo/bar@1.2.3"))
+		Expect(references.NormalizeOrbRef("foo/bar")).To(Equal("foo/bar"))
+		Expect(references.NormalizeOrbRef("foo/bar@volatile")).To(Equal("foo/bar@volatile"))
+		Expect(references.NormalizeOrbRef("foo/bar@5")).To(Equal("foo/bar@5"))
+		Expect(references.NormalizeOrbRef("foo/bar@v5.1")).To(Equal("foo/bar@5.1"))
+		Expect(references.NormalizeOrbRef("foo/bar@dev:alpha")).To(Equal("foo/bar@dev:alpha"))
+
+		_, err := references.NormalizeOrbRef("foo/bar@latest")
+		Expect(err).Should(MatchError("Invalid orb version 'latest': Expected a version such as '1', '1.2' or '1.2.3', 'volatile', or a dev version such as 'dev:alpha'"))
+
+		_, err = references.NormalizeOrbRef("foo/bar@1.2.3.4")
+		Expect(err).Should(HaveOccurred())
+
+		_, err = references.NormalizeOrbRef("foo/bar@dev:")
+		Expect(err).Should(HaveOccurred())
+	})
 })
@@ -0,0 +1,34 @@
+package settings
+
+import (
+	"github.com/zalando/go-keyring"
+)
+
+// keychainService is the name the token is stored under in the OS keychain,
+// one entry per host.
+const keychainService = "circleci-cli"
+
+// keychainTokenSource is the TokenSource of a token read from the keychain.
+const keychainTokenSource = "the keychain"
+
+// SaveTokenToKeychain stores the token for host in the OS keychain: the macOS
+// Keychain, the Windows Credential Manager or the Secret Service on Linux.
+// It returns an error when no keychain is available.
+func SaveTokenToKeychain(host, token string) error {
+	return keyring.Set(keychainService, host, token)
+}
+
+// TokenFromKeychain returns the token saved for host in the OS keychain.
+func TokenFromKeychain(host string) (string, error) {
+	return keyring.Get(keychainService, host)
+}
+
+// DeleteTokenFromKeychain removes the token saved for host from the OS
+// keychain. It isn't an error when there is no token to remove.
+func DeleteTokenFromKeychain(host string) error {
+	err := keyring.Delete(keychainService, host)
+	if err == keyring.ErrNotFound {
+		return nil
+	}
+	return err
+}
@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/CircleCI-Public/circleci-cli/api/debug"
 	"github.com/CircleCI-Public/circleci-cli/data"
 	yaml "gopkg.in/yaml.v3"
 )
@@ -23,9 +24,13 @@ type Config struct {
 	Host            string            `yaml:"host"`
 	Endpoint        string            `yaml:"endpoint"`
 	Token           string            `yaml:"token"`
+	Keychain        bool              `yaml:"keychain,omitempty"`
 	RestEndpoint    string            `yaml:"rest_endpoint"`
 	TLSCert         string            `yaml:"tls_cert"`
 	TLSInsecure     bool              `yaml:"tls_insecure"`
+	CACert          string            `yaml:"ca_cert,omitempty"`
+	DefaultOrgID    string            `yaml:"default_org_id,omitempty"`
+	CacheTTL        time.Duration     `yaml:"cache_ttl,omitempty"`
 	HTTPClient      *http.Client      `yaml:"-"`
 	Data            *data.YML         `yaml:"-"`
 	Debug           bool              `yaml:"-"`
@@ -33,7 +38,32 @@ type Config struct {
 	FileUsed        string            `yaml:"-"`
 	GitHubAPI       string            `yaml:"-"`
 	SkipUpdateCheck bool              `yaml:"-"`
+	MaxRetries      int               `yaml:"-"`
+	Timeout         time.Duration     `yaml:"-"`
 	OrbPublishing   OrbPublishingInfo `yaml:"orb_publishing"`
+	// Profile is the name of the profile in Profiles that is active, if any.
+	Profile  string             `yaml:"-"`
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+	// TokenSource is where Token came from, such as CIRCLECI_CLI_TOKEN, as
+	// shown by `circleci diagnostic`.
+	TokenSource string `yaml:"-"`
+	// unprofiled are the settings that the profile replaced, so that they
+	// can be written back to the top of the file.
+	unprofiled Profile
+}
+
+// Profile is a named set of settings in the config file, such as for a
+// staging server, which is used in place of the settings at the top of the
+// file when it is active. The token always comes from the profile, so that it
+// isn't sent to another host; the other settings fall back to the top of the
+// file when they aren't set.
+type Profile struct {
+	Host         string `yaml:"host,omitempty"`
+	Endpoint     string `yaml:"endpoint,omitempty"`
+	RestEndpoint string `yaml:"rest_endpoint,omitempty"`
+	Token        string `yaml:"token,omitempty"`
+	Keychain     bool   `yaml:"keychain,omitempty"`
+	DefaultOrgID string `yaml:"default_org_id,omitempty"`
 }
 
 type OrbPublishingInfo struct {
@@ -79,27 +109,96 @@ func (upd *UpdateCheck) WriteToDisk() error {
 }
 
 // Load will read the config from the user's disk and then evaluate possible configuration from the environment.
+// The profile is Profile when it is set, or otherwise CIRCLECI_CLI_PROFILE.
 func (cfg *Config) Load() error {
+	if cfg.Profile == "" {
+		cfg.Profile = ReadFromEnv("circleci_cli", "profile")
+	}
+
 	if err := cfg.LoadFromDisk(); err != nil {
 		return err
 	}
 
+	cfg.TokenSource = ""
+	if cfg.Token != "" {
+		cfg.TokenSource = "the config file"
+	}
+
+	if err := cfg.LoadTokenFile("circleci_cli"); err != nil {
+		return err
+	}
+
 	cfg.LoadFromEnv("circleci_cli")
 
+	// After the environment, so that the token is that of CIRCLECI_CLI_HOST
+	cfg.LoadTokenFromKeychain()
+
+	return nil
+}
+
+// LoadTokenFromKeychain reads the token for Host from the keychain, when the
+// config keeps its token there and nothing else gave one. The keychain keeps
+// a token per host, so it is read again if Host changes, such as with --host.
+// If the keychain can't be read we carry on without it, as with no token at
+// all.
+func (cfg *Config) LoadTokenFromKeychain() {
+	if !cfg.Keychain || (cfg.Token != "" && cfg.TokenSource != keychainTokenSource) {
+		return
+	}
+
+	cfg.Token, cfg.TokenSource = "", ""
+	if token, err := TokenFromKeychain(cfg.Host); err == nil {
+		cfg.Token = token
+		cfg.TokenSource = keychainTokenSource
+	}
+}
+
+// LoadTokenFile reads the token from the file named by the TOKEN_FILE
+// variable with prefix, such as CIRCLECI_CLI_TOKEN_FILE, so that secret
+// managers which provide secrets as files don't need to put the token in the
+// environment. The TOKEN variable takes precedence over it.
+func (cfg *Config) LoadTokenFile(prefix string) error {
+	path := ReadFromEnv(prefix, "token_file")
+	if path == "" {
+		return nil
+	}
+
+	name := strings.ToUpper(prefix + "_token_file")
+	content, err := ioutil.ReadFile(path) // #nosec
+	if err != nil {
+		return fmt.Errorf("unable to read the token from %s: %s", name, err)
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return fmt.Errorf("the file %s given by %s is empty", path, name)
+	}
+
+	cfg.Token = token
+	cfg.TokenSource = name
 	return nil
 }
 
 // LoadFromDisk is used to read config from the user's disk and deserialize the YAML into our runtime config.
+// The config is read from FileUsed when it is set, or otherwise from ConfigPath. Only the default config file is
+// created when it doesn't exist, any other is created when it is first written to, such as by `circleci setup`.
 func (cfg *Config) LoadFromDisk() error {
-	path := filepath.Join(SettingsPath(), configFilename())
+	path := cfg.FileUsed
+	if path == "" {
+		path = ConfigPath()
+	}
 
-	if err := ensureSettingsFileExists(path); err != nil {
-		return err
+	if path == defaultConfigPath() {
+		if err := ensureSettingsFileExists(path); err != nil {
+			return err
+		}
 	}
 
 	cfg.FileUsed = path
 
 	content, err := ioutil.ReadFile(path) // #nosec
+	if os.IsNotExist(err) && path != defaultConfigPath() {
+		content, err = nil, nil
+	}
 	if err != nil {
 		return err
 	}
@@ -109,20 +208,96 @@ func (cfg *Config) LoadFromDisk() error {
 		return nil
 	}
 
+	cfg.unprofiled = cfg.profileSettings()
+	if cfg.Profile != "" {
+		// A profile that isn't in the file yet starts without a token
+		cfg.applyProfile(cfg.Profiles[cfg.Profile])
+	}
+
 	return cfg.WithHTTPClient()
 }
 
-// WriteToDisk will write the runtime config instance to disk by serializing the YAML
+// HasProfile reports whether the config file has a profile called name.
+func (cfg *Config) HasProfile(name string) bool {
+	_, ok := cfg.Profiles[name]
+	return ok
+}
+
+// applyProfile replaces the settings at the top of the file with those of
+// profile.
+func (cfg *Config) applyProfile(profile Profile) {
+	if profile.Host != "" {
+		cfg.Host = profile.Host
+	}
+	if profile.Endpoint != "" {
+		cfg.Endpoint = profile.Endpoint
+	}
+	if profile.RestEndpoint != "" {
+		cfg.RestEndpoint = profile.RestEndpoint
+	}
+	if profile.DefaultOrgID != "" {
+		cfg.DefaultOrgID = profile.DefaultOrgID
+	}
+	cfg.Token = profile.Token
+	cfg.Keychain = profile.Keychain
+}
+
+// WriteToDisk will write the runtime config instance to disk by serializing the YAML.
+// When a profile is active its settings are saved to the profile, and those at the top of the file are kept.
 func (cfg *Config) WriteToDisk() error {
-	enc, err := yaml.Marshal(&cfg)
+	saved := *cfg
+	if saved.Keychain {
+		// The token is kept in the keychain instead
+		saved.Token = ""
+	}
+
+	if saved.Profile != "" {
+		saved.saveToProfile()
+	}
+
+	enc, err := yaml.Marshal(&saved)
 	if err != nil {
 		return err
 	}
 
+	if err := os.MkdirAll(filepath.Dir(cfg.FileUsed), 0700); err != nil {
+		return err
+	}
+
 	err = ioutil.WriteFile(cfg.FileUsed, enc, 0600)
 	return err
 }
 
+// profileSettings returns the settings of cfg that a profile can have.
+func (cfg *Config) profileSettings() Profile {
+	return Profile{
+		Host:         cfg.Host,
+		Endpoint:     cfg.Endpoint,
+		RestEndpoint: cfg.RestEndpoint,
+		Token:        cfg.Token,
+		Keychain:     cfg.Keychain,
+		DefaultOrgID: cfg.DefaultOrgID,
+	}
+}
+
+// saveToProfile moves the settings that belong to the active profile into it,
+// and puts back those that the profile replaced at the top of the file.
+func (cfg *Config) saveToProfile() {
+	profiles := map[string]Profile{}
+	for name, profile := range cfg.Profiles {
+		profiles[name] = profile
+	}
+	profiles[cfg.Profile] = cfg.profileSettings()
+	cfg.Profiles = profiles
+
+	cfg.Host = cfg.unprofiled.Host
+	cfg.Endpoint = cfg.unprofiled.Endpoint
+	cfg.RestEndpoint = cfg.unprofiled.RestEndpoint
+	cfg.Token = cfg.unprofiled.Token
+	cfg.Keychain = cfg.unprofiled.Keychain
+	cfg.DefaultOrgID = cfg.unprofiled.DefaultOrgID
+}
+
 // LoadFromEnv will read from environment variables of the given prefix for host, endpoint, and token specifically.
 func (cfg *Config) LoadFromEnv(prefix string) {
 	if host := ReadFromEnv(prefix, "host"); host != "" {
@@ -139,6 +314,11 @@ func (cfg *Config) LoadFromEnv(prefix string) {
 
 	if token := ReadFromEnv(prefix, "token"); token != "" {
 		cfg.Token = token
+		cfg.TokenSource = strings.ToUpper(prefix + "_token")
+	}
+
+	if caCert := ReadFromEnv(prefix, "ca_cert"); caCert != "" {
+		cfg.CACert = caCert
 	}
 }
 
@@ -155,10 +335,22 @@ func updateCheckFilename() string {
 
 // configFilename returns the name of the cli config file
 func configFilename() string {
-	// TODO: Make this configurable
 	return "cli.yml"
 }
 
+// ConfigPath returns the path of the CLI config file, which is
+// CIRCLECI_CLI_CONFIG when it is set.
+func ConfigPath() string {
+	if path := ReadFromEnv("circleci_cli", "config"); path != "" {
+		return path
+	}
+	return defaultConfigPath()
+}
+
+func defaultConfigPath() string {
+	return filepath.Join(SettingsPath(), configFilename())
+}
+
 // settingsPath returns the path of the CLI settings directory
 func SettingsPath() string {
 	// TODO: Make this configurable
@@ -166,6 +358,12 @@ func SettingsPath() string {
 	return path.Join(home, ".circleci")
 }
 
+// CachePath returns the path of the directory where the CLI caches API
+// responses, when the cache_ttl setting turns that on.
+func CachePath() string {
+	return filepath.Join(SettingsPath(), "cache")
+}
+
 // ensureSettingsFileExists does just that.
 func ensureSettingsFileExists(path string) error {
 	// TODO - handle invalid YAML config files.
@@ -222,15 +420,44 @@ func (cfg *Config) WithHTTPClient() error {
 		tlsConfig.RootCAs = pool
 	}
 
+	if cfg.CACert != "" {
+		pemData, err := ioutil.ReadFile(cfg.CACert)
+		if err != nil {
+			return fmt.Errorf("unable to read CA bundle: %s", err.Error())
+		}
+
+		// Unlike TLSCert, the bundle is trusted in addition to the system roots
+		pool := tlsConfig.RootCAs
+		if pool == nil {
+			pool, err = x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+		}
+		if !pool.AppendCertsFromPEM(pemData) {
+			return fmt.Errorf("unable to parse CA bundle %s: no PEM certificates found", cfg.CACert)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	var transport http.RoundTripper = &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ExpectContinueTimeout: 1 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          10,
+		TLSHandshakeTimeout:   10 * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}
+	// Every API client shares this transport, so --debug logs all of their
+	// requests in one place
+	if cfg.Debug {
+		transport = debug.NewTransport(transport, os.Stderr)
+	}
+
 	cfg.HTTPClient = &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			ExpectContinueTimeout: 1 * time.Second,
-			IdleConnTimeout:       90 * time.Second,
-			MaxIdleConns:          10,
-			TLSHandshakeTimeout:   10 * time.Second,
-			TLSClientConfig:       tlsConfig,
-		},
+		Timeout:   30 * time.Second,
+		Transport: transport,
 	}
 
 	return nil
@@ -2,12 +2,16 @@ package settings_test
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
 
 	"github.com/CircleCI-Public/circleci-cli/settings"
+	"github.com/zalando/go-keyring"
 )
 
 func TestWithHTTPClient(t *testing.T) {
@@ -80,3 +84,304 @@ func TestWithHTTPClient(t *testing.T) {
 		})
 	}
 }
+
+func TestWithHTTPClientCABundle(t *testing.T) {
+	table := []struct {
+		label  string
+		caCert string
+		expErr string
+	}{
+		{
+			label:  "should return an error when the bundle is missing",
+			caCert: "../clitest/missing.pem",
+			expErr: "unable to read CA bundle",
+		},
+		{
+			label:  "should return an error when the bundle isn't PEM",
+			caCert: "../clitest/clitest.go",
+			expErr: "unable to parse CA bundle ../clitest/clitest.go: no PEM certificates found",
+		},
+		{
+			label:  "should trust the bundle in addition to the system roots",
+			caCert: "../clitest/mockcert.pem",
+		},
+	}
+
+	for _, ts := range table {
+		t.Run(ts.label, func(t *testing.T) {
+			c := settings.Config{
+				CACert: ts.caCert,
+			}
+
+			err := c.WithHTTPClient()
+			if err != nil {
+				if ts.expErr == "" || !strings.Contains(err.Error(), ts.expErr) {
+					t.Fatalf("unexpected error: %s", err.Error())
+				}
+				return
+			}
+
+			if ts.expErr != "" {
+				t.Fatalf("unexpected nil error")
+			}
+
+			transport := c.HTTPClient.Transport.(*http.Transport)
+			if transport.TLSClientConfig.RootCAs == nil {
+				t.Fatalf("expected the bundle to be added to the root CAs")
+			}
+			if transport.Proxy == nil {
+				t.Fatalf("expected the proxy to be read from the environment")
+			}
+		})
+	}
+}
+
+func TestKeychainToken(t *testing.T) {
+	keyring.MockInit()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home) // windows
+	t.Setenv("CIRCLECI_CLI_TOKEN", "")
+
+	c := settings.Config{Host: "https://circleci.example.com"}
+	if err := c.LoadFromDisk(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	c.Token = "secret-token"
+	c.Keychain = true
+
+	if err := settings.SaveTokenToKeychain(c.Host, c.Token); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if err := c.WriteToDisk(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	saved, err := ioutil.ReadFile(c.FileUsed)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if strings.Contains(string(saved), "secret-token") {
+		t.Fatalf("expected the token to be left out of the config file, got:\n%s", saved)
+	}
+	if c.Token != "secret-token" {
+		t.Fatalf("expected writing to disk to keep the token in memory")
+	}
+
+	loaded := settings.Config{}
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if loaded.Token != "secret-token" {
+		t.Fatalf("expected the token to be read from the keychain, got %q", loaded.Token)
+	}
+
+	if err := settings.DeleteTokenFromKeychain(c.Host); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if err := settings.DeleteTokenFromKeychain(c.Host); err != nil {
+		t.Fatalf("expected deleting a missing token to succeed, got %s", err.Error())
+	}
+
+	loaded = settings.Config{}
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if loaded.Token != "" {
+		t.Fatalf("expected no token once it was removed, got %q", loaded.Token)
+	}
+}
+
+func TestKeychainTokenForEffectiveHost(t *testing.T) {
+	keyring.MockInit()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home) // windows
+	t.Setenv("CIRCLECI_CLI_TOKEN", "")
+	t.Setenv("CIRCLECI_CLI_HOST", "")
+
+	c := settings.Config{Host: "https://circleci.com", Keychain: true}
+	if err := c.LoadFromDisk(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if err := c.WriteToDisk(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	for host, token := range map[string]string{
+		"https://circleci.com":         "cloud-token",
+		"https://circleci.example.com": "server-token",
+		"https://staging.example.com":  "staging-token",
+	} {
+		if err := settings.SaveTokenToKeychain(host, token); err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+	}
+
+	t.Setenv("CIRCLECI_CLI_HOST", "https://circleci.example.com")
+	loaded := settings.Config{}
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if loaded.Token != "server-token" {
+		t.Fatalf("expected the token for CIRCLECI_CLI_HOST, got %q", loaded.Token)
+	}
+
+	// As when --host is given
+	loaded.Host = "https://staging.example.com"
+	loaded.LoadTokenFromKeychain()
+	if loaded.Token != "staging-token" || loaded.TokenSource != "the keychain" {
+		t.Fatalf("expected the token for the new host from the keychain, got %q from %s", loaded.Token, loaded.TokenSource)
+	}
+
+	loaded.Token, loaded.TokenSource = "flag-token", "--token"
+	loaded.LoadTokenFromKeychain()
+	if loaded.Token != "flag-token" {
+		t.Fatalf("expected a token given otherwise to be kept, got %q", loaded.Token)
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home) // windows
+
+	t.Setenv("CIRCLECI_CLI_CONFIG", "")
+	if path := settings.ConfigPath(); path != filepath.Join(home, ".circleci", "cli.yml") {
+		t.Fatalf("expected the default config file, got %s", path)
+	}
+
+	custom := filepath.Join(home, "orgs", "other.yml")
+	t.Setenv("CIRCLECI_CLI_CONFIG", custom)
+	if path := settings.ConfigPath(); path != custom {
+		t.Fatalf("expected CIRCLECI_CLI_CONFIG to be used, got %s", path)
+	}
+
+	c := settings.Config{}
+	if err := c.LoadFromDisk(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if c.FileUsed != custom {
+		t.Fatalf("expected %s to be loaded, got %s", custom, c.FileUsed)
+	}
+	if _, err := os.Stat(custom); !os.IsNotExist(err) {
+		t.Fatalf("expected %s not to be created by loading it", custom)
+	}
+
+	c.Token = "other-token"
+	if err := c.WriteToDisk(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	saved, err := ioutil.ReadFile(custom)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !strings.Contains(string(saved), "token: other-token") {
+		t.Fatalf("expected the token to be saved, got:\n%s", saved)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home) // windows
+	t.Setenv("CIRCLECI_CLI_CONFIG", "")
+	t.Setenv("CIRCLECI_CLI_TOKEN", "")
+	t.Setenv("CIRCLECI_CLI_HOST", "")
+
+	path := filepath.Join(home, ".circleci", "cli.yml")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	err := ioutil.WriteFile(path, []byte(`
+host: https://circleci.com
+token: prod-token
+profiles:
+  staging:
+    host: https://staging.example.com
+    token: staging-token
+`), 0600)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	t.Setenv("CIRCLECI_CLI_PROFILE", "staging")
+	c := settings.Config{}
+	if err := c.Load(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if c.Host != "https://staging.example.com" || c.Token != "staging-token" {
+		t.Fatalf("expected the staging profile to be used, got %s and %s", c.Host, c.Token)
+	}
+
+	t.Setenv("CIRCLECI_CLI_TOKEN", "env-token")
+	c = settings.Config{}
+	if err := c.Load(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if c.Token != "env-token" {
+		t.Fatalf("expected CIRCLECI_CLI_TOKEN to win over the profile, got %s", c.Token)
+	}
+	t.Setenv("CIRCLECI_CLI_TOKEN", "")
+
+	c = settings.Config{Profile: "local"}
+	if err := c.Load(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if c.Token != "" {
+		t.Fatalf("expected a new profile to start without a token, got %s", c.Token)
+	}
+	c.Host = "http://localhost:3000"
+	c.Token = "local-token"
+	if err := c.WriteToDisk(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	t.Setenv("CIRCLECI_CLI_PROFILE", "")
+	c = settings.Config{}
+	if err := c.Load(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if c.Host != "https://circleci.com" || c.Token != "prod-token" {
+		t.Fatalf("expected the top of the file to be kept, got %s and %s", c.Host, c.Token)
+	}
+	if !c.HasProfile("staging") || c.Profiles["local"].Token != "local-token" {
+		t.Fatalf("expected the local profile to be saved next to staging, got %v", c.Profiles)
+	}
+}
+
+func TestLoadTokenFile(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv("CIRCLECI_CLI_TOKEN_FILE", "")
+	c := settings.Config{Token: "file-token"}
+	if err := c.LoadTokenFile("circleci_cli"); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if c.Token != "file-token" {
+		t.Fatalf("expected the token to be kept without CIRCLECI_CLI_TOKEN_FILE, got %s", c.Token)
+	}
+
+	path := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(path, []byte("  secret-token\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	t.Setenv("CIRCLECI_CLI_TOKEN_FILE", path)
+	if err := c.LoadTokenFile("circleci_cli"); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if c.Token != "secret-token" || c.TokenSource != "CIRCLECI_CLI_TOKEN_FILE" {
+		t.Fatalf("expected the token from the file, got %s from %s", c.Token, c.TokenSource)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if err := c.LoadTokenFile("circleci_cli"); err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Fatalf("expected an error for an empty token file, got %v", err)
+	}
+
+	t.Setenv("CIRCLECI_CLI_TOKEN_FILE", filepath.Join(dir, "missing"))
+	if err := c.LoadTokenFile("circleci_cli"); err == nil || !strings.Contains(err.Error(), "unable to read the token from CIRCLECI_CLI_TOKEN_FILE") {
+		t.Fatalf("expected an error for a missing token file, got %v", err)
+	}
+}
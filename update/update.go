@@ -3,7 +3,9 @@ package update
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -54,6 +56,65 @@ func CheckForUpdates(githubAPI, slug, current, packageManager string) (*Options,
 	return check, err
 }
 
+// CheckForVersion will look up the given release of the CLI, so that it can
+// be installed in place of the current version, even when it is older.
+func CheckForVersion(githubAPI, slug, current, packageManager, target string) (*Options, error) {
+	targetVersion, err := ParseTargetVersion(target)
+	if err != nil {
+		return nil, err
+	}
+
+	currentVersion, err := semver.Parse(current)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse current version")
+	}
+
+	check := &Options{
+		Current:        currentVersion,
+		PackageManager: packageManager,
+
+		githubAPI: githubAPI,
+		slug:      slug,
+	}
+
+	if check.PackageManager == "homebrew" {
+		return nil, fmt.Errorf("installing a specific version isn't supported when the CLI was installed with homebrew, try `brew install circleci@%s`", targetVersion)
+	}
+
+	updater, err := selfupdate.NewUpdater(selfupdate.Config{
+		EnterpriseBaseURL: check.githubAPI,
+	})
+	if err != nil {
+		return nil, err
+	}
+	check.updater = updater
+
+	// Releases of the CLI are tagged with a leading v
+	release, found, err := updater.DetectVersion(slug, "v"+targetVersion.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to query the GitHub API for version %s", targetVersion)
+	}
+	if !found {
+		return nil, fmt.Errorf("version %s of the CLI was not found, check https://github.com/%s/releases for the released versions", targetVersion, slug)
+	}
+
+	check.Latest = release
+	check.Found = true
+
+	return check, nil
+}
+
+// ParseTargetVersion parses a version given by the user, such as v0.1.2 or
+// 0.1.2, refusing anything that isn't a full semver version.
+func ParseTargetVersion(target string) (semver.Version, error) {
+	version, err := semver.Parse(strings.TrimPrefix(strings.TrimSpace(target), "v"))
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("invalid version %q, expected a version such as v0.1.2: %s", target, err)
+	}
+
+	return version, nil
+}
+
 func checkFromSource(check *Options) error {
 	updater, err := selfupdate.NewUpdater(selfupdate.Config{
 		EnterpriseBaseURL: check.githubAPI,
@@ -167,6 +228,10 @@ type Options struct {
 	Latest         *selfupdate.Release
 	PackageManager string
 
+	// VerifySignature makes installing an update also check the signature of
+	// the checksums published with the release.
+	VerifySignature bool
+
 	updater   *selfupdate.Updater
 	githubAPI string
 	slug      string
@@ -213,12 +278,47 @@ func IsLatestVersion(opts *Options) bool {
 
 // InstallLatest will execute the updater and replace the current CLI with the latest version available.
 func InstallLatest(opts *Options) (string, error) {
-	release, err := opts.updater.UpdateSelf(opts.Current, opts.slug)
+	cmdPath, err := executablePath()
 	if err != nil {
+		return "", err
+	}
+
+	if err := installRelease(opts, cmdPath); err != nil {
 		return "", errors.Wrap(err, "failed to install update")
 	}
 
-	return fmt.Sprintf("Updated to %s", release.Version), nil
+	return fmt.Sprintf("Updated to %s", opts.Latest.Version), nil
+}
+
+// InstallVersion will replace the current CLI with the release found by
+// CheckForVersion, which may be older than the current version.
+func InstallVersion(opts *Options) (string, error) {
+	cmdPath, err := executablePath()
+	if err != nil {
+		return "", err
+	}
+
+	if err := installRelease(opts, cmdPath); err != nil {
+		return "", errors.Wrapf(err, "failed to install version %s", opts.Latest.Version)
+	}
+
+	return fmt.Sprintf("Updated from %s to %s", opts.Current, opts.Latest.Version), nil
+}
+
+// executablePath returns the path of the running CLI, following symlinks so
+// that the binary is replaced rather than the link.
+func executablePath() (string, error) {
+	cmdPath, err := os.Executable()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to find the path of the CLI")
+	}
+
+	cmdPath, err = filepath.EvalSymlinks(cmdPath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to find the path of the CLI")
+	}
+
+	return cmdPath, nil
 }
 
 // DebugVersion returns a nicely formatted string representing the state of the current version.
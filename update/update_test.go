@@ -29,3 +29,23 @@ var _ = Describe("Homebrew Version Parsing", func() {
 		Expect(err).To(MatchError(MatchRegexp("asdad.1231.-_")))
 	})
 })
+
+var _ = Describe("Target Version Parsing", func() {
+
+	It("Should parse versions with or without a leading v", func() {
+		version, err := update.ParseTargetVersion("v0.1.2")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(version.String()).To(Equal("0.1.2"))
+
+		version, err = update.ParseTargetVersion("0.1.2")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(version.String()).To(Equal("0.1.2"))
+	})
+
+	It("Should refuse invalid versions", func() {
+		for _, target := range []string{"", "latest", "v1", "1.2", "vv1.2.3"} {
+			_, err := update.ParseTargetVersion(target)
+			Expect(err).To(MatchError(MatchRegexp("invalid version")))
+		}
+	})
+})
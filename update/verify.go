@@ -0,0 +1,241 @@
+package update
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/inconshreveable/go-update"
+	"github.com/pkg/errors"
+	"github.com/rhysd/go-github-selfupdate/selfupdate"
+)
+
+// githubRelease is the part of the GitHub API's description of a release we
+// need to find the checksums published alongside the binaries.
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"assets"`
+}
+
+// releaseAsset is a file attached to a release.
+type releaseAsset struct {
+	ID   int64
+	Name string
+}
+
+func (release githubRelease) asset(match func(releaseAsset) bool) (releaseAsset, bool) {
+	for _, a := range release.Assets {
+		asset := releaseAsset{ID: a.ID, Name: a.Name}
+		if match(asset) {
+			return asset, true
+		}
+	}
+	return releaseAsset{}, false
+}
+
+// installRelease downloads the release opts.Latest refers to and replaces the
+// binary at cmdPath with it, but only once the download matches the checksum
+// published with the release. Everything is downloaded into a temporary
+// directory which is removed whether or not the install succeeds.
+func installRelease(opts *Options, cmdPath string) error {
+	tag := "v" + opts.Latest.Version.String()
+	release, err := fetchRelease(opts.githubAPI, opts.slug, tag)
+	if err != nil {
+		return err
+	}
+
+	binary, ok := release.asset(func(a releaseAsset) bool { return a.ID == opts.Latest.AssetID })
+	if !ok {
+		return fmt.Errorf("asset %d is missing from release %s", opts.Latest.AssetID, tag)
+	}
+	checksums, ok := release.asset(func(a releaseAsset) bool { return strings.HasSuffix(a.Name, "checksums.txt") })
+	if !ok {
+		return fmt.Errorf("release %s doesn't publish a checksums file, refusing to install it", tag)
+	}
+
+	dir, err := ioutil.TempDir("", "circleci-cli-update")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a directory for the download")
+	}
+	defer os.RemoveAll(dir)
+
+	binaryPath := filepath.Join(dir, filepath.Base(binary.Name))
+	if err := downloadAsset(opts, binary, binaryPath); err != nil {
+		return err
+	}
+	checksumsPath := filepath.Join(dir, filepath.Base(checksums.Name))
+	if err := downloadAsset(opts, checksums, checksumsPath); err != nil {
+		return err
+	}
+
+	if opts.VerifySignature {
+		signature, ok := release.asset(func(a releaseAsset) bool {
+			return a.Name == checksums.Name+".sig" || a.Name == checksums.Name+".asc"
+		})
+		if !ok {
+			return fmt.Errorf("release %s doesn't publish a signature for %s", tag, checksums.Name)
+		}
+		signaturePath := filepath.Join(dir, filepath.Base(signature.Name))
+		if err := downloadAsset(opts, signature, signaturePath); err != nil {
+			return err
+		}
+		if err := verifySignature(signaturePath, checksumsPath); err != nil {
+			return err
+		}
+	}
+
+	if err := verifyChecksum(binaryPath, binary.Name, checksumsPath); err != nil {
+		return err
+	}
+
+	file, err := os.Open(binaryPath) // #nosec
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// As with selfupdate, the archive format is taken from the download URL
+	_, cmd := filepath.Split(cmdPath)
+	executable, err := selfupdate.UncompressCommand(file, opts.Latest.AssetURL, cmd)
+	if err != nil {
+		return err
+	}
+
+	// Apply writes the new binary next to the old one before swapping them,
+	// rolling back if the swap fails.
+	return update.Apply(executable, update.Options{
+		TargetPath: cmdPath,
+	})
+}
+
+// githubGet makes a request to the GitHub API, authenticated with
+// $GITHUB_TOKEN when it is set.
+func githubGet(githubAPI, path, accept string) (*http.Response, error) {
+	url := strings.TrimSuffix(githubAPI, "/") + path
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s", url)
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("failed to get %s: %s", url, res.Status)
+	}
+
+	return res, nil
+}
+
+func fetchRelease(githubAPI, slug, tag string) (githubRelease, error) {
+	var release githubRelease
+
+	res, err := githubGet(githubAPI, fmt.Sprintf("/repos/%s/releases/tags/%s", slug, tag), "application/vnd.github.v3+json")
+	if err != nil {
+		return release, errors.Wrapf(err, "failed to look up release %s", tag)
+	}
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(&release); err != nil {
+		return release, errors.Wrapf(err, "failed to parse release %s", tag)
+	}
+
+	return release, nil
+}
+
+func downloadAsset(opts *Options, asset releaseAsset, path string) error {
+	res, err := githubGet(opts.githubAPI, fmt.Sprintf("/repos/%s/releases/assets/%d", opts.slug, asset.ID), "application/octet-stream")
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %s", asset.Name)
+	}
+	defer res.Body.Close()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, res.Body); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "failed to download %s", asset.Name)
+	}
+
+	return file.Close()
+}
+
+// verifyChecksum compares the SHA256 of the file at path with the one listed
+// for name in the checksums file, which has a "<sha256>  <name>" line for
+// each asset of the release.
+func verifyChecksum(path, name, checksumsPath string) error {
+	checksums, err := os.Open(checksumsPath) // #nosec
+	if err != nil {
+		return err
+	}
+	defer checksums.Close()
+
+	expected := ""
+	scanner := bufio.NewScanner(checksums)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			expected = strings.ToLower(fields[0])
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "failed to read the checksums file")
+	}
+	if expected == "" {
+		return fmt.Errorf("no checksum is published for %s, refusing to install it", name)
+	}
+
+	file, err := os.Open(path) // #nosec
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s. The download may be corrupt or have been tampered with, so it was not installed", name, expected, actual)
+	}
+
+	return nil
+}
+
+// verifySignature checks the detached signature of the checksums file with
+// gpg, which must already trust the key the release was signed with.
+func verifySignature(signaturePath, checksumsPath string) error {
+	gpg, err := exec.LookPath("gpg")
+	if err != nil {
+		return errors.Wrap(err, "Expected to find `gpg` in your $PATH to verify the signature but wasn't able to find it")
+	}
+
+	command := exec.Command(gpg, "--verify", signaturePath, checksumsPath) // #nosec
+	if out, err := command.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to verify the signature of the checksums file:\n%s", out)
+	}
+
+	return nil
+}
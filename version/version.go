@@ -12,6 +12,8 @@ var (
 	Version = "0.0.0-dev"
 	// Commit is the current git commit SHA
 	Commit = "dirty-local-tree"
+	// Date is when the binary was built, in RFC3339
+	Date = "unknown"
 )
 
 // PackageManager defines the package manager which was used to install the CLI.